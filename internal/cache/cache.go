@@ -0,0 +1,195 @@
+// Package cache caches the generated cover letters, so identical generation requests are not sent to the LLM provider again
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendStore  = "store"
+)
+
+// Cache stores generated cover letters by the key of their generation request
+type Cache interface {
+	// Get returns the cover letter of a key, and whether it was found and has not expired
+	Get(key string) (*types.GeneratedCoverLetter, bool, error)
+	Set(key string, coverLetter *types.GeneratedCoverLetter) error
+}
+
+// Config holds the cache settings, read from env variables
+type Config struct {
+	// Backend is "memory", "store", or empty when the cache is disabled
+	Backend string
+	TTL     time.Duration
+	// Size is the maximum number of cover letters of the memory cache
+	Size int
+}
+
+// ConfigFromEnv returns the cache configuration from env variables, using defaults when not set
+func ConfigFromEnv() Config {
+	config := Config{
+		Backend: strings.TrimSpace(os.Getenv("COVER_LETTER_CACHE")),
+		TTL:     24 * time.Hour,
+		Size:    1000,
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("COVER_LETTER_CACHE_TTL")); err == nil && ttl > 0 {
+		config.TTL = ttl
+	}
+	if size, err := strconv.Atoi(os.Getenv("COVER_LETTER_CACHE_SIZE")); err == nil && size > 0 {
+		config.Size = size
+	}
+	return config
+}
+
+// New returns the cache of the configured backend, or nil when the cache is disabled
+func New(store CoverLetterStore, config Config) Cache {
+	switch config.Backend {
+	case BackendMemory:
+		return NewMemoryCache(config.Size, config.TTL)
+	case BackendStore:
+		if store != nil {
+			return NewStoreCache(store, config.TTL)
+		}
+	}
+	return nil
+}
+
+// Key returns the cache key of a cover letter generation: a hash of the career profile, job posting, options,
+// prompt template version and model, so the key changes when any of them does
+func Key(careerProfile *types.CareerProfile, jobPosting *types.JobPosting, options *types.CoverLetterOptions, promptVersion string, model string) string {
+	data, _ := json.Marshal(struct {
+		CareerProfile *types.CareerProfile      `json:"career_profile"`
+		JobPosting    *types.JobPosting         `json:"job_posting"`
+		Options       *types.CoverLetterOptions `json:"options"`
+		PromptVersion string                    `json:"prompt_version"`
+		Model         string                    `json:"model"`
+	}{careerProfile, jobPosting, options, promptVersion, model})
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// entry is a cover letter of the memory cache
+type entry struct {
+	key         string
+	coverLetter types.GeneratedCoverLetter
+	expiresAt   time.Time
+}
+
+// MemoryCache is an in-memory least recently used Cache, for a single API instance
+type MemoryCache struct {
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	// recent holds the entries from the most to the least recently used
+	recent *list.List
+	mutex  sync.Mutex
+	now    func() time.Time
+}
+
+// NewMemoryCache returns an in-memory Cache of at most size cover letters, which expire after the ttl
+func NewMemoryCache(size int, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		size:    size,
+		ttl:     ttl,
+		entries: map[string]*list.Element{},
+		recent:  list.New(),
+		now:     time.Now,
+	}
+}
+
+// Get returns the cover letter of a key, marking it as the most recently used
+func (m *MemoryCache) Get(key string) (*types.GeneratedCoverLetter, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	cached := element.Value.(*entry)
+	if !m.now().Before(cached.expiresAt) {
+		m.recent.Remove(element)
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	m.recent.MoveToFront(element)
+	coverLetter := cached.coverLetter
+	return &coverLetter, true, nil
+}
+
+// Set saves the cover letter of a key, evicting the least recently used cover letter when the cache is full
+func (m *MemoryCache) Set(key string, coverLetter *types.GeneratedCoverLetter) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	cached := &entry{key: key, coverLetter: *coverLetter, expiresAt: m.now().Add(m.ttl)}
+	if element, ok := m.entries[key]; ok {
+		element.Value = cached
+		m.recent.MoveToFront(element)
+		return nil
+	}
+	m.entries[key] = m.recent.PushFront(cached)
+	for m.recent.Len() > m.size {
+		oldest := m.recent.Back()
+		m.recent.Remove(oldest)
+		delete(m.entries, oldest.Value.(*entry).key)
+	}
+	return nil
+}
+
+// CoverLetterStore persists cached cover letters, so that the cache is shared between API instances
+type CoverLetterStore interface {
+	GetCachedCoverLetter(key string) (*types.CachedCoverLetter, error)
+	StoreCachedCoverLetter(cachedCoverLetter *types.CachedCoverLetter) error
+}
+
+// StoreCache is a Cache backed by the store
+type StoreCache struct {
+	store CoverLetterStore
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewStoreCache returns a Cache backed by the store, where the cover letters expire after the ttl
+func NewStoreCache(store CoverLetterStore, ttl time.Duration) *StoreCache {
+	return &StoreCache{
+		store: store,
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+// Get returns the cover letter of a key from the store
+func (s *StoreCache) Get(key string) (*types.GeneratedCoverLetter, bool, error) {
+	cached, err := s.store.GetCachedCoverLetter(key)
+	if err != nil && strings.Contains(err.Error(), "no document") {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if !s.now().Before(cached.ExpiresAt) {
+		return nil, false, nil
+	}
+	return &cached.CoverLetter, true, nil
+}
+
+// Set saves the cover letter of a key in the store
+func (s *StoreCache) Set(key string, coverLetter *types.GeneratedCoverLetter) error {
+	return s.store.StoreCachedCoverLetter(&types.CachedCoverLetter{
+		Key:         key,
+		CoverLetter: *coverLetter,
+		ExpiresAt:   s.now().Add(s.ttl),
+	})
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewMemoryCache(2, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	t.Run("returns the cached cover letters", func(t *testing.T) {
+		assert.NoError(t, cache.Set("first", &types.GeneratedCoverLetter{Content: "first cover letter"}))
+
+		coverLetter, found, err := cache.Get("first")
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "first cover letter", coverLetter.Content)
+
+		_, found, err = cache.Get("missing")
+		assert.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("evicts the least recently used cover letter", func(t *testing.T) {
+		assert.NoError(t, cache.Set("second", &types.GeneratedCoverLetter{Content: "second cover letter"}))
+		_, found, _ := cache.Get("first")
+		assert.True(t, found)
+		assert.NoError(t, cache.Set("third", &types.GeneratedCoverLetter{Content: "third cover letter"}))
+
+		_, found, _ = cache.Get("second")
+		assert.False(t, found)
+		_, found, _ = cache.Get("first")
+		assert.True(t, found)
+		_, found, _ = cache.Get("third")
+		assert.True(t, found)
+	})
+
+	t.Run("expires the cover letters after the ttl", func(t *testing.T) {
+		now = now.Add(time.Hour)
+
+		_, found, _ := cache.Get("first")
+		assert.False(t, found)
+		assert.Equal(t, 1, cache.recent.Len())
+	})
+}
+
+// fakeCoverLetterStore is an in-memory CoverLetterStore
+type fakeCoverLetterStore struct {
+	cachedCoverLetters map[string]types.CachedCoverLetter
+	err                error
+}
+
+func (f *fakeCoverLetterStore) GetCachedCoverLetter(key string) (*types.CachedCoverLetter, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	cachedCoverLetter, ok := f.cachedCoverLetters[key]
+	if !ok {
+		return nil, errors.New("mongo: no documents in result")
+	}
+	return &cachedCoverLetter, nil
+}
+
+func (f *fakeCoverLetterStore) StoreCachedCoverLetter(cachedCoverLetter *types.CachedCoverLetter) error {
+	f.cachedCoverLetters[cachedCoverLetter.Key] = *cachedCoverLetter
+	return f.err
+}
+
+func TestStoreCache(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeCoverLetterStore{cachedCoverLetters: map[string]types.CachedCoverLetter{}}
+	cache := NewStoreCache(store, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	assert.NoError(t, cache.Set("key", &types.GeneratedCoverLetter{Content: "perfect cover letter"}))
+	assert.Equal(t, now.Add(time.Hour), store.cachedCoverLetters["key"].ExpiresAt)
+
+	coverLetter, found, err := cache.Get("key")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "perfect cover letter", coverLetter.Content)
+
+	_, found, err = cache.Get("missing")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	now = now.Add(time.Hour)
+	_, found, err = cache.Get("key")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	store.err = errors.New("store unavailable")
+	_, _, err = cache.Get("key")
+	assert.Error(t, err)
+}
+
+func TestKey(t *testing.T) {
+	careerProfile := &types.CareerProfile{ID: uuid.New(), FirstName: "John", Headline: "Manager"}
+	jobPosting := &types.JobPosting{CompanyName: "Acme", JobRole: "Manager"}
+	options := &types.CoverLetterOptions{Tone: "formal"}
+	key := Key(careerProfile, jobPosting, options, "cover_letter/v2", "gpt-3.5-turbo")
+
+	assert.Len(t, key, 64)
+	assert.Equal(t, key, Key(careerProfile, &types.JobPosting{CompanyName: "Acme", JobRole: "Manager"}, &types.CoverLetterOptions{Tone: "formal"}, "cover_letter/v2", "gpt-3.5-turbo"))
+	assert.NotEqual(t, key, Key(&types.CareerProfile{ID: careerProfile.ID, FirstName: "John", Headline: "Director"}, jobPosting, options, "cover_letter/v2", "gpt-3.5-turbo"))
+	assert.NotEqual(t, key, Key(careerProfile, jobPosting, &types.CoverLetterOptions{Tone: "concise"}, "cover_letter/v2", "gpt-3.5-turbo"))
+	assert.NotEqual(t, key, Key(careerProfile, jobPosting, options, "cover_letter/v1", "gpt-3.5-turbo"))
+	assert.NotEqual(t, key, Key(careerProfile, jobPosting, options, "cover_letter/v2", "gpt-4"))
+}
+
+func TestNew(t *testing.T) {
+	assert.Nil(t, New(nil, Config{}))
+	assert.IsType(t, &MemoryCache{}, New(nil, Config{Backend: BackendMemory, Size: 10, TTL: time.Hour}))
+	assert.IsType(t, &StoreCache{}, New(&fakeCoverLetterStore{}, Config{Backend: BackendStore, TTL: time.Hour}))
+	assert.Nil(t, New(nil, Config{Backend: BackendStore, TTL: time.Hour}))
+}
@@ -0,0 +1,65 @@
+// Package diff compares the versions of a text line by line.
+package diff
+
+import "strings"
+
+// Line prefixes of a diff
+const (
+	Unchanged = "  "
+	Removed   = "- "
+	Added     = "+ "
+)
+
+// Lines returns a line diff from previous to current, where each line is prefixed with Unchanged, Removed or Added.
+// It returns an empty string when both texts are equal.
+func Lines(previous string, current string) string {
+	if previous == current {
+		return ""
+	}
+	previousLines := strings.Split(previous, "\n")
+	currentLines := strings.Split(current, "\n")
+
+	// common[i][j] is the length of the longest common subsequence of previousLines[i:] and currentLines[j:]
+	common := make([][]int, len(previousLines)+1)
+	for i := range common {
+		common[i] = make([]int, len(currentLines)+1)
+	}
+	for i := len(previousLines) - 1; i >= 0; i-- {
+		for j := len(currentLines) - 1; j >= 0; j-- {
+			if previousLines[i] == currentLines[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var builder strings.Builder
+	writeLine := func(prefix string, line string) {
+		builder.WriteString(prefix)
+		builder.WriteString(line)
+		builder.WriteString("\n")
+	}
+	i, j := 0, 0
+	for i < len(previousLines) && j < len(currentLines) {
+		switch {
+		case previousLines[i] == currentLines[j]:
+			writeLine(Unchanged, previousLines[i])
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			writeLine(Removed, previousLines[i])
+			i++
+		default:
+			writeLine(Added, currentLines[j])
+			j++
+		}
+	}
+	for ; i < len(previousLines); i++ {
+		writeLine(Removed, previousLines[i])
+	}
+	for ; j < len(currentLines); j++ {
+		writeLine(Added, currentLines[j])
+	}
+	return builder.String()
+}
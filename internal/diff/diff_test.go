@@ -0,0 +1,25 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	t.Run("changed lines", func(t *testing.T) {
+		previous := "Dear Hiring Manager,\n\nI am a great fit.\nI led a team of 5.\n\nSincerely,"
+		current := "Dear Hiring Manager,\n\nI am a great fit.\nI led a team of 12 engineers.\nI planned the roadmap.\n\nSincerely,"
+
+		expected := "  Dear Hiring Manager,\n  \n  I am a great fit.\n- I led a team of 5.\n+ I led a team of 12 engineers.\n+ I planned the roadmap.\n  \n  Sincerely,\n"
+		assert.Equal(t, expected, Lines(previous, current))
+	})
+
+	t.Run("equal texts", func(t *testing.T) {
+		assert.Equal(t, "", Lines("Dear Hiring Manager,", "Dear Hiring Manager,"))
+	})
+
+	t.Run("removed ending", func(t *testing.T) {
+		assert.Equal(t, "  first\n- second\n- third\n", Lines("first\nsecond\nthird", "first"))
+	})
+}
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+</Types>`
+
+const docxRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+</Relationships>`
+
+// docxParagraph is the formatting of a DOCX paragraph
+type docxParagraph struct {
+	// Size is the font size in half-points
+	Size         int
+	Bold         bool
+	Color        string
+	BorderBottom bool
+	// SpacingAfter is the space after the paragraph in twentieths of a point
+	SpacingAfter int
+}
+
+// renderDOCX returns the letter as a Word document, with the letterhead separated from the body by a border
+func renderDOCX(letter *Letter) ([]byte, error) {
+	var body strings.Builder
+	writeParagraph(&body, letter.Name, docxParagraph{Size: 40, Bold: true, SpacingAfter: 80})
+	writeParagraph(&body, strings.Join(letter.Contact, "  |  "), docxParagraph{Size: 20, Color: "555555", BorderBottom: true, SpacingAfter: 480})
+	writeParagraph(&body, letter.Date, docxParagraph{Size: 22, SpacingAfter: 240})
+	writeParagraph(&body, strings.Join(letter.Recipient, "\n"), docxParagraph{Size: 22, SpacingAfter: 240})
+	for _, paragraph := range letter.Paragraphs {
+		writeParagraph(&body, paragraph, docxParagraph{Size: 22, SpacingAfter: 240})
+	}
+	return docxPackage(body.String())
+}
+
+// renderResumeDOCX returns the resume as a Word document, with the header of the letters and a bold title for each section
+func renderResumeDOCX(resume *Resume) ([]byte, error) {
+	var body strings.Builder
+	writeParagraph(&body, resume.Name, docxParagraph{Size: 40, Bold: true, SpacingAfter: 80})
+	if resume.Headline != "" {
+		writeParagraph(&body, resume.Headline, docxParagraph{Size: 24, SpacingAfter: 80})
+	}
+	writeParagraph(&body, strings.Join(resume.Contact, "  |  "), docxParagraph{Size: 20, Color: "555555", BorderBottom: true, SpacingAfter: 240})
+	if resume.Summary != "" {
+		writeParagraph(&body, "Summary", docxParagraph{Size: 24, Bold: true, SpacingAfter: 80})
+		writeParagraph(&body, resume.Summary, docxParagraph{Size: 22, SpacingAfter: 240})
+	}
+	if len(resume.Skills) > 0 {
+		writeParagraph(&body, "Skills", docxParagraph{Size: 24, Bold: true, SpacingAfter: 80})
+		writeParagraph(&body, strings.Join(resume.Skills, ", "), docxParagraph{Size: 22, SpacingAfter: 240})
+	}
+	if len(resume.Experience) > 0 {
+		writeParagraph(&body, "Experience", docxParagraph{Size: 24, Bold: true, SpacingAfter: 80})
+		for _, position := range resume.Experience {
+			writeParagraph(&body, position.Heading, docxParagraph{Size: 22, Bold: true, SpacingAfter: 0})
+			writeParagraph(&body, position.Details, docxParagraph{Size: 20, Color: "555555", SpacingAfter: 80})
+			for i, bullet := range position.Bullets {
+				// The last bullet point separates the position from the next one
+				spacingAfter := 40
+				if i == len(position.Bullets)-1 {
+					spacingAfter = 200
+				}
+				writeParagraph(&body, "• "+bullet, docxParagraph{Size: 22, SpacingAfter: spacingAfter})
+			}
+		}
+	}
+	return docxPackage(body.String())
+}
+
+// docxPackage returns the Word document with the given body, on US Letter pages with 1 inch margins
+func docxPackage(body string) ([]byte, error) {
+	document := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` + body +
+		`<w:sectPr><w:pgSz w:w="12240" w:h="15840"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="720" w:footer="720" w:gutter="0"/></w:sectPr></w:body></w:document>`
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for _, file := range []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRelationships},
+		{"word/document.xml", document},
+	} {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write([]byte(file.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// writeParagraph writes a DOCX paragraph with the text, where the new lines are line breaks
+func writeParagraph(builder *strings.Builder, text string, format docxParagraph) {
+	builder.WriteString("<w:p><w:pPr>")
+	if format.BorderBottom {
+		builder.WriteString(`<w:pBdr><w:bottom w:val="single" w:sz="6" w:space="6" w:color="999999"/></w:pBdr>`)
+	}
+	builder.WriteString(`<w:spacing w:after="` + strconv.Itoa(format.SpacingAfter) + `"/></w:pPr>`)
+
+	runProperties := `<w:rPr><w:rFonts w:ascii="Helvetica" w:hAnsi="Helvetica"/>`
+	if format.Bold {
+		runProperties += "<w:b/>"
+	}
+	if format.Color != "" {
+		runProperties += `<w:color w:val="` + format.Color + `"/>`
+	}
+	runProperties += `<w:sz w:val="` + strconv.Itoa(format.Size) + `"/></w:rPr>`
+
+	for i, line := range strings.Split(text, "\n") {
+		builder.WriteString("<w:r>" + runProperties)
+		if i > 0 {
+			builder.WriteString("<w:br/>")
+		}
+		builder.WriteString(`<w:t xml:space="preserve">`)
+		_ = xml.EscapeText(builder, []byte(line))
+		builder.WriteString("</w:t></w:r>")
+	}
+	builder.WriteString("</w:p>")
+}
@@ -0,0 +1,163 @@
+// Package export renders cover letters as documents with a letterhead layout, and tailored resumes with the same header.
+//
+// Every renderer is written in pure Go without external services, so the exports work offline:
+// PDF uses the standard Helvetica fonts, and DOCX is a minimal Office Open XML package.
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+const (
+	FormatPDF      = "pdf"
+	FormatDOCX     = "docx"
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatText     = "txt"
+)
+
+// Formats are the supported export formats
+var Formats = []string{FormatPDF, FormatDOCX, FormatMarkdown, FormatHTML, FormatText}
+
+var contentTypes = map[string]string{
+	FormatPDF:      "application/pdf",
+	FormatDOCX:     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	FormatMarkdown: "text/markdown; charset=utf-8",
+	FormatHTML:     "text/html; charset=utf-8",
+	FormatText:     "text/plain; charset=utf-8",
+}
+
+// ContentType returns the MIME type of an export format
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// Letter is a cover letter split into the parts of the letterhead layout
+type Letter struct {
+	Name string
+	// Contact are the address, email, phone and website of the letterhead, omitting the empty ones
+	Contact   []string
+	Date      string
+	Recipient []string
+	// Paragraphs of the body, from the greeting to the signature, which can contain line breaks
+	Paragraphs []string
+}
+
+// dateLayout is the format of the cover letter date, as written in the generated cover letters
+const dateLayout = "January 2, 2006"
+
+var paragraphSeparator = regexp.MustCompile(`\n\s*\n`)
+
+// NewLetter returns the layout of a saved cover letter, with the letterhead from the career profile contact information.
+// The body is the structured cover letter when it was generated as JSON. Otherwise, the header lines added to the content
+// when it was generated are replaced by the letterhead, so the body starts at the greeting, or is the whole content when it has no greeting.
+func NewLetter(coverLetter *types.CoverLetter, careerProfile *types.CareerProfile) *Letter {
+	letter := &Letter{
+		Name:      fullName(careerProfile),
+		Date:      coverLetter.CreatedAt,
+		Recipient: []string{"Hiring Manager", coverLetter.JobPosting.CompanyName},
+	}
+	if createdAt, err := time.Parse("2006-01-02 15:04:05", coverLetter.CreatedAt); err == nil {
+		letter.Date = createdAt.Format(dateLayout)
+	}
+	letter.Contact = contactLines(careerProfile)
+
+	// The body generated as JSON is used unless the user edited the content
+	if coverLetter.Structured != nil && !coverLetter.Edited {
+		letter.Paragraphs = append([]string{coverLetter.Structured.Salutation}, coverLetter.Structured.Paragraphs...)
+		letter.Paragraphs = append(letter.Paragraphs, strings.TrimSpace(coverLetter.Structured.Closing+"\n"+letter.Name))
+		return letter
+	}
+
+	body := strings.ReplaceAll(coverLetter.Content, "\r\n", "\n")
+	if greeting := regexp.MustCompile(`(?m)^\s*Dear `).FindStringIndex(body); greeting != nil {
+		body = body[greeting[0]:]
+	}
+	for _, paragraph := range paragraphSeparator.Split(strings.TrimSpace(body), -1) {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			letter.Paragraphs = append(letter.Paragraphs, paragraph)
+		}
+	}
+	return letter
+}
+
+// fullName returns the name of the career profile
+func fullName(careerProfile *types.CareerProfile) string {
+	return strings.TrimSpace(careerProfile.FirstName + " " + careerProfile.LastName)
+}
+
+// contactLines returns the address, email, phone and website of the career profile, omitting the empty ones
+func contactLines(careerProfile *types.CareerProfile) []string {
+	var lines []string
+	if careerProfile.ContactInfo != nil {
+		for _, contact := range []string{careerProfile.ContactInfo.Address, careerProfile.ContactInfo.Email, careerProfile.ContactInfo.Phone, careerProfile.ContactInfo.Website} {
+			if contact = strings.TrimSpace(contact); contact != "" {
+				lines = append(lines, contact)
+			}
+		}
+	}
+	return lines
+}
+
+// Render returns the letter document in the given format
+func Render(letter *Letter, format string) ([]byte, error) {
+	switch format {
+	case FormatPDF:
+		return renderPDF(letter), nil
+	case FormatDOCX:
+		return renderDOCX(letter)
+	case FormatMarkdown:
+		return []byte(renderMarkdown(letter)), nil
+	case FormatHTML:
+		return renderHTML(letter)
+	case FormatText:
+		return []byte(renderText(letter)), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// renderText returns the letter as plain text, with the contact information in a single letterhead line
+func renderText(letter *Letter) string {
+	var builder strings.Builder
+	builder.WriteString(letter.Name + "\n")
+	if len(letter.Contact) > 0 {
+		builder.WriteString(strings.Join(letter.Contact, " | ") + "\n")
+	}
+	builder.WriteString("\n" + letter.Date + "\n\n")
+	builder.WriteString(strings.Join(letter.Recipient, "\n") + "\n\n")
+	builder.WriteString(strings.Join(letter.Paragraphs, "\n\n") + "\n")
+	return builder.String()
+}
+
+var markdownSpecialCharacters = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "#", `\#`, "[", `\[`, "]", `\]`, "<", `\<`)
+
+// renderMarkdown returns the letter as Markdown, with the name as the title and hard line breaks in the paragraphs
+func renderMarkdown(letter *Letter) string {
+	escape := markdownSpecialCharacters.Replace
+	lineBreaks := func(text string) string {
+		return strings.ReplaceAll(escape(text), "\n", "  \n")
+	}
+
+	var builder strings.Builder
+	builder.WriteString("# " + escape(letter.Name) + "\n\n")
+	if len(letter.Contact) > 0 {
+		contact := make([]string, len(letter.Contact))
+		for i, line := range letter.Contact {
+			contact[i] = escape(line)
+		}
+		builder.WriteString(strings.Join(contact, " · ") + "\n\n")
+	}
+	builder.WriteString("---\n\n")
+	builder.WriteString(escape(letter.Date) + "\n\n")
+	builder.WriteString(lineBreaks(strings.Join(letter.Recipient, "\n")) + "\n\n")
+	for _, paragraph := range letter.Paragraphs {
+		builder.WriteString(lineBreaks(paragraph) + "\n\n")
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
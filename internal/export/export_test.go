@@ -0,0 +1,225 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	careerProfile := &types.CareerProfile{
+		FirstName: "John",
+		LastName:  "Doe",
+		ContactInfo: &types.ContactInfo{
+			Email:   "john@email.com",
+			Address: "1 Main St, Springfield",
+			Phone:   "555-0100",
+			Website: "https://johndoe.dev",
+		},
+	}
+	coverLetter := &types.CoverLetter{
+		JobPosting: types.JobPosting{CompanyName: "Acme & Sons", JobRole: "Manager"},
+		Content:    "John Doe\n\n\njohn@email.com\n555-0100\nOctober 1, 2023\n\nHiring Manager\nAcme & Sons\n\n\nDear Hiring Manager,\n\nI am excited to apply <today>.\n\nSincerely,\nJohn Doe",
+		CreatedAt:  "2023-10-01 10:00:00",
+	}
+	letter := NewLetter(coverLetter, careerProfile)
+
+	t.Run("NewLetter", func(t *testing.T) {
+		assert.Equal(t, &Letter{
+			Name:       "John Doe",
+			Contact:    []string{"1 Main St, Springfield", "john@email.com", "555-0100", "https://johndoe.dev"},
+			Date:       "October 1, 2023",
+			Recipient:  []string{"Hiring Manager", "Acme & Sons"},
+			Paragraphs: []string{"Dear Hiring Manager,", "I am excited to apply <today>.", "Sincerely,\nJohn Doe"},
+		}, letter)
+
+		// The content without a greeting is the body
+		edited := NewLetter(&types.CoverLetter{Content: "To whom it may concern,\n\nHello"}, &types.CareerProfile{FirstName: "Jane"})
+		assert.Equal(t, []string{"To whom it may concern,", "Hello"}, edited.Paragraphs)
+		assert.Empty(t, edited.Contact)
+
+		// The body generated as JSON is used unless the content was edited
+		structured := *coverLetter
+		structured.Structured = &types.StructuredCoverLetter{Salutation: "Dear Ms. Smith,", Paragraphs: []string{"First.", "Second."}, Closing: "Best regards,"}
+		assert.Equal(t, []string{"Dear Ms. Smith,", "First.", "Second.", "Best regards,\nJohn Doe"}, NewLetter(&structured, careerProfile).Paragraphs)
+		structured.Edited = true
+		assert.Equal(t, letter.Paragraphs, NewLetter(&structured, careerProfile).Paragraphs)
+	})
+
+	t.Run("txt", func(t *testing.T) {
+		text, err := Render(letter, FormatText)
+		assert.NoError(t, err)
+		expected := "John Doe\n1 Main St, Springfield | john@email.com | 555-0100 | https://johndoe.dev\n\nOctober 1, 2023\n\nHiring Manager\nAcme & Sons\n\nDear Hiring Manager,\n\nI am excited to apply <today>.\n\nSincerely,\nJohn Doe\n"
+		assert.Equal(t, expected, string(text))
+	})
+
+	t.Run("md", func(t *testing.T) {
+		markdown, err := Render(letter, FormatMarkdown)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(markdown), "# John Doe\n\n1 Main St, Springfield · john@email.com · 555-0100 · https://johndoe.dev\n\n---\n\nOctober 1, 2023\n\nHiring Manager  \nAcme & Sons\n\n"))
+		assert.Contains(t, string(markdown), "I am excited to apply \\<today>.\n\nSincerely,  \nJohn Doe\n")
+	})
+
+	t.Run("html", func(t *testing.T) {
+		html, err := Render(letter, FormatHTML)
+		assert.NoError(t, err)
+		assert.Contains(t, string(html), "<h1>John Doe</h1>")
+		assert.Contains(t, string(html), "<span>john@email.com</span>")
+		assert.Contains(t, string(html), "Hiring Manager<br>Acme &amp; Sons")
+		assert.Contains(t, string(html), "<p>I am excited to apply &lt;today&gt;.</p>")
+		assert.Contains(t, string(html), "<p>Sincerely,<br>John Doe</p>")
+	})
+
+	t.Run("docx", func(t *testing.T) {
+		docx, err := Render(letter, FormatDOCX)
+		assert.NoError(t, err)
+		archive, err := zip.NewReader(bytes.NewReader(docx), int64(len(docx)))
+		assert.NoError(t, err)
+		files := map[string]string{}
+		for _, file := range archive.File {
+			reader, err := file.Open()
+			assert.NoError(t, err)
+			content, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			files[file.Name] = string(content)
+		}
+		assert.Contains(t, files, "[Content_Types].xml")
+		assert.Contains(t, files, "_rels/.rels")
+		document := files["word/document.xml"]
+		assert.Contains(t, document, `<w:b/><w:sz w:val="40"/></w:rPr><w:t xml:space="preserve">John Doe</w:t>`)
+		assert.Contains(t, document, `<w:t xml:space="preserve">Acme &amp; Sons</w:t>`)
+		assert.Contains(t, document, `<w:br/><w:t xml:space="preserve">John Doe</w:t>`)
+		assert.Contains(t, document, "apply &lt;today&gt;.")
+	})
+
+	t.Run("pdf", func(t *testing.T) {
+		pdf, err := Render(letter, FormatPDF)
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+		assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+		assert.Contains(t, string(pdf), "/F2 20.0 Tf 72 696.00 Td (John Doe) Tj")
+		assert.Contains(t, string(pdf), "(Acme & Sons) Tj")
+		assert.Contains(t, string(pdf), "/Count 1")
+
+		// The cross-reference table points to the objects
+		startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+		offset, err := strconv.Atoi(string(startxref[1]))
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf[offset:], []byte("xref\n0 7\n")))
+		for i, objectOffset := range regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(pdf, -1) {
+			offset, err := strconv.Atoi(string(objectOffset[1]))
+			assert.NoError(t, err)
+			assert.True(t, bytes.HasPrefix(pdf[offset:], []byte(strconv.Itoa(i+1)+" 0 obj\n")))
+		}
+	})
+
+	t.Run("pdf wrapping and pages", func(t *testing.T) {
+		longLetter := *letter
+		longLetter.Paragraphs = []string{strings.Repeat("I led the operations (and planning) of the team — every day. ", 200)}
+
+		pdf, err := Render(&longLetter, FormatPDF)
+		assert.NoError(t, err)
+		assert.Contains(t, string(pdf), "/Count 4")
+		assert.Contains(t, string(pdf), `\(and planning\)`)
+		assert.Contains(t, string(pdf), "team \x97 every")
+		for _, line := range regexp.MustCompile(`\((.*)\) Tj`).FindAllSubmatch(pdf, -1) {
+			text := strings.NewReplacer(`\(`, "(", `\)`, ")").Replace(string(line[1]))
+			assert.LessOrEqual(t, textWidth(text, helvetica, 11), float64(pdfTextWidth))
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := Render(letter, "odt")
+		assert.EqualError(t, err, "unsupported export format: odt")
+	})
+}
+
+func TestExportResume(t *testing.T) {
+	careerProfile := &types.CareerProfile{
+		FirstName:   "John",
+		LastName:    "Doe",
+		Headline:    "Operations Manager",
+		ContactInfo: &types.ContactInfo{Email: "john@email.com", Phone: "555-0100"},
+	}
+	resume := NewResume(&types.Resume{
+		Summary: "Manager of <large> teams.",
+		Skills:  []string{"Planning", "C#"},
+		Experience: []types.ResumeExperience{
+			{Company: "Acme & Sons", Title: "Manager", Location: "Springfield", StartDate: "Jan 2020", Bullets: []string{"Led a team of 5", "Cut costs by 10%"}},
+			{Company: "Globex", Title: "Coordinator", StartDate: "2017", EndDate: "2019"},
+		},
+	}, careerProfile)
+
+	t.Run("NewResume", func(t *testing.T) {
+		assert.Equal(t, &Resume{
+			Name:     "John Doe",
+			Headline: "Operations Manager",
+			Contact:  []string{"john@email.com", "555-0100"},
+			Summary:  "Manager of <large> teams.",
+			Skills:   []string{"Planning", "C#"},
+			Experience: []ResumePosition{
+				{Heading: "Manager, Acme & Sons", Details: "Springfield | Jan 2020 - Present", Bullets: []string{"Led a team of 5", "Cut costs by 10%"}},
+				{Heading: "Coordinator, Globex", Details: "2017 - 2019"},
+			},
+		}, resume)
+	})
+
+	t.Run("txt", func(t *testing.T) {
+		text, err := RenderResume(resume, FormatText)
+		assert.NoError(t, err)
+		expected := "John Doe\nOperations Manager\njohn@email.com | 555-0100\n\nSUMMARY\nManager of <large> teams.\n\nSKILLS\nPlanning, C#\n\nEXPERIENCE\nManager, Acme & Sons\nSpringfield | Jan 2020 - Present\n- Led a team of 5\n- Cut costs by 10%\n\nCoordinator, Globex\n2017 - 2019\n"
+		assert.Equal(t, expected, string(text))
+	})
+
+	t.Run("md", func(t *testing.T) {
+		markdown, err := RenderResume(resume, FormatMarkdown)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(markdown), "# John Doe\n\n**Operations Manager**\n\njohn@email.com · 555-0100\n\n---\n\n## Summary\n\nManager of \\<large> teams.\n\n## Skills\n\nPlanning, C\\#\n\n"))
+		assert.Contains(t, string(markdown), "## Experience\n\n### Manager, Acme & Sons\n\n*Springfield | Jan 2020 - Present*\n\n- Led a team of 5\n- Cut costs by 10%\n\n### Coordinator, Globex\n\n*2017 - 2019*\n")
+	})
+
+	t.Run("html", func(t *testing.T) {
+		html, err := RenderResume(resume, FormatHTML)
+		assert.NoError(t, err)
+		assert.Contains(t, string(html), "<title>Resume - John Doe</title>")
+		assert.Contains(t, string(html), `<div class="headline">Operations Manager</div>`)
+		assert.Contains(t, string(html), "<p>Manager of &lt;large&gt; teams.</p>")
+		assert.Contains(t, string(html), "<h3>Manager, Acme &amp; Sons</h3>\n<div class=\"details\">Springfield | Jan 2020 - Present</div>\n<ul>\n<li>Led a team of 5</li>")
+	})
+
+	t.Run("docx", func(t *testing.T) {
+		docx, err := RenderResume(resume, FormatDOCX)
+		assert.NoError(t, err)
+		archive, err := zip.NewReader(bytes.NewReader(docx), int64(len(docx)))
+		assert.NoError(t, err)
+		reader, err := archive.Open("word/document.xml")
+		assert.NoError(t, err)
+		document, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Contains(t, string(document), `<w:b/><w:sz w:val="24"/></w:rPr><w:t xml:space="preserve">Experience</w:t>`)
+		assert.Contains(t, string(document), `<w:t xml:space="preserve">Manager, Acme &amp; Sons</w:t>`)
+		assert.Contains(t, string(document), `<w:t xml:space="preserve">• Cut costs by 10%</w:t>`)
+	})
+
+	t.Run("pdf", func(t *testing.T) {
+		pdf, err := RenderResume(resume, FormatPDF)
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+		assert.Contains(t, string(pdf), "/F2 20.0 Tf 72 696.00 Td (John Doe) Tj")
+		assert.Contains(t, string(pdf), "/F2 12.0 Tf")
+		assert.Contains(t, string(pdf), "(\x95 Led a team of 5) Tj")
+		assert.Contains(t, string(pdf), "/Count 1")
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := RenderResume(resume, "odt")
+		assert.EqualError(t, err, "unsupported export format: odt")
+	})
+}
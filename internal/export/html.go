@@ -0,0 +1,121 @@
+package export
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+)
+
+var htmlTemplate = template.Must(template.New("letter").Funcs(template.FuncMap{
+	"lines": func(text string) []string {
+		return strings.Split(text, "\n")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Cover letter - {{.Name}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 11pt; line-height: 1.5; color: #222; max-width: 8.5in; margin: 0 auto; padding: 1in; }
+header { border-bottom: 1px solid #999; padding-bottom: 12pt; margin-bottom: 24pt; }
+h1 { font-size: 20pt; margin: 0 0 4pt; }
+.contact { font-size: 10pt; color: #555; }
+.contact span + span::before { content: " | "; }
+.date, .recipient { margin-bottom: 12pt; }
+p { margin: 0 0 12pt; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Name}}</h1>
+{{- if .Contact}}
+<div class="contact">{{range .Contact}}<span>{{.}}</span>{{end}}</div>
+{{- end}}
+</header>
+<div class="date">{{.Date}}</div>
+<div class="recipient">{{range $i, $line := .Recipient}}{{if $i}}<br>{{end}}{{$line}}{{end}}</div>
+{{- range .Paragraphs}}
+<p>{{range $i, $line := lines .}}{{if $i}}<br>{{end}}{{$line}}{{end}}</p>
+{{- end}}
+</body>
+</html>
+`))
+
+var resumeHTMLTemplate = template.Must(template.New("resume").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Resume - {{.Name}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 11pt; line-height: 1.5; color: #222; max-width: 8.5in; margin: 0 auto; padding: 1in; }
+header { border-bottom: 1px solid #999; padding-bottom: 12pt; margin-bottom: 12pt; }
+h1 { font-size: 20pt; margin: 0 0 4pt; }
+h2 { font-size: 12pt; margin: 18pt 0 4pt; }
+h3 { font-size: 11pt; margin: 12pt 0 0; }
+.headline { font-size: 12pt; margin-bottom: 4pt; }
+.contact, .details { font-size: 10pt; color: #555; }
+.contact span + span::before { content: " | "; }
+p, ul { margin: 0; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Name}}</h1>
+{{- with .Headline}}
+<div class="headline">{{.}}</div>
+{{- end}}
+{{- if .Contact}}
+<div class="contact">{{range .Contact}}<span>{{.}}</span>{{end}}</div>
+{{- end}}
+</header>
+{{- with .Summary}}
+<section>
+<h2>Summary</h2>
+<p>{{.}}</p>
+</section>
+{{- end}}
+{{- if .Skills}}
+<section>
+<h2>Skills</h2>
+<p>{{range $i, $skill := .Skills}}{{if $i}}, {{end}}{{$skill}}{{end}}</p>
+</section>
+{{- end}}
+{{- if .Experience}}
+<section>
+<h2>Experience</h2>
+{{- range .Experience}}
+<h3>{{.Heading}}</h3>
+{{- with .Details}}
+<div class="details">{{.}}</div>
+{{- end}}
+{{- if .Bullets}}
+<ul>
+{{- range .Bullets}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+// renderHTML returns the letter as a standalone HTML page, styled for printing
+func renderHTML(letter *Letter) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := htmlTemplate.Execute(&buffer, letter); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// renderResumeHTML returns the resume as a standalone HTML page, styled for printing
+func renderResumeHTML(resume *Resume) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := resumeHTMLTemplate.Execute(&buffer, resume); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
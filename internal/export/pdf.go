@@ -0,0 +1,239 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Page layout of the PDF export in points, using the US Letter size with 1 inch margins
+const (
+	pdfPageWidth  = 612
+	pdfPageHeight = 792
+	pdfMargin     = 72
+	pdfTextWidth  = pdfPageWidth - 2*pdfMargin
+)
+
+// pdfFont is a standard PDF font, available in every PDF reader without embedding it
+type pdfFont struct {
+	resource string
+	baseFont string
+	// widthScale approximates the widths of the font from the Helvetica widths
+	widthScale float64
+}
+
+var (
+	helvetica     = pdfFont{resource: "F1", baseFont: "Helvetica", widthScale: 1}
+	helveticaBold = pdfFont{resource: "F2", baseFont: "Helvetica-Bold", widthScale: 1.06}
+)
+
+// helveticaWidths are the Helvetica glyph widths, in thousandths of the font size, of the characters from 32 to 126
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// defaultWidth is the width of the WinAnsi characters outside of helveticaWidths
+const defaultWidth = 556
+
+// pdfDocument lays out lines of text in pages
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func (d *pdfDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// writeText writes the text wrapped to the text width, starting a new page when the bottom margin is reached
+func (d *pdfDocument) writeText(text string, font pdfFont, size float64, leading float64) {
+	for _, line := range strings.Split(text, "\n") {
+		for _, wrapped := range wrapLine(encodeWinAnsi(line), font, size) {
+			if d.y-leading < pdfMargin {
+				d.newPage()
+			}
+			d.y -= leading
+			fmt.Fprintf(d.pages[len(d.pages)-1], "BT /%s %.1f Tf %d %.2f Td (%s) Tj ET\n", font.resource, size, pdfMargin, d.y, escapePDFString(wrapped))
+		}
+	}
+}
+
+// space adds vertical space before the next line
+func (d *pdfDocument) space(height float64) {
+	d.y -= height
+}
+
+// rule draws a horizontal line across the text width
+func (d *pdfDocument) rule() {
+	d.y -= 8
+	fmt.Fprintf(d.pages[len(d.pages)-1], "0.6 G 0.75 w %d %.2f m %d %.2f l S 0 G\n", pdfMargin, d.y, pdfPageWidth-pdfMargin, d.y)
+}
+
+// renderPDF returns the letter as a PDF document using the standard Helvetica fonts
+func renderPDF(letter *Letter) []byte {
+	document := &pdfDocument{}
+	document.newPage()
+	document.writeText(letter.Name, helveticaBold, 20, 24)
+	if len(letter.Contact) > 0 {
+		document.space(2)
+		document.writeText(strings.Join(letter.Contact, "  |  "), helvetica, 10, 13)
+	}
+	document.rule()
+	document.space(18)
+	document.writeText(letter.Date, helvetica, 11, 15)
+	document.space(12)
+	document.writeText(strings.Join(letter.Recipient, "\n"), helvetica, 11, 15)
+	for _, paragraph := range letter.Paragraphs {
+		document.space(12)
+		document.writeText(paragraph, helvetica, 11, 15)
+	}
+	return document.bytes()
+}
+
+// renderResumePDF returns the resume as a PDF document, with the header of the letters and a bold title for each section
+func renderResumePDF(resume *Resume) []byte {
+	document := &pdfDocument{}
+	document.newPage()
+	document.writeText(resume.Name, helveticaBold, 20, 24)
+	if resume.Headline != "" {
+		document.writeText(resume.Headline, helvetica, 12, 16)
+	}
+	if len(resume.Contact) > 0 {
+		document.space(2)
+		document.writeText(strings.Join(resume.Contact, "  |  "), helvetica, 10, 13)
+	}
+	document.rule()
+	section := func(title string) {
+		document.space(14)
+		document.writeText(title, helveticaBold, 12, 16)
+		document.space(2)
+	}
+	if resume.Summary != "" {
+		section("Summary")
+		document.writeText(resume.Summary, helvetica, 11, 15)
+	}
+	if len(resume.Skills) > 0 {
+		section("Skills")
+		document.writeText(strings.Join(resume.Skills, ", "), helvetica, 11, 15)
+	}
+	if len(resume.Experience) > 0 {
+		section("Experience")
+		for i, position := range resume.Experience {
+			if i > 0 {
+				document.space(8)
+			}
+			document.writeText(position.Heading, helveticaBold, 11, 15)
+			if position.Details != "" {
+				document.writeText(position.Details, helvetica, 10, 13)
+			}
+			for _, bullet := range position.Bullets {
+				document.writeText("• "+bullet, helvetica, 11, 15)
+			}
+		}
+	}
+	return document.bytes()
+}
+
+// bytes returns the PDF file, with the objects: catalog, pages, fonts, and then each page followed by its content stream
+func (d *pdfDocument) bytes() []byte {
+	var objects []string
+	pageIds := make([]string, len(d.pages))
+	for i := range d.pages {
+		pageIds[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageIds, " "), len(d.pages)),
+		fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", helvetica.baseFont),
+		fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", helveticaBold.baseFont),
+	)
+	for i, page := range d.pages {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, 6+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()),
+		)
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buffer.Len()
+		fmt.Fprintf(&buffer, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xrefOffset := buffer.Len()
+	fmt.Fprintf(&buffer, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buffer, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buffer, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefOffset)
+	return buffer.Bytes()
+}
+
+// wrapLine splits an encoded line in the lines that fit in the text width, breaking at spaces when possible
+func wrapLine(line string, font pdfFont, size float64) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Split(line, " ") {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if textWidth(candidate, font, size) <= pdfTextWidth {
+			current = candidate
+			continue
+		}
+		if current != "" {
+			lines = append(lines, current)
+		}
+		// Break the words longer than the text width
+		for textWidth(word, font, size) > pdfTextWidth {
+			split := len(word) - 1
+			for split > 1 && textWidth(word[:split], font, size) > pdfTextWidth {
+				split--
+			}
+			lines = append(lines, word[:split])
+			word = word[split:]
+		}
+		current = word
+	}
+	return append(lines, current)
+}
+
+// textWidth returns the width in points of an encoded text
+func textWidth(text string, font pdfFont, size float64) float64 {
+	width := 0
+	for i := 0; i < len(text); i++ {
+		if character := text[i]; character >= 32 && int(character)-32 < len(helveticaWidths) {
+			width += helveticaWidths[character-32]
+		} else {
+			width += defaultWidth
+		}
+	}
+	return float64(width) * font.widthScale * size / 1000
+}
+
+// encodeWinAnsi encodes a text to WinAnsi, the encoding of the standard fonts, replacing the unsupported characters with "?"
+func encodeWinAnsi(text string) string {
+	encoded, err := encoding.ReplaceUnsupported(charmap.Windows1252.NewEncoder()).String(text)
+	if err != nil {
+		return text
+	}
+	return strings.ReplaceAll(encoded, string(encoding.ASCIISub), "?")
+}
+
+var pdfStringEscaper = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", "", "\t", " ")
+
+// escapePDFString escapes the special characters of a PDF literal string
+func escapePDFString(text string) string {
+	return pdfStringEscaper.Replace(text)
+}
@@ -0,0 +1,155 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// Resume is a tailored resume split into the sections of the resume layout, under the same header as the letters
+type Resume struct {
+	Name     string
+	Headline string
+	// Contact are the address, email, phone and website of the header, omitting the empty ones
+	Contact    []string
+	Summary    string
+	Skills     []string
+	Experience []ResumePosition
+}
+
+// ResumePosition is a position of the experience section
+type ResumePosition struct {
+	// Heading is the title and the company of the position, e.g. "Engineer, Acme"
+	Heading string
+	// Details are the location and the dates of the position, e.g. "Toronto | Jan 2020 - Present"
+	Details string
+	Bullets []string
+}
+
+// NewResume returns the layout of a saved resume, with the name, headline and contact information of the career profile
+func NewResume(resume *types.Resume, careerProfile *types.CareerProfile) *Resume {
+	layout := &Resume{
+		Name:     fullName(careerProfile),
+		Headline: careerProfile.Headline,
+		Contact:  contactLines(careerProfile),
+		Summary:  resume.Summary,
+		Skills:   resume.Skills,
+	}
+	for _, experience := range resume.Experience {
+		layout.Experience = append(layout.Experience, ResumePosition{
+			Heading: joinNonEmpty(", ", experience.Title, experience.Company),
+			Details: joinNonEmpty(" | ", experience.Location, experience.Dates()),
+			Bullets: experience.Bullets,
+		})
+	}
+	return layout
+}
+
+// joinNonEmpty joins the parts that are not empty with the separator
+func joinNonEmpty(separator string, parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, separator)
+}
+
+// RenderResume returns the resume document in the given format
+func RenderResume(resume *Resume, format string) ([]byte, error) {
+	switch format {
+	case FormatPDF:
+		return renderResumePDF(resume), nil
+	case FormatDOCX:
+		return renderResumeDOCX(resume)
+	case FormatMarkdown:
+		return []byte(renderResumeMarkdown(resume)), nil
+	case FormatHTML:
+		return renderResumeHTML(resume)
+	case FormatText:
+		return []byte(renderResumeText(resume)), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// renderResumeText returns the resume as plain text, with the section titles in upper case
+func renderResumeText(resume *Resume) string {
+	var builder strings.Builder
+	builder.WriteString(resume.Name + "\n")
+	if resume.Headline != "" {
+		builder.WriteString(resume.Headline + "\n")
+	}
+	if len(resume.Contact) > 0 {
+		builder.WriteString(strings.Join(resume.Contact, " | ") + "\n")
+	}
+	if resume.Summary != "" {
+		builder.WriteString("\nSUMMARY\n" + resume.Summary + "\n")
+	}
+	if len(resume.Skills) > 0 {
+		builder.WriteString("\nSKILLS\n" + strings.Join(resume.Skills, ", ") + "\n")
+	}
+	if len(resume.Experience) > 0 {
+		builder.WriteString("\nEXPERIENCE\n")
+		for i, position := range resume.Experience {
+			if i > 0 {
+				builder.WriteString("\n")
+			}
+			builder.WriteString(position.Heading + "\n")
+			if position.Details != "" {
+				builder.WriteString(position.Details + "\n")
+			}
+			for _, bullet := range position.Bullets {
+				builder.WriteString("- " + bullet + "\n")
+			}
+		}
+	}
+	return builder.String()
+}
+
+// renderResumeMarkdown returns the resume as Markdown, with the name as the title and a heading for each section and position
+func renderResumeMarkdown(resume *Resume) string {
+	escape := markdownSpecialCharacters.Replace
+
+	var builder strings.Builder
+	builder.WriteString("# " + escape(resume.Name) + "\n\n")
+	if resume.Headline != "" {
+		builder.WriteString("**" + escape(resume.Headline) + "**\n\n")
+	}
+	if len(resume.Contact) > 0 {
+		contact := make([]string, len(resume.Contact))
+		for i, line := range resume.Contact {
+			contact[i] = escape(line)
+		}
+		builder.WriteString(strings.Join(contact, " · ") + "\n\n")
+	}
+	builder.WriteString("---\n\n")
+	if resume.Summary != "" {
+		builder.WriteString("## Summary\n\n" + escape(resume.Summary) + "\n\n")
+	}
+	if len(resume.Skills) > 0 {
+		skills := make([]string, len(resume.Skills))
+		for i, skill := range resume.Skills {
+			skills[i] = escape(skill)
+		}
+		builder.WriteString("## Skills\n\n" + strings.Join(skills, ", ") + "\n\n")
+	}
+	if len(resume.Experience) > 0 {
+		builder.WriteString("## Experience\n\n")
+		for _, position := range resume.Experience {
+			builder.WriteString("### " + escape(position.Heading) + "\n\n")
+			if position.Details != "" {
+				builder.WriteString("*" + escape(position.Details) + "*\n\n")
+			}
+			for _, bullet := range position.Bullets {
+				builder.WriteString("- " + escape(bullet) + "\n")
+			}
+			if len(position.Bullets) > 0 {
+				builder.WriteString("\n")
+			}
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
@@ -16,37 +16,38 @@ import (
 func (h *Handler) HandleLinkedInCallback(c *gin.Context) {
 	linkedInClientID := os.Getenv("LINKEDIN_CLIENT_ID")
 	if linkedInClientID == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "no LinkedIn Client ID env variable"})
+		respondError(c, http.StatusInternalServerError, "no LinkedIn Client ID env variable")
 		return
 	}
 
 	linkedInClientSecret := os.Getenv("LINKEDIN_CLIENT_SECRET")
 	if linkedInClientSecret == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "no LinkedIn Client Secret env variable"})
+		respondError(c, http.StatusInternalServerError, "no LinkedIn Client Secret env variable")
 		return
 	}
 
 	baseUrl := os.Getenv("BASE_API_URL")
 	if baseUrl == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "no base api url env variable"})
+		respondError(c, http.StatusInternalServerError, "no base api url env variable")
 		return
 	}
 	baseUrl = strings.TrimSpace(baseUrl)
 
 	state := c.Query("state")
 	if state == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no state provided in the request"})
+		respondError(c, http.StatusBadRequest, "no state provided in the request")
 		return
 	}
 
 	code := c.Query("code")
 	if code == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no code provided in the request"})
+		respondError(c, http.StatusBadRequest, "no code provided in the request")
 		return
 	}
 
 	client := &http.Client{}
-	redirectURI := fmt.Sprintf("%s/linkedin/callback", baseUrl)
+	// LinkedIn requires the same redirect URI used to request the authorization code
+	redirectURI := fmt.Sprintf("%s%s", baseUrl, c.Request.URL.Path)
 	fmt.Println("setting redirectURI: ", redirectURI)
 	// Set parameters for LinkedIn access token request
 	data := url.Values{}
@@ -59,27 +60,27 @@ func (h *Handler) HandleLinkedInCallback(c *gin.Context) {
 	// Create LinkedIn access token request
 	tokenRequest, err := http.NewRequest("POST", "https://www.linkedin.com/oauth/v2/accessToken", strings.NewReader(data.Encode()))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 	tokenRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	tokenResponse, err := client.Do(tokenRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 	defer tokenResponse.Body.Close()
 	// Get data from LinkedIn access token response
 	tokenResponseBody, err := readResponse(tokenResponse)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 	tokenResponseData := types.MapToLinkedInTokenResponse(tokenResponseBody)
 
 	clientUrl := os.Getenv("CLIENT_URL")
 	if clientUrl == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "no client url env variable"})
+		respondError(c, http.StatusInternalServerError, "no client url env variable")
 		return
 	}
 
@@ -90,7 +91,7 @@ func (h *Handler) HandleLinkedInCallback(c *gin.Context) {
 func (h *Handler) HandleAuth(c *gin.Context) {
 	accessTokenParam, exists := c.Get("AccessToken")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "no authorization token provided"})
+		respondError(c, http.StatusUnauthorized, "no authorization token provided")
 		return
 	}
 	var accessToken string
@@ -98,7 +99,7 @@ func (h *Handler) HandleAuth(c *gin.Context) {
 		if str, ok := accessTokenParam.(string); ok {
 			accessToken = str
 		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "no authorization token provided"})
+			respondError(c, http.StatusUnauthorized, "no authorization token provided")
 			return
 		}
 	}
@@ -107,27 +108,27 @@ func (h *Handler) HandleAuth(c *gin.Context) {
 	// Create LinkedIn user data request
 	userDataRequest, err := http.NewRequest("GET", "https://api.linkedin.com/v2/userinfo", nil)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 	userDataRequest.Header.Add("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 	userDataResponse, err := client.Do(userDataRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 	defer userDataResponse.Body.Close()
 	// Get data from LinkedIn user data response
 	userDataResponseBody, err := readResponse(userDataResponse)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		respondError(c, http.StatusUnauthorized, err.Error())
 		return
 	}
 	linkedInUserData := types.MapToLinkedInUserData(userDataResponseBody)
 
 	isValidAccount := validateLinkedInAccount(linkedInUserData.Email)
 	if !isValidAccount {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "this account is not authorized"})
+		respondError(c, http.StatusUnauthorized, "this account is not authorized")
 		return
 	}
 
@@ -144,14 +145,14 @@ func (h *Handler) HandleAuth(c *gin.Context) {
 			},
 		})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondError(c, http.StatusInternalServerError, err.Error())
 			return
 		}
 		isNewProfile = true
 		profileID = newCareerProfile.ID
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -162,11 +163,11 @@ func (h *Handler) HandleAuth(c *gin.Context) {
 	// Store access token in DB
 	_, err = h.StoreClient.StoreAccessToken(profileID, accessToken, c.ClientIP())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"profile_id": profileID})
+	respond(c, http.StatusOK, types.AuthResponse{ProfileID: profileID}, nil)
 }
 
 func validateLinkedInAccount(email string) bool {
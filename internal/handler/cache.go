@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// cachedDate replaces the date written in the cached cover letters, which are returned with the current date
+const cachedDate = "{{date}}"
+
+// cachedCoverLetter returns the cache key of a cover letter request, with the cached cover letter of an identical request
+// unless the request forces a refresh. The key is empty when the cache is disabled or fails, so the cover letter is not cached.
+func (h *Handler) cachedCoverLetter(coverLetterRequest *types.CoverLetterRequest) (string, *types.GeneratedCoverLetter) {
+	if h.Cache == nil {
+		return "", nil
+	}
+	key, err := h.OpenAIClient.CoverLetterCacheKey(coverLetterRequest.ProfileID, &coverLetterRequest.JobPosting, &coverLetterRequest.Options, h.StoreClient)
+	if err != nil {
+		log.Printf("error getting the cover letter cache key: %s", err.Error())
+		return "", nil
+	}
+	if coverLetterRequest.ForceRefresh {
+		return key, nil
+	}
+	coverLetter, found, err := h.Cache.Get(key)
+	if err != nil {
+		log.Printf("error getting cached cover letter: %s", err.Error())
+		return key, nil
+	}
+	if !found {
+		return key, nil
+	}
+	// The cached cover letter did not use any tokens, and is dated today
+	coverLetter.Usage = types.TokenUsage{}
+	if coverLetter.Date != "" {
+		today := time.Now().Format(types.CoverLetterDateLayout)
+		coverLetter.Content = strings.ReplaceAll(coverLetter.Content, coverLetter.Date, today)
+		coverLetter.Date = today
+	}
+	return key, coverLetter
+}
+
+// cacheCoverLetter saves a generated cover letter in the cache without its date, with the id of the cover letter saved
+// in the history, if any, only logging the errors
+func (h *Handler) cacheCoverLetter(key string, coverLetter *types.GeneratedCoverLetter, savedCoverLetter *types.CoverLetter) {
+	if key == "" {
+		return
+	}
+	cached := *coverLetter
+	if cached.Date != "" {
+		cached.Content = strings.ReplaceAll(cached.Content, cached.Date, cachedDate)
+		cached.Date = cachedDate
+	}
+	if savedCoverLetter != nil {
+		cached.CoverLetterID = &savedCoverLetter.ID
+	}
+	if err := h.Cache.Set(key, &cached); err != nil {
+		log.Printf("error caching cover letter: %s", err.Error())
+	}
+}
+
+// cachedCoverLetterMeta marks the response meta of a cached cover letter, linking it to the cover letter saved in the
+// history when it was generated, since identical requests are not saved again
+func cachedCoverLetterMeta(meta map[string]interface{}, coverLetter *types.GeneratedCoverLetter) {
+	meta["cached"] = true
+	if coverLetter.CoverLetterID != nil {
+		meta["cover_letter_id"] = *coverLetter.CoverLetterID
+	}
+}
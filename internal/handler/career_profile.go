@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"fmt"
 	"net/http"
 	"strings"
 
@@ -14,50 +13,44 @@ import (
 func (h *Handler) HandleCreateCareerProfile(c *gin.Context) {
 	// Receive CareerProfileRequest parameters from request payload
 	var careerProfileRequest types.CareerProfile
-	if err := c.ShouldBindJSON(&careerProfileRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("error retrieving JSON: %s", err.Error())})
-		return
-	}
-
-	if careerProfileRequest.Headline == "" || careerProfileRequest.ExperienceYears == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "headline and experience are required"})
+	if !bindJSON(c, &careerProfileRequest) {
 		return
 	}
 
 	// Call store method to upsert CareerProfile in MongoDB
 	careerProfile, responseMsq, err := h.StoreClient.StoreCareerProfile(&careerProfileRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": responseMsq, "data": careerProfile})
+	respondMessage(c, http.StatusOK, careerProfile, responseMsq)
 }
 
 // HandleCreateCareerProfile handles a GET method to retrieve a career profile from MongoDB
 func (h *Handler) HandleGetCareerProfile(c *gin.Context) {
 	profileIdParam, exists := c.Get("ProfileID")
 	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no profile_id provided in the request"})
+		respondError(c, http.StatusBadRequest, "no profile_id provided in the request")
 		return
 	}
 
 	profileId, ok := profileIdParam.(uuid.UUID)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid profile id"})
+		respondError(c, http.StatusBadRequest, "invalid profile id")
 		return
 	}
 
 	// Call store method to retrieve CareerProfile from MongoDB
 	careerProfile, err := h.StoreClient.GetCareerProfileByID(profileId)
 	if err != nil && strings.Contains(err.Error(), "no document") {
-		c.JSON(http.StatusNotFound, gin.H{"error": "career profile not found"})
+		respondError(c, http.StatusNotFound, "career profile not found")
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": &careerProfile})
+	respond(c, http.StatusOK, careerProfile, nil)
 }
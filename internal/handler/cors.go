@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig is the cross-origin resource sharing policy of the API
+type CORSConfig struct {
+	AllowedOrigins []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	MaxAge         time.Duration
+}
+
+// CORSConfigFromEnv returns the CORS policy from env variables,
+// allowing the origins from CORS_ALLOWED_ORIGINS or the CLIENT_URL by default
+func CORSConfigFromEnv() CORSConfig {
+	allowedOrigins := os.Getenv("CORS_ALLOWED_ORIGINS")
+	if allowedOrigins == "" {
+		allowedOrigins = os.Getenv("CLIENT_URL")
+	}
+
+	maxAge := 10 * time.Minute
+	if seconds, err := strconv.Atoi(os.Getenv("CORS_MAX_AGE")); err == nil {
+		maxAge = time.Duration(seconds) * time.Second
+	}
+
+	var origins []string
+	for _, origin := range strings.Split(allowedOrigins, ",") {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	return CORSConfig{
+		AllowedOrigins: origins,
+		AllowedHeaders: []string{"Content-Type", "Authorization", "Accept", "Cache-Control", "X-Requested-With", "UserID"},
+		ExposedHeaders: []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After", "Deprecation", "Link"},
+		MaxAge:         maxAge,
+	}
+}
+
+// allowsOrigin checks if an origin is in the allowed origins list, where "*" allows any origin
+func (config CORSConfig) allowsOrigin(origin string) bool {
+	for _, allowedOrigin := range config.AllowedOrigins {
+		if allowedOrigin == "*" || allowedOrigin == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsAnyOrigin checks if the allowed origins list contains the "*" wildcard
+func (config CORSConfig) allowsAnyOrigin() bool {
+	for _, allowedOrigin := range config.AllowedOrigins {
+		if allowedOrigin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// cors applies the CORS policy to cross-origin requests, and answers preflight requests
+// with the methods registered for the requested route
+func cors(config CORSConfig, routes func() gin.RoutesInfo) gin.HandlerFunc {
+	var routeMethods map[string][]string
+	var routeMethodsOnce sync.Once
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !config.allowsOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// Browsers block the response without the CORS headers
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Origin")
+		c.Header("Access-Control-Allow-Origin", origin)
+		// Credentials are not allowed together with a wildcard origin
+		if !config.allowsAnyOrigin() {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			c.Header("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+			c.Next()
+			return
+		}
+
+		// Routes are registered before the first request, so they are only collected once
+		routeMethodsOnce.Do(func() {
+			routeMethods = map[string][]string{}
+			for _, route := range routes() {
+				routeMethods[route.Path] = append(routeMethods[route.Path], route.Method)
+			}
+		})
+		var methods []string
+		for path, pathMethods := range routeMethods {
+			if matchRoute(path, c.Request.URL.Path) {
+				methods = append(methods, pathMethods...)
+			}
+		}
+		if len(methods) == 0 {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+
+		c.Header("Access-Control-Allow-Methods", strings.Join(append(methods, http.MethodOptions), ", "))
+		c.Header("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+		c.Header("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge.Seconds())))
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// matchRoute checks if a request path matches a gin route path with :param and *wildcard segments
+func matchRoute(routePath string, path string) bool {
+	routeSegments := strings.Split(routePath, "/")
+	pathSegments := strings.Split(path, "/")
+	for i, routeSegment := range routeSegments {
+		if strings.HasPrefix(routeSegment, "*") {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if strings.HasPrefix(routeSegment, ":") && pathSegments[i] != "" {
+			continue
+		}
+		if routeSegment != pathSegments[i] {
+			return false
+		}
+	}
+	return len(routeSegments) == len(pathSegments)
+}
@@ -0,0 +1,331 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/internal/diff"
+	"github.com/jonada182/cover-letter-ai-api/internal/export"
+	"github.com/jonada182/cover-letter-ai-api/internal/usage"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// saveCoverLetter saves a generated cover letter in the profile history, returning nil if it could not be saved,
+// since the generation was already completed and can still be returned
+func (h *Handler) saveCoverLetter(coverLetter *types.CoverLetter, generated *types.GeneratedCoverLetter) *types.CoverLetter {
+	coverLetter.Content = generated.Content
+	coverLetter.Structured = generated.Structured
+	coverLetter.Model = generated.Model
+	coverLetter.PromptVersion = generated.PromptVersion
+	coverLetter.Usage = generated.Usage
+	coverLetter.Messages = generated.Messages
+	savedCoverLetter, err := h.StoreClient.StoreCoverLetter(coverLetter)
+	if err != nil {
+		log.Printf("error saving cover letter: %s", err.Error())
+		return nil
+	}
+	return savedCoverLetter
+}
+
+// handleCoverLetterVariants generates the requested number of cover letters, saving each of them in the profile history,
+// and ranks them against the job posting when requested, returning the variants sorted by score, or unranked with the
+// ranking error in the meta when the ranking fails
+func (h *Handler) handleCoverLetterVariants(c *gin.Context, coverLetterRequest *types.CoverLetterRequest) {
+	jobPosting := coverLetterRequest.JobPosting
+	generated, statusCode, err := h.OpenAIClient.GenerateChatGPTCoverLetterVariants(c, coverLetterRequest.ProfileID, &jobPosting, &coverLetterRequest.Options, coverLetterRequest.Variants, h.StoreClient)
+	if err != nil {
+		h.recordFailedUsage(coverLetterRequest.ProfileID, usage.OperationCoverLetterVariant, err)
+		respondGenerationError(c, statusCode, err)
+		return
+	}
+
+	// The variants are saved and their usage recorded before ranking, so a failed ranking does not lose them
+	variants := make([]types.CoverLetterVariant, len(generated))
+	totalUsage := types.TokenUsage{}
+	for i := range generated {
+		variants[i] = types.CoverLetterVariant{Content: generated[i].Content, Usage: generated[i].Usage, Placeholders: generated[i].Placeholders}
+		savedCoverLetter := h.saveCoverLetter(&types.CoverLetter{ProfileID: coverLetterRequest.ProfileID, JobPosting: jobPosting, Options: coverLetterRequest.Options}, &generated[i])
+		if savedCoverLetter != nil {
+			variants[i].CoverLetterID = &savedCoverLetter.ID
+		}
+		h.recordCoverLetterUsage(coverLetterRequest.ProfileID, usage.OperationCoverLetterVariant, &generated[i])
+		totalUsage = totalUsage.Add(generated[i].Usage)
+	}
+
+	meta := map[string]interface{}{
+		"model":          generated[0].Model,
+		"prompt_version": generated[0].PromptVersion,
+	}
+	if coverLetterRequest.Rank {
+		rankings, _, err := h.OpenAIClient.RankChatGPTCoverLetters(c, &jobPosting, &coverLetterRequest.Options, generated)
+		if err != nil {
+			// The variants are returned unranked, in the order they were generated
+			log.Printf("Failed to rank the cover letter variants:%s", err.Error())
+			h.recordFailedUsage(coverLetterRequest.ProfileID, usage.OperationCoverLetterRanking, err)
+			meta["ranking_error"] = err.Error()
+		} else {
+			for _, ranking := range rankings.Rankings {
+				score := ranking.Score
+				variants[ranking.Variant-1].Score = &score
+				variants[ranking.Variant-1].Reason = ranking.Reason
+			}
+			sort.SliceStable(variants, func(i, j int) bool {
+				return *variants[i].Score > *variants[j].Score
+			})
+			for i := range variants {
+				variants[i].Rank = i + 1
+			}
+			h.recordUsage(coverLetterRequest.ProfileID, usage.OperationCoverLetterRanking, rankings.Model, rankings.PromptVersion, rankings.Usage)
+			totalUsage = totalUsage.Add(rankings.Usage)
+			meta["ranking_prompt_version"] = rankings.PromptVersion
+			meta["ranking_usage"] = rankings.Usage
+		}
+	}
+	// The usage is the total of the variants and the ranking
+	meta["usage"] = totalUsage
+	respond(c, http.StatusOK, variants, meta)
+}
+
+// HandleGetCoverLetters handles a GET method to retrieve the cover letters generated by the profile
+func (h *Handler) HandleGetCoverLetters(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+
+	// Call store method to retrieve []CoverLetter from MongoDB
+	coverLetters, err := h.StoreClient.GetCoverLetters(profileId)
+	if err != nil && strings.Contains(err.Error(), "no cover letters found") {
+		respondError(c, http.StatusNotFound, "no cover letters found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, coverLetters, nil)
+}
+
+// HandleGetCoverLetterByID handles a GET method to retrieve a cover letter of the profile
+func (h *Handler) HandleGetCoverLetterByID(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	coverLetterId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid cover letter id")
+		return
+	}
+
+	// Call store method to retrieve CoverLetter from MongoDB
+	coverLetter, err := h.StoreClient.GetCoverLetterByID(profileId, coverLetterId)
+	if err != nil && strings.Contains(err.Error(), "no document") {
+		respondError(c, http.StatusNotFound, "cover letter not found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, coverLetter, nil)
+}
+
+// HandleUpdateCoverLetter handles a PUT method to save the user edits of a cover letter
+func (h *Handler) HandleUpdateCoverLetter(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	coverLetterId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid cover letter id")
+		return
+	}
+	var updateRequest types.CoverLetterUpdateRequest
+	if !bindJSON(c, &updateRequest) {
+		return
+	}
+
+	// Call store method to update the CoverLetter content in MongoDB
+	coverLetter, err := h.StoreClient.UpdateCoverLetterContent(profileId, coverLetterId, updateRequest.Content)
+	if err != nil && strings.Contains(err.Error(), "no document") {
+		respondError(c, http.StatusNotFound, "cover letter not found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondMessage(c, http.StatusOK, coverLetter, "cover letter has been updated")
+}
+
+// HandleDeleteCoverLetter handles a DELETE request to delete a cover letter of the profile
+func (h *Handler) HandleDeleteCoverLetter(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	coverLetterId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid cover letter id")
+		return
+	}
+
+	err = h.StoreClient.DeleteCoverLetter(profileId, coverLetterId)
+	if err != nil && strings.Contains(err.Error(), "no cover letter found") {
+		respondError(c, http.StatusNotFound, "cover letter not found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondMessage(c, http.StatusOK, nil, "cover letter deleted successfully")
+}
+
+// HandleReviseCoverLetter handles a POST method that applies a user instruction to a cover letter of the profile,
+// saving the revision as a new version with the diff against the revised cover letter
+func (h *Handler) HandleReviseCoverLetter(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	coverLetterId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid cover letter id")
+		return
+	}
+	var revisionRequest types.CoverLetterRevisionRequest
+	if !bindJSON(c, &revisionRequest) {
+		return
+	}
+
+	coverLetter, err := h.StoreClient.GetCoverLetterByID(profileId, coverLetterId)
+	if err != nil && strings.Contains(err.Error(), "no document") {
+		respondError(c, http.StatusNotFound, "cover letter not found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Call the LLM provider to revise the cover letter, continuing its conversation
+	revised, statusCode, err := h.OpenAIClient.ReviseChatGPTCoverLetter(c, coverLetter, revisionRequest.Instruction, h.StoreClient)
+	if err != nil {
+		h.recordFailedUsage(profileId, usage.OperationCoverLetterRevision, err)
+		respondGenerationError(c, statusCode, err)
+		return
+	}
+
+	h.recordCoverLetterUsage(profileId, usage.OperationCoverLetterRevision, revised)
+	revision := h.saveCoverLetter(&types.CoverLetter{
+		ProfileID:        profileId,
+		JobApplicationID: coverLetter.JobApplicationID,
+		JobPosting:       coverLetter.JobPosting,
+		Options:          coverLetter.Options,
+		Version:          max(coverLetter.Version, 1) + 1,
+		ParentID:         &coverLetter.ID,
+		Instruction:      revisionRequest.Instruction,
+		Diff:             diff.Lines(coverLetter.Content, revised.Content),
+	}, revised)
+	if revision == nil {
+		respondError(c, http.StatusInternalServerError, "error saving the cover letter revision")
+		return
+	}
+
+	meta := map[string]interface{}{
+		"model":          revised.Model,
+		"prompt_version": revised.PromptVersion,
+		"usage":          revised.Usage,
+	}
+	if len(revised.Placeholders) > 0 {
+		meta["placeholders"] = revised.Placeholders
+	}
+	respond(c, http.StatusOK, revision, meta)
+}
+
+// HandleExportCoverLetter handles a GET method that downloads a cover letter of the profile as a document
+// in the format of the query, with the letterhead from the career profile contact information
+func (h *Handler) HandleExportCoverLetter(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	coverLetterId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid cover letter id")
+		return
+	}
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	coverLetter, err := h.StoreClient.GetCoverLetterByID(profileId, coverLetterId)
+	if err != nil && strings.Contains(err.Error(), "no document") {
+		respondError(c, http.StatusNotFound, "cover letter not found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	careerProfile, err := h.StoreClient.GetCareerProfileByID(profileId)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	document, err := export.Render(export.NewLetter(coverLetter, careerProfile), format)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, exportFilename("cover-letter", &coverLetter.JobPosting, format)))
+	c.Data(http.StatusOK, export.ContentType(format), document)
+}
+
+// exportFormat returns the export format of the request query, pdf by default, and responds 422 when it is not supported
+func exportFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", export.FormatPDF)
+	if !slices.Contains(export.Formats, format) {
+		respondErrorDetails(c, http.StatusUnprocessableEntity, ErrorCodeValidationFailed, "request validation failed",
+			[]types.ValidationErrorDetail{{Field: "format", Rule: "oneof", Message: "must be one of: " + strings.Join(export.Formats, " ")}})
+		return "", false
+	}
+	return format, true
+}
+
+var filenameUnsafeCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// exportFilename returns the download file name of a document for a job posting, e.g. cover-letter-acme-manager.pdf
+func exportFilename(document string, jobPosting *types.JobPosting, format string) string {
+	name := strings.ToLower(jobPosting.CompanyName + " " + jobPosting.JobRole)
+	name = strings.Trim(filenameUnsafeCharacters.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		return document + "." + format
+	}
+	return document + "-" + name + "." + format
+}
+
+// exportContentTypes returns the content types of the cover letter and resume export formats
+func exportContentTypes() []string {
+	contentTypes := make([]string, len(export.Formats))
+	for i, format := range export.Formats {
+		contentTypes[i] = export.ContentType(format)
+	}
+	return contentTypes
+}
@@ -6,83 +6,302 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"os"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jonada182/cover-letter-ai-api/internal/cache"
+	"github.com/jonada182/cover-letter-ai-api/internal/jobposting"
+	"github.com/jonada182/cover-letter-ai-api/internal/llm"
+	"github.com/jonada182/cover-letter-ai-api/internal/ratelimit"
+	"github.com/jonada182/cover-letter-ai-api/internal/usage"
 	"github.com/jonada182/cover-letter-ai-api/types"
 )
 
 type HandlerInterface interface {
 	HandleIndex(c *gin.Context)
+	HandleOpenAPISpec(c *gin.Context)
+	HandleSwaggerUI(c *gin.Context)
 	HandleCoverLetter(c *gin.Context)
+	HandleCoverLetterStream(c *gin.Context)
+	HandleGetCoverLetters(c *gin.Context)
+	HandleGetCoverLetterByID(c *gin.Context)
+	HandleUpdateCoverLetter(c *gin.Context)
+	HandleDeleteCoverLetter(c *gin.Context)
+	HandleReviseCoverLetter(c *gin.Context)
+	HandleExportCoverLetter(c *gin.Context)
 	HandleCreateCareerProfile(c *gin.Context)
 	HandleGetCareerProfile(c *gin.Context)
 	HandleCreateJobApplication(c *gin.Context)
 	HandleGetJobApplications(c *gin.Context)
 	HandleGetJobApplicationByID(c *gin.Context)
 	HandleDeleteJobApplication(c *gin.Context)
+	HandleJobApplicationCoverLetter(c *gin.Context)
+	HandleGetJobApplicationCoverLetters(c *gin.Context)
+	HandleInterviewPrep(c *gin.Context)
+	HandleGetInterviewPrep(c *gin.Context)
+	HandleParseJobPosting(c *gin.Context)
+	HandleImportJobPosting(c *gin.Context)
+	HandleSkillsGap(c *gin.Context)
+	HandleTailorResume(c *gin.Context)
+	HandleGetResumes(c *gin.Context)
+	HandleGetResumeByID(c *gin.Context)
+	HandleExportResume(c *gin.Context)
+	HandleGetUsage(c *gin.Context)
+	HandleAdminUsage(c *gin.Context)
 	HandleLinkedInCallback(c *gin.Context)
 	HandleAuth(c *gin.Context)
 }
 
 type Handler struct {
-	StoreClient  types.StoreClient
-	OpenAIClient types.OpenAIClient
+	StoreClient    types.StoreClient
+	OpenAIClient   types.OpenAIClient
+	ProfileLimiter ratelimit.Limiter
+	IPLimiter      ratelimit.Limiter
+	Quota          ratelimit.Quota
+	// GenerationQuota limits the LLM generations other than cover letters
+	GenerationQuota ratelimit.Quota
+	CORS            CORSConfig
+	// AllowedModels are the models clients can request in the cover letter options
+	AllowedModels []string
+	// Prices are the model prices used to estimate the cost of the LLM calls
+	Prices usage.Prices
+	// AdminAPIKey is required by the admin routes, which are disabled when it is empty
+	AdminAPIKey string
+	// Cache returns the cover letters of identical generation requests, and is nil when caching is disabled
+	Cache cache.Cache
+	// JobPostingFetcher downloads the job posting pages to import
+	JobPostingFetcher *jobposting.Fetcher
 }
 
 // NewHandler Initializes application handler allowing the injection of clients
 func NewHandler(s types.StoreClient, o types.OpenAIClient) *Handler {
+	rateLimitConfig := ratelimit.ConfigFromEnv()
+	profileLimiter, ipLimiter := newRateLimiters(s, rateLimitConfig)
+	quota, generationQuota := newQuotas(s, rateLimitConfig)
 	return &Handler{
-		StoreClient:  s,
-		OpenAIClient: o,
+		StoreClient:       s,
+		OpenAIClient:      o,
+		ProfileLimiter:    profileLimiter,
+		IPLimiter:         ipLimiter,
+		Quota:             quota,
+		GenerationQuota:   generationQuota,
+		CORS:              CORSConfigFromEnv(),
+		AllowedModels:     llm.AllowedModelsFromEnv(),
+		Prices:            usage.PricesFromEnv(),
+		AdminAPIKey:       os.Getenv("ADMIN_API_KEY"),
+		Cache:             cache.New(s, cache.ConfigFromEnv()),
+		JobPostingFetcher: jobposting.NewFetcher(jobposting.FetchConfigFromEnv()),
 	}
 }
 
+// APIVersionPrefix is the path prefix of the current API version
+const APIVersionPrefix = "/v1"
+
 // setupRouter sets all the API endpoints and returns a gin router
 func (h *Handler) SetupRouter() *gin.Engine {
 	router := gin.Default()
-	router.Use(h.middleware())
-	router.GET("/", h.HandleIndex)
-	router.POST("/cover-letter", h.HandleCoverLetter)
-	router.POST("/career-profile", h.HandleCreateCareerProfile)
-	router.GET("/career-profile", h.HandleGetCareerProfile)
-	router.POST("/job-applications", h.HandleCreateJobApplication)
-	router.GET("/job-applications", h.HandleGetJobApplications)
-	router.GET("/job-applications/:id", h.HandleGetJobApplicationByID)
-	router.DELETE("/job-applications/:id", h.HandleDeleteJobApplication)
-	router.GET("/linkedin/callback", h.HandleLinkedInCallback)
-	router.GET("/auth", h.HandleAuth)
+	router.Use(cors(h.CORS, router.Routes))
+	router.GET("/openapi.json", h.HandleOpenAPISpec)
+	router.GET("/docs", h.HandleSwaggerUI)
+	h.registerRoutes(router.Group(APIVersionPrefix))
+	// Unversioned routes are kept as deprecated aliases of the current API version
+	h.registerRoutes(router.Group("", deprecated()))
 	return router
 }
 
+// registerRoutes sets all the versioned API endpoints on the given router group
+func (h *Handler) registerRoutes(router *gin.RouterGroup) {
+	router.GET("/linkedin/callback", h.HandleLinkedInCallback)
+	router.GET("/auth", h.requireAccessToken(), h.HandleAuth)
+	router.GET("/admin/usage", h.requireAdminKey(), h.HandleAdminUsage)
+
+	authenticated := router.Group("", h.authenticate())
+	authenticated.GET("/", h.HandleIndex)
+	authenticated.POST("/cover-letter", h.rateLimit(), h.HandleCoverLetter)
+	authenticated.POST("/cover-letter/stream", h.rateLimit(), h.HandleCoverLetterStream)
+	authenticated.GET("/cover-letters", h.HandleGetCoverLetters)
+	authenticated.GET("/cover-letters/:id", h.HandleGetCoverLetterByID)
+	authenticated.PUT("/cover-letters/:id", h.HandleUpdateCoverLetter)
+	authenticated.DELETE("/cover-letters/:id", h.HandleDeleteCoverLetter)
+	authenticated.POST("/cover-letters/:id/revise", h.rateLimit(), h.HandleReviseCoverLetter)
+	authenticated.GET("/cover-letters/:id/export", h.HandleExportCoverLetter)
+	authenticated.POST("/career-profile", h.HandleCreateCareerProfile)
+	authenticated.GET("/career-profile", h.HandleGetCareerProfile)
+	authenticated.POST("/job-applications", h.HandleCreateJobApplication)
+	authenticated.GET("/job-applications", h.HandleGetJobApplications)
+	authenticated.GET("/job-applications/:id", h.HandleGetJobApplicationByID)
+	authenticated.DELETE("/job-applications/:id", h.HandleDeleteJobApplication)
+	authenticated.POST("/job-applications/:id/cover-letter", h.rateLimit(), h.HandleJobApplicationCoverLetter)
+	authenticated.GET("/job-applications/:id/cover-letters", h.HandleGetJobApplicationCoverLetters)
+	authenticated.POST("/job-applications/:id/interview-prep", h.generationRateLimit(), h.HandleInterviewPrep)
+	authenticated.GET("/job-applications/:id/interview-prep", h.HandleGetInterviewPrep)
+	authenticated.POST("/job-postings/parse", h.throttle(), h.HandleParseJobPosting)
+	authenticated.POST("/job-postings/import", h.throttle(), h.HandleImportJobPosting)
+	authenticated.POST("/analysis/skills-gap", h.generationRateLimit(), h.HandleSkillsGap)
+	authenticated.POST("/resume/tailor", h.generationRateLimit(), h.HandleTailorResume)
+	authenticated.GET("/resumes", h.HandleGetResumes)
+	authenticated.GET("/resumes/:id", h.HandleGetResumeByID)
+	authenticated.GET("/resumes/:id/export", h.HandleExportResume)
+	authenticated.GET("/me/usage", h.HandleGetUsage)
+}
+
 // HandleIndex returns a welcome message when "/" is accessed
 func (h *Handler) HandleIndex(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Welcome to the CoverLetterAI API"})
+	respondMessage(c, http.StatusOK, nil, "Welcome to the CoverLetterAI API")
 }
 
 // HandleCoverLetter handles a POST method that returns a cover letter from OpenAI
 func (h *Handler) HandleCoverLetter(c *gin.Context) {
 	// Receive CoverLetterRequest parameters from request payload
 	var coverLetterRequest types.CoverLetterRequest
-	if err := c.ShouldBindJSON(&coverLetterRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("error retrieving JSON: %s", err.Error())})
+	if !bindJSON(c, &coverLetterRequest) || !h.validateModel(c, coverLetterRequest.Options.Model) || !requireOwnProfile(c, coverLetterRequest.ProfileID) {
 		return
 	}
-
-	jobPosting := coverLetterRequest.JobPosting
-	if jobPosting.CompanyName == "" || jobPosting.JobRole == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "company name and job role are required"})
+	if coverLetterRequest.Variants > 1 {
+		h.handleCoverLetterVariants(c, &coverLetterRequest)
 		return
 	}
+	jobPosting := coverLetterRequest.JobPosting
+
+	cacheKey, coverLetter := h.cachedCoverLetter(&coverLetterRequest)
+	cached := coverLetter != nil
+	if !cached {
+		// Call OpenAI to generate a cover letter with the given parameters
+		var statusCode int
+		var err error
+		coverLetter, statusCode, err = h.OpenAIClient.GenerateChatGPTCoverLetter(c, coverLetterRequest.ProfileID, &jobPosting, &coverLetterRequest.Options, h.StoreClient)
+		if err != nil {
+			h.recordFailedUsage(coverLetterRequest.ProfileID, usage.OperationCoverLetter, err)
+			respondGenerationError(c, statusCode, err)
+			return
+		}
+		h.recordCoverLetterUsage(coverLetterRequest.ProfileID, usage.OperationCoverLetter, coverLetter)
+	}
 
-	// Call OpenAI to generate a cover letter with the given parameters
-	coverLetter, statusCode, err := h.OpenAIClient.GenerateChatGPTCoverLetter(c, coverLetterRequest.ProfileID, &jobPosting, h.StoreClient)
-	if err != nil {
-		c.JSON(statusCode, gin.H{"error": err.Error()})
+	// The cached cover letters were saved in the history when they were generated
+	var savedCoverLetter *types.CoverLetter
+	if !cached {
+		savedCoverLetter = h.saveCoverLetter(&types.CoverLetter{ProfileID: coverLetterRequest.ProfileID, JobPosting: jobPosting, Options: coverLetterRequest.Options}, coverLetter)
+		h.cacheCoverLetter(cacheKey, coverLetter, savedCoverLetter)
+	}
+	meta := generatedCoverLetterMeta(coverLetter, savedCoverLetter)
+	if cached {
+		cachedCoverLetterMeta(meta, coverLetter)
+		refundQuota(c)
+	}
+	respond(c, http.StatusOK, coverLetter.Content, meta)
+}
+
+// generatedCoverLetterMeta returns the response meta recording how a cover letter was generated,
+// and its id in the history when it was saved
+func generatedCoverLetterMeta(coverLetter *types.GeneratedCoverLetter, savedCoverLetter *types.CoverLetter) map[string]interface{} {
+	meta := map[string]interface{}{
+		"model":          coverLetter.Model,
+		"prompt_version": coverLetter.PromptVersion,
+		"usage":          coverLetter.Usage,
+	}
+	if savedCoverLetter != nil {
+		meta["cover_letter_id"] = savedCoverLetter.ID
+	}
+	if len(coverLetter.Placeholders) > 0 {
+		meta["placeholders"] = coverLetter.Placeholders
+	}
+	if len(coverLetter.Truncated) > 0 {
+		meta["truncated"] = coverLetter.Truncated
+	}
+	if len(coverLetter.Summarized) > 0 {
+		meta["summarized"] = coverLetter.Summarized
+	}
+	return meta
+}
+
+// HandleCoverLetterStream handles a POST method that streams a cover letter from the LLM provider as Server-Sent Events:
+// "token" events with the generated content, followed by a "done" event with the parsed cover letter,
+// or an "error" event if the generation fails after the stream started
+func (h *Handler) HandleCoverLetterStream(c *gin.Context) {
+	// Receive CoverLetterRequest parameters from request payload
+	var coverLetterRequest types.CoverLetterRequest
+	if !bindJSON(c, &coverLetterRequest) || !h.validateModel(c, coverLetterRequest.Options.Model) || !requireOwnProfile(c, coverLetterRequest.ProfileID) {
+		return
+	}
+	if coverLetterRequest.Variants > 1 {
+		respondErrorDetails(c, http.StatusUnprocessableEntity, ErrorCodeValidationFailed, "request validation failed",
+			[]types.ValidationErrorDetail{{Field: "n", Rule: "max", Message: "must be at most 1 when streaming"}})
 		return
 	}
+	jobPosting := coverLetterRequest.JobPosting
+
+	streaming := false
+	startStream := func() {
+		if streaming {
+			return
+		}
+		streaming = true
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Status(http.StatusOK)
+	}
+	onDelta := func(delta string) error {
+		// Stop generating when the client disconnects
+		if err := c.Request.Context().Err(); err != nil {
+			return err
+		}
+		startStream()
+		c.SSEvent("token", gin.H{"content": delta})
+		c.Writer.Flush()
+		return nil
+	}
 
-	c.JSON(http.StatusOK, gin.H{"data": coverLetter})
+	// A cached cover letter is only sent with the done event
+	cacheKey, coverLetter := h.cachedCoverLetter(&coverLetterRequest)
+	cached := coverLetter != nil
+	if !cached {
+		// Call the LLM provider to stream a cover letter with the given parameters
+		var statusCode int
+		var err error
+		coverLetter, statusCode, err = h.OpenAIClient.StreamChatGPTCoverLetter(c, coverLetterRequest.ProfileID, &jobPosting, &coverLetterRequest.Options, h.StoreClient, onDelta)
+		if err != nil {
+			h.recordFailedUsage(coverLetterRequest.ProfileID, usage.OperationCoverLetterStream, err)
+		} else {
+			h.recordCoverLetterUsage(coverLetterRequest.ProfileID, usage.OperationCoverLetterStream, coverLetter)
+		}
+		if err != nil {
+			// The stream status stays 200 once it started, so the failed generation is refunded explicitly
+			refundQuota(c)
+		}
+		if c.Request.Context().Err() != nil {
+			log.Printf("client disconnected from cover letter stream")
+			return
+		}
+		if err != nil && !streaming {
+			// Nothing was sent yet, so the error can be returned as a regular response
+			respondGenerationError(c, statusCode, err)
+			return
+		}
+		if err != nil {
+			c.SSEvent("error", types.Response{Error: &types.ResponseError{Code: errorCode(statusCode), Message: err.Error()}})
+			c.Writer.Flush()
+			return
+		}
+	}
+
+	// The cached cover letters were saved in the history when they were generated
+	var savedCoverLetter *types.CoverLetter
+	if !cached {
+		savedCoverLetter = h.saveCoverLetter(&types.CoverLetter{ProfileID: coverLetterRequest.ProfileID, JobPosting: jobPosting, Options: coverLetterRequest.Options}, coverLetter)
+		h.cacheCoverLetter(cacheKey, coverLetter, savedCoverLetter)
+	}
+	meta := generatedCoverLetterMeta(coverLetter, savedCoverLetter)
+	if cached {
+		cachedCoverLetterMeta(meta, coverLetter)
+		refundQuota(c)
+	}
+	startStream()
+	c.SSEvent("done", types.Response{Data: coverLetter.Content, Meta: meta})
+	c.Writer.Flush()
 }
 
 // readResponse returns a string map from a response body
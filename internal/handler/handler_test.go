@@ -3,11 +3,23 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/internal/cache"
+	"github.com/jonada182/cover-letter-ai-api/internal/jobposting"
+	"github.com/jonada182/cover-letter-ai-api/internal/llm"
+	"github.com/jonada182/cover-letter-ai-api/internal/openai"
+	"github.com/jonada182/cover-letter-ai-api/internal/openai/openaitest"
+	"github.com/jonada182/cover-letter-ai-api/internal/ratelimit"
+	"github.com/jonada182/cover-letter-ai-api/internal/store"
 	"github.com/jonada182/cover-letter-ai-api/mocks"
 	"github.com/jonada182/cover-letter-ai-api/types"
 	"github.com/jonada182/cover-letter-ai-api/util"
@@ -33,7 +45,7 @@ func TestHandler(t *testing.T) {
 		assert.Equal(t, http.StatusOK, recorder.Code)
 
 		// Check the response body
-		expectedResponse := `{"message":"Welcome to the CoverLetterAI API"}`
+		expectedResponse := `{"data":null,"meta":{"message":"Welcome to the CoverLetterAI API"}}`
 		assert.Equal(t, expectedResponse, recorder.Body.String())
 	})
 
@@ -60,7 +72,7 @@ func TestHandler(t *testing.T) {
 
 			// Setup request handler
 			handler := NewHandler(mockStore, mockOpenAI)
-			router.Use(handler.middleware())
+			router.Use(handler.authenticate())
 			router.POST(apiEndpoint, handler.HandleCoverLetter)
 			// Create a new HTTP request with no payload
 			req, err := http.NewRequest(http.MethodPost, apiEndpoint, nil)
@@ -73,7 +85,7 @@ func TestHandler(t *testing.T) {
 			// Check the response status code
 			assert.Equal(t, http.StatusBadRequest, recorder.Code)
 			// Check the response body
-			expectedResponse := `{"error":"error retrieving JSON: invalid request"}`
+			expectedResponse := `{"data":null,"error":{"code":"bad_request","message":"error retrieving JSON: invalid request"}}`
 			assert.Equal(t, expectedResponse, recorder.Body.String())
 		})
 
@@ -84,6 +96,7 @@ func TestHandler(t *testing.T) {
 
 			email := "test@email"
 			profileId := uuid.New()
+			coverLetterId := uuid.New()
 			accessToken := "some_token"
 			requestData := types.CoverLetterRequest{
 				ProfileID: profileId,
@@ -105,18 +118,42 @@ func TestHandler(t *testing.T) {
 			mockStore := mocks.NewMockStore(ctrl)
 			mockOpenAI := mocks.NewMockOpenAI(ctrl)
 			mockOpenAI.EXPECT().
-				GenerateChatGPTCoverLetter(gomock.Any(), gomock.Eq(profileId), gomock.Eq(&requestData.JobPosting), gomock.Any()).
-				Return("perfect cover letter", 200, nil).
+				GenerateChatGPTCoverLetter(gomock.Any(), gomock.Eq(profileId), gomock.Eq(&requestData.JobPosting), gomock.Any(), gomock.Any()).
+				Return(&types.GeneratedCoverLetter{Content: "perfect cover letter", Model: "gpt-3.5-turbo", PromptVersion: "cover_letter/v1", Usage: types.TokenUsage{PromptTokens: 40, CompletionTokens: 3, TotalTokens: 43}}, 200, nil).
 				Times(1)
 			mockStore.
 				EXPECT().
 				ValidateAccessToken(gomock.Eq(profileId), gomock.Eq(accessToken), gomock.Any()).
 				Return(true, nil).
 				Times(1)
+			mockStore.
+				EXPECT().
+				StoreCoverLetter(gomock.Any()).
+				DoAndReturn(func(coverLetter *types.CoverLetter) (*types.CoverLetter, error) {
+					assert.Equal(t, profileId, coverLetter.ProfileID)
+					assert.Equal(t, "perfect cover letter", coverLetter.Content)
+					assert.Equal(t, 43, coverLetter.Usage.TotalTokens)
+					coverLetter.ID = coverLetterId
+					return coverLetter, nil
+				}).
+				Times(1)
+			mockStore.
+				EXPECT().
+				StoreUsageRecord(gomock.Any()).
+				DoAndReturn(func(usageRecord *types.UsageRecord) error {
+					assert.Equal(t, profileId, usageRecord.ProfileID)
+					assert.Equal(t, "cover_letter", usageRecord.Operation)
+					assert.Equal(t, "gpt-3.5-turbo", usageRecord.Model)
+					assert.Equal(t, 43, usageRecord.Usage.TotalTokens)
+					assert.Equal(t, 0.0000245, usageRecord.Cost)
+					assert.Equal(t, time.Now().Format("2006-01-02"), usageRecord.Date)
+					return nil
+				}).
+				Times(1)
 
 			// Setup mocks and expectations
 			handler := NewHandler(mockStore, mockOpenAI)
-			router.Use(handler.middleware())
+			router.Use(handler.authenticate())
 			router.POST(apiEndpoint, handler.HandleCoverLetter)
 
 			// Create a new HTTP request with valid payload
@@ -134,11 +171,263 @@ func TestHandler(t *testing.T) {
 			assert.Equal(t, http.StatusOK, recorder.Code)
 
 			// Check the response body
-			expectedResponse := `{"data":"perfect cover letter"}`
+			expectedResponse := fmt.Sprintf(`{"data":"perfect cover letter","meta":{"cover_letter_id":"%s","model":"gpt-3.5-turbo","prompt_version":"cover_letter/v1","usage":{"prompt_tokens":40,"completion_tokens":3,"total_tokens":43}}}`, coverLetterId)
+			assert.Equal(t, expectedResponse, recorder.Body.String())
+		})
+
+		t.Run("provider unavailable", func(t *testing.T) {
+			// Setup httptest, gin router and environment variables
+			router, recorder := util.SetupTestRouter()
+			util.SetupTestEnvironment(t)
+
+			profileId := uuid.New()
+			accessToken := "some_token"
+			requestData := types.CoverLetterRequest{
+				ProfileID:  profileId,
+				JobPosting: types.JobPosting{CompanyName: "Acme", JobRole: "Manager", Details: "Great worker"},
+			}
+			providerError := &llm.APIError{Provider: "openai", StatusCode: http.StatusTooManyRequests, Code: "rate_limit_exceeded", Message: "Rate limit reached", RetryAfter: 20 * time.Second}
+
+			// Setup mocks and expectations
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockStore := mocks.NewMockStore(ctrl)
+			mockOpenAI := mocks.NewMockOpenAI(ctrl)
+			mockOpenAI.EXPECT().
+				GenerateChatGPTCoverLetter(gomock.Any(), gomock.Eq(profileId), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(nil, llm.HTTPStatus(providerError), providerError).
+				Times(1)
+			mockStore.
+				EXPECT().
+				ValidateAccessToken(gomock.Eq(profileId), gomock.Eq(accessToken), gomock.Any()).
+				Return(true, nil).
+				Times(1)
+
+			handler := NewHandler(mockStore, mockOpenAI)
+			router.Use(handler.authenticate())
+			router.POST(apiEndpoint, handler.HandleCoverLetter)
+
+			requestBody, err := json.Marshal(requestData)
+			assert.NoError(t, err)
+			req, err := http.NewRequest(http.MethodPost, apiEndpoint, bytes.NewBuffer(requestBody))
+			req.Header.Set("UserID", profileId.String())
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+			assert.NoError(t, err)
+
+			// Serve the request
+			router.ServeHTTP(recorder, req)
+
+			// The provider rate limit is returned as 503 with its Retry-After delay
+			assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+			assert.Equal(t, "20", recorder.Header().Get("Retry-After"))
+			expectedResponse := `{"data":null,"error":{"code":"service_unavailable","message":"openai API error (status 429, rate_limit_exceeded): Rate limit reached"}}`
 			assert.Equal(t, expectedResponse, recorder.Body.String())
 		})
 	})
 
+	t.Run("HandleCoverLetterStream", func(t *testing.T) {
+		apiEndpoint := "/cover-letter/stream"
+		profileId := uuid.New()
+		accessToken := "some_token"
+		requestData := types.CoverLetterRequest{
+			ProfileID: profileId,
+			JobPosting: types.JobPosting{
+				CompanyName: "Acme",
+				JobRole:     "Manager",
+			},
+		}
+		requestBody, err := json.Marshal(requestData)
+		assert.NoError(t, err)
+
+		for name, streamErr := range map[string]error{"completed stream": nil, "upstream error": errors.New("upstream failed")} {
+			t.Run(name, func(t *testing.T) {
+				// Setup httptest, gin router and environment variables
+				router, recorder := util.SetupTestRouter()
+				util.SetupTestEnvironment(t)
+
+				// Setup mocks and expectations
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+				mockStore := mocks.NewMockStore(ctrl)
+				mockOpenAI := mocks.NewMockOpenAI(ctrl)
+				mockStore.
+					EXPECT().
+					ValidateAccessToken(gomock.Eq(profileId), gomock.Eq(accessToken), gomock.Any()).
+					Return(true, nil).
+					Times(1)
+				if streamErr == nil {
+					mockStore.
+						EXPECT().
+						StoreCoverLetter(gomock.Any()).
+						Return(nil, errors.New("store unavailable")).
+						Times(1)
+					mockStore.EXPECT().StoreUsageRecord(gomock.Any()).Return(errors.New("store unavailable")).Times(1)
+				}
+				mockOpenAI.EXPECT().
+					StreamChatGPTCoverLetter(gomock.Any(), gomock.Eq(profileId), gomock.Eq(&requestData.JobPosting), gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ *gin.Context, _ uuid.UUID, _ *types.JobPosting, _ *types.CoverLetterOptions, _ types.StoreClient, onDelta func(string) error) (*types.GeneratedCoverLetter, int, error) {
+						assert.NoError(t, onDelta("Dear "))
+						assert.NoError(t, onDelta("Hiring Manager,"))
+						if streamErr != nil {
+							return nil, http.StatusInternalServerError, streamErr
+						}
+						return &types.GeneratedCoverLetter{Content: "John Doe\n\nDear Hiring Manager,", Model: "gpt-3.5-turbo", PromptVersion: "cover_letter/v1"}, http.StatusOK, nil
+					}).
+					Times(1)
+
+				// Setup request handler allowing a single generation per day
+				handler := NewHandler(mockStore, mockOpenAI)
+				handler.Quota = ratelimit.NewMemoryQuota(1, 0)
+				router.Use(handler.authenticate())
+				router.POST(apiEndpoint, handler.rateLimit(), handler.HandleCoverLetterStream)
+
+				req, err := http.NewRequest(http.MethodPost, apiEndpoint, bytes.NewBuffer(requestBody))
+				req.Header.Set("UserID", profileId.String())
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+				assert.NoError(t, err)
+
+				// Serve the request
+				router.ServeHTTP(recorder, req)
+
+				// Check the response status code and events
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+				expectedEvents := "event:token\ndata:{\"content\":\"Dear \"}\n\nevent:token\ndata:{\"content\":\"Hiring Manager,\"}\n\n"
+				if streamErr != nil {
+					expectedEvents += "event:error\ndata:{\"data\":null,\"error\":{\"code\":\"internal_server_error\",\"message\":\"upstream failed\"}}\n\n"
+				} else {
+					expectedEvents += "event:done\ndata:{\"data\":\"John Doe\\n\\nDear Hiring Manager,\",\"meta\":{\"model\":\"gpt-3.5-turbo\",\"prompt_version\":\"cover_letter/v1\",\"usage\":{\"prompt_tokens\":0,\"completion_tokens\":0,\"total_tokens\":0}}}\n\n"
+				}
+				assert.Equal(t, expectedEvents, recorder.Body.String())
+
+				// The failed stream is refunded even though its status is 200
+				quota, err := handler.Quota.Consume(profileId)
+				assert.NoError(t, err)
+				assert.Equal(t, streamErr != nil, quota.Allowed)
+			})
+		}
+	})
+
+	t.Run("RateLimit", func(t *testing.T) {
+		apiEndpoint := "/cover-letter"
+		// Setup httptest, gin router and environment variables
+		router, _ := util.SetupTestRouter()
+		util.SetupTestEnvironment(t)
+
+		profileId := uuid.New()
+		accessToken := "some_token"
+		requestData := types.CoverLetterRequest{
+			ProfileID: profileId,
+			JobPosting: types.JobPosting{
+				CompanyName: "Acme",
+				JobRole:     "Manager",
+			},
+		}
+
+		// Setup mocks and expectations
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockStore := mocks.NewMockStore(ctrl)
+		mockOpenAI := mocks.NewMockOpenAI(ctrl)
+		mockStore.
+			EXPECT().
+			ValidateAccessToken(gomock.Eq(profileId), gomock.Eq(accessToken), gomock.Any()).
+			Return(true, nil).
+			Times(2)
+		mockStore.EXPECT().StoreCoverLetter(gomock.Any()).Return(&types.CoverLetter{ID: uuid.New()}, nil).Times(1)
+		mockStore.EXPECT().StoreUsageRecord(gomock.Any()).Return(nil).Times(1)
+		mockOpenAI.EXPECT().
+			GenerateChatGPTCoverLetter(gomock.Any(), gomock.Eq(profileId), gomock.Eq(&requestData.JobPosting), gomock.Any(), gomock.Any()).
+			Return(&types.GeneratedCoverLetter{Content: "perfect cover letter", Model: "gpt-3.5-turbo", PromptVersion: "cover_letter/v1"}, 200, nil).
+			Times(1)
+
+		// Setup request handler allowing a single request per profile
+		handler := NewHandler(mockStore, mockOpenAI)
+		handler.ProfileLimiter = ratelimit.NewMemoryLimiter(1, 1)
+		router.Use(handler.authenticate())
+		router.POST(apiEndpoint, handler.rateLimit(), handler.HandleCoverLetter)
+
+		requestBody, err := json.Marshal(requestData)
+		assert.NoError(t, err)
+		for _, expectedCode := range []int{http.StatusOK, http.StatusTooManyRequests} {
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, apiEndpoint, bytes.NewBuffer(requestBody))
+			req.Header.Set("UserID", profileId.String())
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+			assert.NoError(t, err)
+
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, expectedCode, recorder.Code)
+			assert.Equal(t, "1", recorder.Header().Get("X-RateLimit-Limit"))
+			assert.Equal(t, "0", recorder.Header().Get("X-RateLimit-Remaining"))
+			if expectedCode == http.StatusTooManyRequests {
+				assert.Equal(t, "60", recorder.Header().Get("Retry-After"))
+				assert.Contains(t, recorder.Body.String(), `"code":"rate_limited"`)
+			}
+		}
+	})
+
+	t.Run("GenerationQuota", func(t *testing.T) {
+		apiEndpoint := "/resume/tailor"
+		router, _ := util.SetupTestRouter()
+		util.SetupTestEnvironment(t)
+
+		profileId := uuid.New()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// Setup request handler allowing a single generation per day, which does not use the cover letter quota
+		handler := NewHandler(mocks.NewMockStore(ctrl), mocks.NewMockOpenAI(ctrl))
+		handler.Quota = ratelimit.NewMemoryQuota(1, 0)
+		handler.GenerationQuota = ratelimit.NewMemoryQuota(1, 0)
+		router.Use(func(c *gin.Context) { c.Set("ProfileID", profileId) })
+		router.POST(apiEndpoint, handler.generationRateLimit(), func(c *gin.Context) {
+			respond(c, http.StatusOK, nil, nil)
+		})
+
+		for _, expectedCode := range []int{http.StatusOK, http.StatusTooManyRequests} {
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, apiEndpoint, nil)
+			assert.NoError(t, err)
+
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, expectedCode, recorder.Code)
+			if expectedCode == http.StatusTooManyRequests {
+				assert.Contains(t, recorder.Body.String(), `"code":"quota_exceeded"`)
+				assert.Contains(t, recorder.Body.String(), "daily generation quota of 1 exceeded")
+			}
+		}
+		quota, err := handler.Quota.Consume(profileId)
+		assert.NoError(t, err)
+		assert.True(t, quota.Allowed)
+	})
+
+	t.Run("CoverLetterCache", func(t *testing.T) {
+		profileId := uuid.New()
+		coverLetterId := uuid.New()
+		handler, _, mockOpenAI := newTestHandler(t, profileId)
+		handler.Cache = cache.NewMemoryCache(10, time.Hour)
+		mockOpenAI.EXPECT().CoverLetterCacheKey(gomock.Eq(profileId), gomock.Any(), gomock.Any(), gomock.Any()).Return("some_key", nil).AnyTimes()
+
+		// The cover letter is cached without its date, linked to the cover letter saved in the history
+		handler.cacheCoverLetter("some_key", &types.GeneratedCoverLetter{
+			Content: "John Doe\n\nOctober 1, 2023\n\nDear Hiring Manager,",
+			Usage:   types.TokenUsage{PromptTokens: 120, CompletionTokens: 18, TotalTokens: 138},
+			Date:    "October 1, 2023",
+		}, &types.CoverLetter{ID: coverLetterId})
+		key, coverLetter := handler.cachedCoverLetter(&types.CoverLetterRequest{ProfileID: profileId})
+		assert.Equal(t, "some_key", key)
+		if assert.NotNil(t, coverLetter) {
+			today := time.Now().Format(types.CoverLetterDateLayout)
+			assert.Equal(t, "John Doe\n\n"+today+"\n\nDear Hiring Manager,", coverLetter.Content)
+			assert.Equal(t, today, coverLetter.Date)
+			assert.Equal(t, &coverLetterId, coverLetter.CoverLetterID)
+			assert.Equal(t, types.TokenUsage{}, coverLetter.Usage)
+		}
+	})
+
 	t.Run("HandleCreateCareerProfile", func(t *testing.T) {
 		apiEndpoint := "/career-profile"
 		t.Run("invalid request", func(t *testing.T) {
@@ -162,7 +451,7 @@ func TestHandler(t *testing.T) {
 
 			// Setup mocks and expectations
 			handler := NewHandler(mockStore, mockOpenAI)
-			router.Use(handler.middleware())
+			router.Use(handler.authenticate())
 			router.POST(apiEndpoint, handler.HandleCreateCareerProfile)
 			// Create a new HTTP request with no payload
 			req, err := http.NewRequest(http.MethodPost, apiEndpoint, nil)
@@ -175,16 +464,71 @@ func TestHandler(t *testing.T) {
 			// Check the response status code
 			assert.Equal(t, http.StatusBadRequest, recorder.Code)
 			// Check the response body
-			expectedResponse := `{"error":"error retrieving JSON: invalid request"}`
+			expectedResponse := `{"data":null,"error":{"code":"bad_request","message":"error retrieving JSON: invalid request"}}`
 			assert.Equal(t, expectedResponse, recorder.Body.String())
 		})
 
+		t.Run("validation errors", func(t *testing.T) {
+			// Setup httptest, gin router and environment variables
+			router, recorder := util.SetupTestRouter()
+			util.SetupTestEnvironment(t)
+
+			profileId := uuid.New()
+			accessToken := "some_token"
+			requestData := types.CareerProfile{
+				FirstName: "John",
+				ContactInfo: &types.ContactInfo{
+					Email:   "not an email",
+					Website: "not a url",
+				},
+			}
+
+			// Setup mocks and expectations
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockStore := mocks.NewMockStore(ctrl)
+			mockOpenAI := mocks.NewMockOpenAI(ctrl)
+			mockStore.
+				EXPECT().
+				ValidateAccessToken(gomock.Eq(profileId), gomock.Eq(accessToken), gomock.Any()).
+				Return(true, nil).
+				Times(1)
+
+			// Setup request handler
+			handler := NewHandler(mockStore, mockOpenAI)
+			router.Use(handler.authenticate())
+			router.POST(apiEndpoint, handler.HandleCreateCareerProfile)
+
+			// Create a new HTTP request with an invalid payload
+			requestBody, err := json.Marshal(requestData)
+			assert.NoError(t, err)
+			req, err := http.NewRequest(http.MethodPost, apiEndpoint, bytes.NewBuffer(requestBody))
+			req.Header.Set("UserID", profileId.String())
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+			assert.NoError(t, err)
+
+			// Serve the request
+			router.ServeHTTP(recorder, req)
+			// Check the response status code
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			// Check the response body lists each failing field
+			var response types.Response
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, "validation_failed", response.Error.Code)
+			assert.Equal(t, []interface{}{
+				map[string]interface{}{"field": "headline", "rule": "required", "message": "is required"},
+				map[string]interface{}{"field": "experience_years", "rule": "required", "message": "is required"},
+				map[string]interface{}{"field": "contact_info.email", "rule": "email", "message": "must be a valid email address"},
+				map[string]interface{}{"field": "contact_info.website", "rule": "url", "message": "must be a valid URL"},
+			}, response.Error.Details)
+		})
+
 		t.Run("valid request", func(t *testing.T) {
 			// Setup httptest, gin router and environment variables
 			router, recorder := util.SetupTestRouter()
 			util.SetupTestEnvironment(t)
 
-			email := "test@email"
+			email := "test@email.com"
 			profileId := uuid.New()
 			accessToken := "some_token"
 			requestData := types.CareerProfile{
@@ -227,7 +571,7 @@ func TestHandler(t *testing.T) {
 
 			// Setup mocks and expectations
 			handler := NewHandler(mockStore, mockOpenAI)
-			router.Use(handler.middleware())
+			router.Use(handler.authenticate())
 			router.POST(apiEndpoint, handler.HandleCreateCareerProfile)
 
 			// Create a new HTTP request with valid payload
@@ -245,9 +589,21 @@ func TestHandler(t *testing.T) {
 			assert.Equal(t, http.StatusOK, recorder.Code)
 
 			// Check the response body
-			expectedResponse := fmt.Sprintf("{\"data\":%s,\"message\":\"success\"}", string(expectedData))
+			expectedResponse := fmt.Sprintf("{\"data\":%s,\"meta\":{\"message\":\"success\"}}", string(expectedData))
 			assert.Equal(t, expectedResponse, recorder.Body.String())
 		})
+
+		t.Run("valid request without contact info", func(t *testing.T) {
+			profileId := uuid.New()
+			router, mockStore, _ := newTestRouter(t, profileId)
+			mockStore.EXPECT().StoreCareerProfile(gomock.Any()).DoAndReturn(func(careerProfile *types.CareerProfile) (*types.CareerProfile, string, error) {
+				assert.Nil(t, careerProfile.ContactInfo)
+				return careerProfile, "success", nil
+			}).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/career-profile", `{"headline":"Manager","experience_years":5}`, profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+		})
 	})
 
 	t.Run("HandleGetCareerProfile", func(t *testing.T) {
@@ -289,7 +645,7 @@ func TestHandler(t *testing.T) {
 
 		// Setup mocks and expectations
 		handler := NewHandler(mockStore, mockOpenAI)
-		router.Use(handler.middleware())
+		router.Use(handler.authenticate())
 		router.GET("/career-profile", handler.HandleGetCareerProfile)
 		req, err := http.NewRequest(http.MethodGet, "/career-profile", nil)
 		req.Header.Set("UserID", profileId.String())
@@ -307,6 +663,1314 @@ func TestHandler(t *testing.T) {
 		assert.Equal(t, expectedResponse, recorder.Body.String())
 	})
 
-	// TODO: Write tests for auth endpoint
-	// TODO: Write tests for job applications
+	t.Run("CoverLetters", func(t *testing.T) {
+		profileId := uuid.New()
+		coverLetter := &types.CoverLetter{
+			ID:            uuid.New(),
+			ProfileID:     profileId,
+			JobPosting:    types.JobPosting{CompanyName: "Acme", JobRole: "Manager"},
+			Content:       "perfect cover letter",
+			Model:         "gpt-3.5-turbo",
+			PromptVersion: "cover_letter/v1",
+			Usage:         types.TokenUsage{PromptTokens: 40, CompletionTokens: 3, TotalTokens: 43},
+			CreatedAt:     "2023-10-01 10:00:00",
+			UpdatedAt:     "2023-10-01 10:00:00",
+		}
+		editedCoverLetter := *coverLetter
+		editedCoverLetter.Content = "edited cover letter"
+		editedCoverLetter.Edited = true
+
+		t.Run("list", func(t *testing.T) {
+			router, mockStore, _ := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetCoverLetters(gomock.Eq(profileId)).Return(&[]types.CoverLetter{*coverLetter}, nil).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodGet, "/v1/cover-letters", "", profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			expectedData, err := json.Marshal([]types.CoverLetter{*coverLetter})
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("{\"data\":%s}", string(expectedData)), recorder.Body.String())
+		})
+
+		t.Run("get", func(t *testing.T) {
+			router, mockStore, _ := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetCoverLetterByID(gomock.Eq(profileId), gomock.Eq(coverLetter.ID)).Return(coverLetter, nil).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodGet, "/v1/cover-letters/"+coverLetter.ID.String(), "", profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), `"content":"perfect cover letter"`)
+			assert.Contains(t, recorder.Body.String(), `"usage":{"prompt_tokens":40,"completion_tokens":3,"total_tokens":43}`)
+		})
+
+		t.Run("get a cover letter of another profile", func(t *testing.T) {
+			router, mockStore, _ := newTestRouter(t, profileId)
+			otherCoverLetterId := uuid.New()
+			// The store only finds cover letters of the authenticated profile
+			mockStore.EXPECT().GetCoverLetterByID(gomock.Eq(profileId), gomock.Eq(otherCoverLetterId)).Return(nil, errors.New("mongo: no documents in result")).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodGet, "/v1/cover-letters/"+otherCoverLetterId.String(), "", profileId)
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+			assert.Equal(t, `{"data":null,"error":{"code":"not_found","message":"cover letter not found"}}`, recorder.Body.String())
+		})
+
+		t.Run("invalid id", func(t *testing.T) {
+			router, _, _ := newTestRouter(t, profileId)
+
+			recorder := serveJSON(t, router, http.MethodGet, "/v1/cover-letters/not-an-id", "", profileId)
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), "invalid cover letter id")
+		})
+
+		t.Run("update", func(t *testing.T) {
+			router, mockStore, _ := newTestRouter(t, profileId)
+			mockStore.EXPECT().UpdateCoverLetterContent(gomock.Eq(profileId), gomock.Eq(coverLetter.ID), gomock.Eq("edited cover letter")).Return(&editedCoverLetter, nil).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPut, "/v1/cover-letters/"+coverLetter.ID.String(), `{"content":"edited cover letter"}`, profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), `"content":"edited cover letter"`)
+			assert.Contains(t, recorder.Body.String(), `"edited":true`)
+			assert.Contains(t, recorder.Body.String(), `"message":"cover letter has been updated"`)
+
+			recorder = serveJSON(t, router, http.MethodPut, "/v1/cover-letters/"+coverLetter.ID.String(), `{}`, profileId)
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), `"field":"content","rule":"required"`)
+		})
+
+		t.Run("delete", func(t *testing.T) {
+			router, mockStore, _ := newTestRouter(t, profileId)
+			mockStore.EXPECT().DeleteCoverLetter(gomock.Eq(profileId), gomock.Eq(coverLetter.ID)).Return(nil).Times(1)
+			mockStore.EXPECT().DeleteCoverLetter(gomock.Eq(profileId), gomock.Not(coverLetter.ID)).Return(errors.New("no cover letter found")).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodDelete, "/v1/cover-letters/"+coverLetter.ID.String(), "", profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), "cover letter deleted successfully")
+
+			recorder = serveJSON(t, router, http.MethodDelete, "/v1/cover-letters/"+uuid.New().String(), "", profileId)
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+		})
+
+		t.Run("revise", func(t *testing.T) {
+			router, mockStore, mockOpenAI := newTestRouter(t, profileId)
+			revisionId := uuid.New()
+			mockStore.EXPECT().GetCoverLetterByID(gomock.Eq(profileId), gomock.Eq(coverLetter.ID)).Return(coverLetter, nil).Times(1)
+			mockOpenAI.EXPECT().
+				ReviseChatGPTCoverLetter(gomock.Any(), gomock.Eq(coverLetter), gomock.Eq("make it shorter"), gomock.Any()).
+				Return(&types.GeneratedCoverLetter{Content: "short cover letter", Model: "gpt-3.5-turbo", PromptVersion: "cover_letter_revision/v1"}, http.StatusOK, nil).
+				Times(1)
+			mockStore.EXPECT().
+				StoreCoverLetter(gomock.Any()).
+				DoAndReturn(func(revision *types.CoverLetter) (*types.CoverLetter, error) {
+					assert.Equal(t, 2, revision.Version)
+					assert.Equal(t, &coverLetter.ID, revision.ParentID)
+					assert.Equal(t, "make it shorter", revision.Instruction)
+					assert.Equal(t, "- perfect cover letter\n+ short cover letter\n", revision.Diff)
+					assert.Equal(t, "short cover letter", revision.Content)
+					revision.ID = revisionId
+					return revision, nil
+				}).
+				Times(1)
+			mockStore.EXPECT().
+				StoreUsageRecord(gomock.Any()).
+				DoAndReturn(func(usageRecord *types.UsageRecord) error {
+					assert.Equal(t, "cover_letter_revision", usageRecord.Operation)
+					return nil
+				}).
+				Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/cover-letters/"+coverLetter.ID.String()+"/revise", `{"instruction":"make it shorter"}`, profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), fmt.Sprintf(`"id":"%s"`, revisionId))
+			assert.Contains(t, recorder.Body.String(), `"version":2`)
+			assert.Contains(t, recorder.Body.String(), `"prompt_version":"cover_letter_revision/v1"`)
+
+			recorder = serveJSON(t, router, http.MethodPost, "/v1/cover-letters/"+coverLetter.ID.String()+"/revise", `{}`, profileId)
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		})
+
+		t.Run("export", func(t *testing.T) {
+			router, mockStore, _ := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetCoverLetterByID(gomock.Eq(profileId), gomock.Eq(coverLetter.ID)).Return(coverLetter, nil).Times(2)
+			mockStore.EXPECT().GetCareerProfileByID(gomock.Eq(profileId)).Return(&types.CareerProfile{
+				ID:          profileId,
+				FirstName:   "John",
+				LastName:    "Doe",
+				ContactInfo: &types.ContactInfo{Email: "john@email.com", Phone: "555-0100"},
+			}, nil).Times(2)
+
+			recorder := serveJSON(t, router, http.MethodGet, "/v1/cover-letters/"+coverLetter.ID.String()+"/export?format=txt", "", profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+			assert.Equal(t, `attachment; filename="cover-letter-acme-manager.txt"`, recorder.Header().Get("Content-Disposition"))
+			assert.Equal(t, "John Doe\njohn@email.com | 555-0100\n\nOctober 1, 2023\n\nHiring Manager\nAcme\n\nperfect cover letter\n", recorder.Body.String())
+
+			// The default format is pdf
+			recorder = serveJSON(t, router, http.MethodGet, "/v1/cover-letters/"+coverLetter.ID.String()+"/export", "", profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "application/pdf", recorder.Header().Get("Content-Type"))
+			assert.True(t, strings.HasPrefix(recorder.Body.String(), "%PDF-1.4"))
+
+			recorder = serveJSON(t, router, http.MethodGet, "/v1/cover-letters/"+coverLetter.ID.String()+"/export?format=odt", "", profileId)
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), `"details":[{"field":"format","rule":"oneof","message":"must be one of: pdf docx md html txt"}]`)
+		})
+
+		t.Run("generate for another profile", func(t *testing.T) {
+			router, _, _ := newTestRouter(t, profileId)
+
+			requestBody := fmt.Sprintf(`{"profile_id":"%s","job_posting":{"company_name":"Acme","job_role":"Manager"}}`, uuid.New())
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/cover-letter", requestBody, profileId)
+			assert.Equal(t, http.StatusForbidden, recorder.Code)
+			assert.Equal(t, `{"data":null,"error":{"code":"forbidden","message":"profile_id does not match the authenticated profile"}}`, recorder.Body.String())
+		})
+	})
+
+	t.Run("JobApplicationCoverLetters", func(t *testing.T) {
+		profileId := uuid.New()
+		url := "https://acme.com/jobs/1"
+		details := "Lead the operations team"
+		jobApplication := &types.JobApplication{
+			ID:          uuid.New(),
+			ProfileID:   profileId,
+			CompanyName: "Acme",
+			JobRole:     "Manager",
+			URL:         &url,
+			JobDetails:  &details,
+		}
+		expectedJobPosting := &types.JobPosting{CompanyName: "Acme", JobRole: "Manager", URL: url, Details: details}
+
+		// setup returns the test router with the store mock returning the job application
+		setup := func(t *testing.T) (*gin.Engine, *mocks.MockStore, *mocks.MockOpenAI) {
+			router, mockStore, mockOpenAI := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetJobApplicationByID(gomock.Eq(jobApplication.ID)).Return(jobApplication, nil).AnyTimes()
+			return router, mockStore, mockOpenAI
+		}
+
+		t.Run("generate", func(t *testing.T) {
+			router, mockStore, mockOpenAI := setup(t)
+			coverLetterId := uuid.New()
+			mockOpenAI.EXPECT().
+				GenerateChatGPTCoverLetter(gomock.Any(), gomock.Eq(profileId), gomock.Eq(expectedJobPosting), gomock.Eq(&types.CoverLetterOptions{Tone: "formal"}), gomock.Any()).
+				Return(&types.GeneratedCoverLetter{Content: "perfect cover letter", Model: "gpt-3.5-turbo", PromptVersion: "cover_letter/v1"}, 200, nil).
+				Times(1)
+			mockStore.EXPECT().
+				StoreCoverLetter(gomock.Any()).
+				DoAndReturn(func(coverLetter *types.CoverLetter) (*types.CoverLetter, error) {
+					assert.Equal(t, &jobApplication.ID, coverLetter.JobApplicationID)
+					assert.Equal(t, *expectedJobPosting, coverLetter.JobPosting)
+					coverLetter.ID = coverLetterId
+					return coverLetter, nil
+				}).
+				Times(1)
+			mockStore.EXPECT().StoreUsageRecord(gomock.Any()).Return(nil).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/job-applications/"+jobApplication.ID.String()+"/cover-letter", `{"options":{"tone":"formal"}}`, profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			expectedResponse := fmt.Sprintf(`{"data":"perfect cover letter","meta":{"cover_letter_id":"%s","job_application_id":"%s","model":"gpt-3.5-turbo","prompt_version":"cover_letter/v1","usage":{"prompt_tokens":0,"completion_tokens":0,"total_tokens":0}}}`, coverLetterId, jobApplication.ID)
+			assert.Equal(t, expectedResponse, recorder.Body.String())
+		})
+
+		t.Run("generate without options", func(t *testing.T) {
+			router, mockStore, mockOpenAI := setup(t)
+			mockOpenAI.EXPECT().
+				GenerateChatGPTCoverLetter(gomock.Any(), gomock.Eq(profileId), gomock.Eq(expectedJobPosting), gomock.Eq(&types.CoverLetterOptions{}), gomock.Any()).
+				Return(&types.GeneratedCoverLetter{Content: "perfect cover letter"}, 200, nil).
+				Times(1)
+			mockStore.EXPECT().StoreCoverLetter(gomock.Any()).Return(&types.CoverLetter{ID: uuid.New()}, nil).Times(1)
+			mockStore.EXPECT().StoreUsageRecord(gomock.Any()).Return(nil).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/job-applications/"+jobApplication.ID.String()+"/cover-letter", "", profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+		})
+
+		t.Run("job application of another profile", func(t *testing.T) {
+			router, mockStore, _ := setup(t)
+			otherJobApplication := &types.JobApplication{ID: uuid.New(), ProfileID: uuid.New(), CompanyName: "Other", JobRole: "Manager"}
+			mockStore.EXPECT().GetJobApplicationByID(gomock.Eq(otherJobApplication.ID)).Return(otherJobApplication, nil).Times(2)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/job-applications/"+otherJobApplication.ID.String()+"/cover-letter", "", profileId)
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+			assert.Equal(t, `{"data":null,"error":{"code":"not_found","message":"job application not found"}}`, recorder.Body.String())
+
+			recorder = serveJSON(t, router, http.MethodGet, "/v1/job-applications/"+otherJobApplication.ID.String()+"/cover-letters", "", profileId)
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+		})
+
+		t.Run("list versions", func(t *testing.T) {
+			router, mockStore, _ := setup(t)
+			coverLetters := []types.CoverLetter{
+				{ID: uuid.New(), ProfileID: profileId, JobApplicationID: &jobApplication.ID, Content: "second version"},
+				{ID: uuid.New(), ProfileID: profileId, JobApplicationID: &jobApplication.ID, Content: "first version"},
+			}
+			mockStore.EXPECT().GetJobApplicationCoverLetters(gomock.Eq(profileId), gomock.Eq(jobApplication.ID)).Return(&coverLetters, nil).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodGet, "/v1/job-applications/"+jobApplication.ID.String()+"/cover-letters", "", profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			expectedData, err := json.Marshal(coverLetters)
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("{\"data\":%s}", string(expectedData)), recorder.Body.String())
+		})
+	})
+
+	t.Run("InterviewPrep", func(t *testing.T) {
+		profileId := uuid.New()
+		notes := "Panel with the COO"
+		jobApplication := &types.JobApplication{
+			ID:          uuid.New(),
+			ProfileID:   profileId,
+			CompanyName: "Acme",
+			JobRole:     "Manager",
+			Events: &[]types.JobApplicationEvent{
+				{Type: store.JobApplicationSubmission, Description: "Applied online", Date: "2023-10-01"},
+				{Type: store.JobApplicationInterview, Description: "Onsite interview", Date: "2023-10-10", AdditionalNotes: &notes},
+			},
+		}
+		careerProfile := &types.CareerProfile{ID: profileId, Headline: "Manager", ExperienceYears: 5}
+		interviewPrep := &types.InterviewPrep{
+			ID:               uuid.New(),
+			ProfileID:        profileId,
+			JobApplicationID: jobApplication.ID,
+			Questions: []types.InterviewQuestion{{
+				Question:        "Tell me about a time you led a team through a change.",
+				Category:        "behavioral",
+				SuggestedAnswer: types.StarAnswer{Situation: "A reorganization", Task: "Keep the team on track", Action: "Planned weekly check-ins", Result: "No missed deadlines"},
+			}},
+			QuestionsToAsk: []string{"How is success measured in this role?"},
+			Model:          "gpt-3.5-turbo",
+			PromptVersion:  "interview_prep/v1",
+			CreatedAt:      "2023-10-05 10:00:00",
+			UpdatedAt:      "2023-10-05 10:00:00",
+		}
+
+		// setup returns the test router with the store mock returning the job application
+		setup := func(t *testing.T) (*gin.Engine, *mocks.MockStore, *mocks.MockOpenAI) {
+			router, mockStore, mockOpenAI := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetJobApplicationByID(gomock.Eq(jobApplication.ID)).Return(jobApplication, nil).AnyTimes()
+			return router, mockStore, mockOpenAI
+		}
+
+		apiEndpoint := "/v1/job-applications/" + jobApplication.ID.String() + "/interview-prep"
+
+		t.Run("generate", func(t *testing.T) {
+			router, mockStore, mockOpenAI := setup(t)
+			mockStore.EXPECT().GetCareerProfileByID(gomock.Eq(profileId)).Return(careerProfile, nil).Times(1)
+			mockOpenAI.EXPECT().
+				PrepareChatGPTInterview(gomock.Any(), gomock.Eq(careerProfile), gomock.Eq(&types.JobPosting{CompanyName: "Acme", JobRole: "Manager"}), gomock.Any()).
+				DoAndReturn(func(_ *gin.Context, _ *types.CareerProfile, _ *types.JobPosting, interviews []types.JobApplicationEvent) (*types.GeneratedInterviewPrep, int, error) {
+					// Only the interview events are sent
+					assert.Equal(t, []types.JobApplicationEvent{(*jobApplication.Events)[1]}, interviews)
+					return &types.GeneratedInterviewPrep{
+						InterviewPrep: types.InterviewPrep{Questions: interviewPrep.Questions, QuestionsToAsk: interviewPrep.QuestionsToAsk},
+						Model:         "gpt-3.5-turbo",
+						PromptVersion: "interview_prep/v1",
+					}, http.StatusOK, nil
+				}).
+				Times(1)
+			mockStore.EXPECT().
+				StoreUsageRecord(gomock.Any()).
+				DoAndReturn(func(usageRecord *types.UsageRecord) error {
+					assert.Equal(t, "interview_prep", usageRecord.Operation)
+					return nil
+				}).
+				Times(1)
+			mockStore.EXPECT().
+				StoreInterviewPrep(gomock.Any()).
+				DoAndReturn(func(saved *types.InterviewPrep) (*types.InterviewPrep, error) {
+					assert.Equal(t, profileId, saved.ProfileID)
+					assert.Equal(t, jobApplication.ID, saved.JobApplicationID)
+					assert.Equal(t, interviewPrep.Questions, saved.Questions)
+					return interviewPrep, nil
+				}).
+				Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, apiEndpoint, "", profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			var response struct {
+				Data types.InterviewPrep    `json:"data"`
+				Meta map[string]interface{} `json:"meta"`
+			}
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, *interviewPrep, response.Data)
+			assert.Equal(t, "interview_prep/v1", response.Meta["prompt_version"])
+		})
+
+		t.Run("generate when the LLM provider fails", func(t *testing.T) {
+			router, mockStore, mockOpenAI := setup(t)
+			mockStore.EXPECT().GetCareerProfileByID(gomock.Eq(profileId)).Return(careerProfile, nil).Times(1)
+			mockOpenAI.EXPECT().
+				PrepareChatGPTInterview(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(nil, http.StatusBadGateway, &llm.UsageError{
+					Err:   errors.New("invalid interview prep from the LLM provider: no questions"),
+					Calls: []types.ProviderCall{{Model: "gpt-3.5-turbo", PromptVersion: "interview_prep/v1", Usage: types.TokenUsage{TotalTokens: 900}}},
+				}).
+				Times(1)
+			// The failed completion is paid for, so its usage is recorded
+			mockStore.EXPECT().StoreUsageRecord(gomock.Any()).DoAndReturn(func(usageRecord *types.UsageRecord) error {
+				assert.Equal(t, "interview_prep", usageRecord.Operation)
+				assert.Equal(t, 900, usageRecord.Usage.TotalTokens)
+				return nil
+			}).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, apiEndpoint, "", profileId)
+			assert.Equal(t, http.StatusBadGateway, recorder.Code)
+		})
+
+		t.Run("get", func(t *testing.T) {
+			router, mockStore, _ := setup(t)
+			mockStore.EXPECT().GetInterviewPrep(gomock.Eq(profileId), gomock.Eq(jobApplication.ID)).Return(interviewPrep, nil).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodGet, apiEndpoint, "", profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			expectedData, err := json.Marshal(interviewPrep)
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("{\"data\":%s}", string(expectedData)), recorder.Body.String())
+		})
+
+		t.Run("get before generating", func(t *testing.T) {
+			router, mockStore, _ := setup(t)
+			mockStore.EXPECT().GetInterviewPrep(gomock.Eq(profileId), gomock.Eq(jobApplication.ID)).Return(nil, errors.New("mongo: no documents in result")).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodGet, apiEndpoint, "", profileId)
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), "interview prep not found")
+		})
+
+		t.Run("job application of another profile", func(t *testing.T) {
+			router, mockStore, _ := setup(t)
+			otherJobApplication := &types.JobApplication{ID: uuid.New(), ProfileID: uuid.New(), CompanyName: "Other", JobRole: "Manager"}
+			mockStore.EXPECT().GetJobApplicationByID(gomock.Eq(otherJobApplication.ID)).Return(otherJobApplication, nil).Times(2)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/job-applications/"+otherJobApplication.ID.String()+"/interview-prep", "", profileId)
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+			recorder = serveJSON(t, router, http.MethodGet, "/v1/job-applications/"+otherJobApplication.ID.String()+"/interview-prep", "", profileId)
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+		})
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		profileId := uuid.New()
+		otherProfileId := uuid.New()
+		dailyUsage := []types.DailyUsage{
+			{ProfileID: profileId, Date: "2023-10-01", Calls: 2, Usage: types.TokenUsage{PromptTokens: 200, CompletionTokens: 100, TotalTokens: 300}, Cost: 0.00025},
+			{ProfileID: otherProfileId, Date: "2023-10-01", Calls: 1, Usage: types.TokenUsage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500}, Cost: 0.00125},
+			{ProfileID: profileId, Date: "2023-10-02", Calls: 1, Usage: types.TokenUsage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150}, Cost: 0.000125},
+		}
+
+		// setup returns the test router with the admin API key
+		setup := func(t *testing.T, adminAPIKey string) (*gin.Engine, *mocks.MockStore) {
+			handler, mockStore, _ := newTestHandler(t, profileId)
+			handler.AdminAPIKey = adminAPIKey
+			return handler.SetupRouter(), mockStore
+		}
+		// serve serves a GET request of the profile with the header
+		serve := func(t *testing.T, router *gin.Engine, apiEndpoint string, header string, value string) *httptest.ResponseRecorder {
+			req := newTestRequest(t, http.MethodGet, apiEndpoint, "", profileId)
+			req.Header.Set(header, value)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		t.Run("usage of the current user", func(t *testing.T) {
+			router, mockStore := setup(t, "")
+			mockStore.EXPECT().GetDailyUsage(gomock.Eq(&profileId), gomock.Eq("2023-10-01"), gomock.Eq("2023-10-31")).Return(&[]types.DailyUsage{dailyUsage[0], dailyUsage[2]}, nil).Times(1)
+
+			recorder := serve(t, router, "/v1/me/usage?from=2023-10-01&to=2023-10-31", "", "")
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			var response struct {
+				Data types.UsageReport `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, types.UsageSummary{Calls: 3, Usage: types.TokenUsage{PromptTokens: 300, CompletionTokens: 150, TotalTokens: 450}, Cost: 0.000375}, response.Data.Total)
+			assert.Len(t, response.Data.Days, 2)
+			assert.Nil(t, response.Data.Profiles)
+		})
+
+		t.Run("last 30 days by default", func(t *testing.T) {
+			router, mockStore := setup(t, "")
+			today := time.Now()
+			mockStore.EXPECT().
+				GetDailyUsage(gomock.Eq(&profileId), gomock.Eq(today.AddDate(0, 0, -29).Format("2006-01-02")), gomock.Eq(today.Format("2006-01-02"))).
+				Return(&[]types.DailyUsage{}, nil).
+				Times(1)
+
+			recorder := serve(t, router, "/v1/me/usage", "", "")
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), `"total":{"calls":0,"usage":{"prompt_tokens":0,"completion_tokens":0,"total_tokens":0},"cost":0},"days":[]`)
+		})
+
+		t.Run("invalid period", func(t *testing.T) {
+			router, _ := setup(t, "")
+
+			recorder := serve(t, router, "/v1/me/usage?from=October", "", "")
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), `"details":[{"field":"from","rule":"datetime","message":"must be a date formatted as 2006-01-02"}]`)
+
+			recorder = serve(t, router, "/v1/me/usage?from=2023-10-02&to=2023-10-01", "", "")
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+			recorder = serve(t, router, "/v1/me/usage?from=2022-01-01&to=2023-10-01", "", "")
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		})
+
+		t.Run("admin usage of every profile", func(t *testing.T) {
+			router, mockStore := setup(t, "admin_key")
+			mockStore.EXPECT().GetDailyUsage(gomock.Nil(), gomock.Eq("2023-10-01"), gomock.Eq("2023-10-02")).Return(&dailyUsage, nil).Times(1)
+
+			recorder := serve(t, router, "/v1/admin/usage?from=2023-10-01&to=2023-10-02", AdminKeyHeader, "admin_key")
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			var response struct {
+				Data types.UsageReport `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, 4, response.Data.Total.Calls)
+			assert.Equal(t, 0.001625, response.Data.Total.Cost)
+			assert.Equal(t, []types.ProfileUsage{
+				{ProfileID: otherProfileId, Calls: 1, Usage: types.TokenUsage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500}, Cost: 0.00125},
+				{ProfileID: profileId, Calls: 3, Usage: types.TokenUsage{PromptTokens: 300, CompletionTokens: 150, TotalTokens: 450}, Cost: 0.000375},
+			}, response.Data.Profiles)
+			assert.Len(t, response.Data.Days, 3)
+		})
+
+		t.Run("admin usage with an invalid key", func(t *testing.T) {
+			router, _ := setup(t, "admin_key")
+
+			recorder := serve(t, router, "/v1/admin/usage", AdminKeyHeader, "some_token")
+			assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		})
+
+		t.Run("admin usage disabled", func(t *testing.T) {
+			router, _ := setup(t, "")
+
+			recorder := serve(t, router, "/v1/admin/usage", AdminKeyHeader, "")
+			assert.Equal(t, http.StatusForbidden, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), "the admin API is disabled")
+		})
+	})
+
+	t.Run("JobPostings", func(t *testing.T) {
+		profileId := uuid.New()
+		postingText := "Backend Engineer at Acme\nLocation: Remote\n\nRequirements:\n- Go\n- SQL\n\nNice to have:\n- Kubernetes"
+
+		type parseResponse struct {
+			Data types.ParsedJobPosting `json:"data"`
+			Meta map[string]interface{} `json:"meta"`
+		}
+
+		t.Run("parse with the LLM provider", func(t *testing.T) {
+			router, mockStore, mockOpenAI := newTestRouter(t, profileId)
+			mockOpenAI.EXPECT().
+				ParseChatGPTJobPosting(gomock.Any(), gomock.Eq(postingText)).
+				Return(&types.GeneratedJobPosting{
+					JobPosting:    types.ParsedJobPosting{CompanyName: "Acme Inc.", JobRole: "Backend Engineer", Seniority: "Mid-level", RequiredSkills: []string{"Go", "SQL"}},
+					Model:         "gpt-3.5-turbo",
+					PromptVersion: "job_posting/v1",
+				}, http.StatusOK, nil).
+				Times(1)
+			mockStore.EXPECT().
+				StoreUsageRecord(gomock.Any()).
+				DoAndReturn(func(usageRecord *types.UsageRecord) error {
+					assert.Equal(t, "job_posting_parse", usageRecord.Operation)
+					return nil
+				}).
+				Times(1)
+
+			body, _ := json.Marshal(types.JobPostingParseRequest{Text: postingText})
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/job-postings/parse", string(body), profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			var response parseResponse
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, "llm", response.Meta["source"])
+			assert.Equal(t, "job_posting/v1", response.Meta["prompt_version"])
+			assert.Equal(t, "Acme Inc.", response.Data.CompanyName)
+			assert.Equal(t, "mid", response.Data.Seniority)
+			// The fields the LLM provider left empty are filled by the fallback extractor
+			assert.Equal(t, "Remote", response.Data.Location)
+			assert.Equal(t, []string{"Kubernetes"}, response.Data.NiceToHaveSkills)
+			assert.Equal(t, types.JobPosting{CompanyName: "Acme Inc.", JobRole: "Backend Engineer", Details: postingText, Skills: "Go, SQL, Kubernetes"}, response.Data.JobPosting)
+		})
+
+		t.Run("fallback when the LLM provider fails", func(t *testing.T) {
+			router, _, mockOpenAI := newTestRouter(t, profileId)
+			mockOpenAI.EXPECT().
+				ParseChatGPTJobPosting(gomock.Any(), gomock.Eq("Backend Engineer at Acme\nRequirements\n- Go\n- SQL")).
+				Return(nil, http.StatusServiceUnavailable, errors.New("the LLM provider is unavailable")).
+				Times(1)
+
+			body, _ := json.Marshal(types.JobPostingParseRequest{Text: "<h1>Backend Engineer at Acme</h1><script>track()</script><h2>Requirements</h2><ul><li>Go</li><li>SQL</li></ul>"})
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/job-postings/parse", string(body), profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			var response parseResponse
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, map[string]interface{}{"source": "fallback"}, response.Meta)
+			assert.Equal(t, "Acme", response.Data.CompanyName)
+			assert.Equal(t, "Backend Engineer", response.Data.JobRole)
+			assert.Equal(t, []string{"Go", "SQL"}, response.Data.RequiredSkills)
+			assert.Equal(t, []string{}, response.Data.Responsibilities)
+		})
+
+		t.Run("fallback when the LLM completion cannot be parsed", func(t *testing.T) {
+			router, mockStore, mockOpenAI := newTestRouter(t, profileId)
+			mockOpenAI.EXPECT().
+				ParseChatGPTJobPosting(gomock.Any(), gomock.Any()).
+				Return(nil, http.StatusBadGateway, &llm.UsageError{
+					Err:   errors.New("invalid job posting from the LLM provider: no JSON object"),
+					Calls: []types.ProviderCall{{Model: "gpt-3.5-turbo", PromptVersion: "job_posting/v1", Usage: types.TokenUsage{TotalTokens: 300}}},
+				}).
+				Times(1)
+			// The failed completion is paid for, so its usage is recorded with the fallback
+			mockStore.EXPECT().StoreUsageRecord(gomock.Any()).DoAndReturn(func(usageRecord *types.UsageRecord) error {
+				assert.Equal(t, "job_posting_parse", usageRecord.Operation)
+				assert.Equal(t, 300, usageRecord.Usage.TotalTokens)
+				return nil
+			}).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/job-postings/parse", `{"text":"Backend Engineer at Acme"}`, profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), `"source":"fallback"`)
+		})
+
+		t.Run("invalid request", func(t *testing.T) {
+			router, _, _ := newTestRouter(t, profileId)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/job-postings/parse", `{}`, profileId)
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+			recorder = serveJSON(t, router, http.MethodPost, "/v1/job-postings/parse", `{"text":"<div><script>track()</script></div>"}`, profileId)
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), `"field":"text","rule":"required"`)
+		})
+
+		t.Run("import from a URL", func(t *testing.T) {
+			pages := httptest.NewServer(http.FileServer(http.Dir("../jobposting/testdata")))
+			defer pages.Close()
+			router, _, _ := newTestRouter(t, profileId)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/job-postings/import", fmt.Sprintf(`{"url":"%s/json_ld.html"}`, pages.URL), profileId)
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), `"field":"url","rule":"public_url"`)
+
+			// The fetcher of the tests allows the loopback address of the fixtures server
+			handler, _, _ := newTestHandler(t, profileId)
+			handler.JobPostingFetcher = jobposting.NewFetcher(jobposting.FetchConfig{Timeout: time.Second, MaxBytes: 1 << 20, MaxRedirects: 2, AllowPrivateNetworks: true})
+			router = handler.SetupRouter()
+
+			recorder = serveJSON(t, router, http.MethodPost, "/v1/job-postings/import", fmt.Sprintf(`{"url":"%s/json_ld.html"}`, pages.URL), profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			var response struct {
+				Data types.JobPosting       `json:"data"`
+				Meta map[string]interface{} `json:"meta"`
+			}
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, "json_ld", response.Meta["source"])
+			assert.Equal(t, "Acme", response.Data.CompanyName)
+			assert.Equal(t, "Senior Backend Engineer", response.Data.JobRole)
+			assert.Equal(t, "Go, PostgreSQL, Kubernetes", response.Data.Skills)
+			assert.Equal(t, pages.URL+"/json_ld.html", response.Data.URL)
+
+			recorder = serveJSON(t, router, http.MethodPost, "/v1/job-postings/import", fmt.Sprintf(`{"url":"%s/missing.html"}`, pages.URL), profileId)
+			assert.Equal(t, http.StatusBadGateway, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), "the job posting URL returned status 404")
+
+			recorder = serveJSON(t, router, http.MethodPost, "/v1/job-postings/import", `{"url":"ftp://example.com/job.html"}`, profileId)
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), `"field":"url","rule":"url"`)
+
+			recorder = serveJSON(t, router, http.MethodPost, "/v1/job-postings/import", `{"url":"not a url"}`, profileId)
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		})
+	})
+
+	t.Run("SkillsGap", func(t *testing.T) {
+		profileId := uuid.New()
+		careerProfile := &types.CareerProfile{ID: profileId, Headline: "Backend Engineer", ExperienceYears: 5, Skills: &[]string{"Golang", "Postgres", "Docker"}}
+		body := `{"job_posting":{"company_name":"Acme","job_role":"Backend Engineer","skills":"Go, PostgreSQL, Kubernetes, Terraform"}}`
+
+		type skillsGapResponse struct {
+			Data types.SkillsGapAnalysis `json:"data"`
+			Meta map[string]interface{}  `json:"meta"`
+		}
+
+		t.Run("keyword match combined with the LLM provider analysis", func(t *testing.T) {
+			router, mockStore, mockOpenAI := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetCareerProfileByID(gomock.Eq(profileId)).Return(careerProfile, nil).Times(1)
+			mockOpenAI.EXPECT().
+				AnalyzeChatGPTSkillsGap(gomock.Any(), gomock.Eq(careerProfile), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ *gin.Context, _ *types.CareerProfile, _ *types.JobPosting, keywordMatch *types.SkillsGapAnalysis) (*types.GeneratedSkillsGap, int, error) {
+					assert.Equal(t, []string{"Go", "PostgreSQL"}, keywordMatch.MatchedSkills)
+					return &types.GeneratedSkillsGap{
+						Analysis: types.SkillsGapAnalysis{
+							Score:              70,
+							MissingSkills:      []string{"Terraform"},
+							TransferableSkills: []types.TransferableSkill{{Skill: "Kubernetes", ProfileSkills: []string{"Docker"}, Explanation: "Containers experience."}},
+							Explanations:       []string{"A strong backend profile."},
+						},
+						Model:         "gpt-3.5-turbo",
+						PromptVersion: "skills_gap/v1",
+					}, http.StatusOK, nil
+				}).
+				Times(1)
+			mockStore.EXPECT().
+				StoreUsageRecord(gomock.Any()).
+				DoAndReturn(func(usageRecord *types.UsageRecord) error {
+					assert.Equal(t, "skills_gap", usageRecord.Operation)
+					return nil
+				}).
+				Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/analysis/skills-gap", body, profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			var response skillsGapResponse
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, "llm", response.Meta["source"])
+			assert.Equal(t, 60, response.Data.Score)
+			assert.Equal(t, 50, response.Data.KeywordScore)
+			assert.Equal(t, 70, *response.Data.LLMScore)
+			assert.Equal(t, []string{"Go", "PostgreSQL"}, response.Data.MatchedSkills)
+			assert.Equal(t, []string{"Terraform"}, response.Data.MissingSkills)
+			assert.Equal(t, "Kubernetes", response.Data.TransferableSkills[0].Skill)
+			assert.Len(t, response.Data.Explanations, 2)
+		})
+
+		t.Run("keyword match when the LLM provider fails", func(t *testing.T) {
+			router, mockStore, mockOpenAI := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetCareerProfileByID(gomock.Eq(profileId)).Return(careerProfile, nil).Times(1)
+			mockOpenAI.EXPECT().
+				AnalyzeChatGPTSkillsGap(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(nil, http.StatusServiceUnavailable, errors.New("the LLM provider is unavailable")).
+				Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/analysis/skills-gap", body, profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			var response skillsGapResponse
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, map[string]interface{}{"source": "keywords"}, response.Meta)
+			assert.Equal(t, 50, response.Data.Score)
+			assert.Nil(t, response.Data.LLMScore)
+			assert.Equal(t, []string{"Kubernetes", "Terraform"}, response.Data.MissingSkills)
+		})
+
+		t.Run("keyword match when the LLM analysis cannot be parsed", func(t *testing.T) {
+			router, mockStore, mockOpenAI := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetCareerProfileByID(gomock.Eq(profileId)).Return(careerProfile, nil).Times(1)
+			mockOpenAI.EXPECT().
+				AnalyzeChatGPTSkillsGap(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(nil, http.StatusBadGateway, &llm.UsageError{
+					Err:   errors.New("invalid skills gap analysis from the LLM provider: no JSON object"),
+					Calls: []types.ProviderCall{{Model: "gpt-3.5-turbo", PromptVersion: "skills_gap/v1", Usage: types.TokenUsage{TotalTokens: 400}}},
+				}).
+				Times(1)
+			// The failed completion is paid for, so its usage is recorded with the keyword match
+			mockStore.EXPECT().StoreUsageRecord(gomock.Any()).DoAndReturn(func(usageRecord *types.UsageRecord) error {
+				assert.Equal(t, "skills_gap", usageRecord.Operation)
+				assert.Equal(t, 400, usageRecord.Usage.TotalTokens)
+				return nil
+			}).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/analysis/skills-gap", body, profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), `"source":"keywords"`)
+		})
+
+		t.Run("without a career profile", func(t *testing.T) {
+			router, mockStore, _ := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetCareerProfileByID(gomock.Eq(profileId)).Return(nil, errors.New("mongo: no documents in result")).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/analysis/skills-gap", body, profileId)
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), "career profile not found")
+		})
+
+		t.Run("invalid request", func(t *testing.T) {
+			router, _, _ := newTestRouter(t, profileId)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/analysis/skills-gap", `{"job_posting":{"company_name":"Acme"}}`, profileId)
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), `"field":"job_posting.job_role"`)
+		})
+	})
+
+	t.Run("Resumes", func(t *testing.T) {
+		profileId := uuid.New()
+		careerProfile := &types.CareerProfile{
+			ID:              profileId,
+			FirstName:       "John",
+			LastName:        "Doe",
+			Headline:        "Manager",
+			ExperienceYears: 5,
+			Skills:          &[]string{"Planning"},
+			ContactInfo:     &types.ContactInfo{Email: "john@email.com"},
+			WorkHistory:     &[]types.WorkExperience{{Company: "Initech", Title: "Manager", StartDate: "2020", Highlights: []string{"Led a team"}}},
+		}
+		resume := &types.Resume{
+			ID:            uuid.New(),
+			ProfileID:     profileId,
+			JobPosting:    types.JobPosting{CompanyName: "Acme", JobRole: "Manager"},
+			Summary:       "Manager leading teams.",
+			Skills:        []string{"Planning"},
+			Experience:    []types.ResumeExperience{{Company: "Initech", Title: "Manager", StartDate: "2020", Bullets: []string{"Led a team of 5"}}},
+			Model:         "gpt-3.5-turbo",
+			PromptVersion: "resume/v1",
+			CreatedAt:     "2023-10-01 10:00:00",
+			UpdatedAt:     "2023-10-01 10:00:00",
+		}
+
+		body := `{"job_posting":{"company_name":"Acme","job_role":"Manager","skills":"Planning"}}`
+
+		t.Run("tailor", func(t *testing.T) {
+			router, mockStore, mockOpenAI := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetCareerProfileByID(gomock.Eq(profileId)).Return(careerProfile, nil).Times(1)
+			mockOpenAI.EXPECT().
+				TailorChatGPTResume(gomock.Any(), gomock.Eq(careerProfile), gomock.Eq(&types.JobPosting{CompanyName: "Acme", JobRole: "Manager", Skills: "Planning"})).
+				Return(&types.GeneratedResume{
+					Resume:        types.Resume{Summary: resume.Summary, Skills: resume.Skills, Experience: resume.Experience},
+					Model:         "gpt-3.5-turbo",
+					PromptVersion: "resume/v1",
+					Usage:         types.TokenUsage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150},
+				}, http.StatusOK, nil).
+				Times(1)
+			mockStore.EXPECT().
+				StoreUsageRecord(gomock.Any()).
+				DoAndReturn(func(usageRecord *types.UsageRecord) error {
+					assert.Equal(t, "resume_tailor", usageRecord.Operation)
+					return nil
+				}).
+				Times(1)
+			mockStore.EXPECT().
+				StoreResume(gomock.Any()).
+				DoAndReturn(func(saved *types.Resume) (*types.Resume, error) {
+					assert.Equal(t, profileId, saved.ProfileID)
+					assert.Equal(t, "Acme", saved.JobPosting.CompanyName)
+					assert.Equal(t, "resume/v1", saved.PromptVersion)
+					assert.Equal(t, 150, saved.Usage.TotalTokens)
+					return resume, nil
+				}).
+				Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/resume/tailor", body, profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			var response struct {
+				Data types.Resume           `json:"data"`
+				Meta map[string]interface{} `json:"meta"`
+			}
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, *resume, response.Data)
+			assert.Equal(t, "resume/v1", response.Meta["prompt_version"])
+		})
+
+		t.Run("tailor when the LLM provider fails", func(t *testing.T) {
+			router, mockStore, mockOpenAI := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetCareerProfileByID(gomock.Eq(profileId)).Return(careerProfile, nil).Times(1)
+			mockOpenAI.EXPECT().
+				TailorChatGPTResume(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(nil, http.StatusBadGateway, &llm.UsageError{
+					Err:   errors.New("invalid resume from the LLM provider: no summary"),
+					Calls: []types.ProviderCall{{Model: "gpt-3.5-turbo", PromptVersion: "resume/v1", Usage: types.TokenUsage{TotalTokens: 900}}},
+				}).
+				Times(1)
+			// The failed completion is paid for, so its usage is recorded
+			mockStore.EXPECT().StoreUsageRecord(gomock.Any()).DoAndReturn(func(usageRecord *types.UsageRecord) error {
+				assert.Equal(t, "resume_tailor", usageRecord.Operation)
+				assert.Equal(t, 900, usageRecord.Usage.TotalTokens)
+				return nil
+			}).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/resume/tailor", body, profileId)
+			assert.Equal(t, http.StatusBadGateway, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), "invalid resume from the LLM provider")
+		})
+
+		t.Run("tailor without a career profile", func(t *testing.T) {
+			router, mockStore, _ := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetCareerProfileByID(gomock.Eq(profileId)).Return(nil, errors.New("mongo: no documents in result")).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodPost, "/v1/resume/tailor", body, profileId)
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), "career profile not found")
+		})
+
+		t.Run("list", func(t *testing.T) {
+			router, mockStore, _ := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetResumes(gomock.Eq(profileId)).Return(&[]types.Resume{*resume}, nil).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodGet, "/v1/resumes", "", profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			expectedData, err := json.Marshal([]types.Resume{*resume})
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("{\"data\":%s}", string(expectedData)), recorder.Body.String())
+		})
+
+		t.Run("get a resume of another profile", func(t *testing.T) {
+			router, mockStore, _ := newTestRouter(t, profileId)
+			otherResumeId := uuid.New()
+			mockStore.EXPECT().GetResumeByID(gomock.Eq(profileId), gomock.Eq(otherResumeId)).Return(nil, errors.New("mongo: no documents in result")).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodGet, "/v1/resumes/"+otherResumeId.String(), "", profileId)
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), "resume not found")
+		})
+
+		t.Run("export", func(t *testing.T) {
+			router, mockStore, _ := newTestRouter(t, profileId)
+			mockStore.EXPECT().GetResumeByID(gomock.Eq(profileId), gomock.Eq(resume.ID)).Return(resume, nil).Times(1)
+			mockStore.EXPECT().GetCareerProfileByID(gomock.Eq(profileId)).Return(careerProfile, nil).Times(1)
+
+			recorder := serveJSON(t, router, http.MethodGet, "/v1/resumes/"+resume.ID.String()+"/export?format=txt", "", profileId)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+			assert.Equal(t, `attachment; filename="resume-acme-manager.txt"`, recorder.Header().Get("Content-Disposition"))
+			assert.Equal(t, "John Doe\nManager\njohn@email.com\n\nSUMMARY\nManager leading teams.\n\nSKILLS\nPlanning\n\nEXPERIENCE\nManager, Initech\n2020 - Present\n- Led a team of 5\n", recorder.Body.String())
+
+			recorder = serveJSON(t, router, http.MethodGet, "/v1/resumes/"+resume.ID.String()+"/export?format=odt", "", profileId)
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		})
+	})
+
+	t.Run("DeprecatedRoutes", func(t *testing.T) {
+		profileId := uuid.New()
+		accessToken := "some_token"
+
+		// Setup mocks and expectations
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockStore := mocks.NewMockStore(ctrl)
+		mockOpenAI := mocks.NewMockOpenAI(ctrl)
+		mockStore.
+			EXPECT().
+			ValidateAccessToken(gomock.Eq(profileId), gomock.Eq(accessToken), gomock.Any()).
+			Return(true, nil).
+			Times(2)
+
+		router := NewHandler(mockStore, mockOpenAI).SetupRouter()
+		for path, deprecated := range map[string]bool{"/v1/": false, "/": true} {
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("UserID", profileId.String())
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+			assert.NoError(t, err)
+
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			if deprecated {
+				assert.Equal(t, "true", recorder.Header().Get("Deprecation"))
+				assert.Equal(t, `</v1/>; rel="successor-version"`, recorder.Header().Get("Link"))
+			} else {
+				assert.Empty(t, recorder.Header().Get("Deprecation"))
+			}
+		}
+	})
+
+	t.Run("CORS", func(t *testing.T) {
+		handler := NewHandler(nil, nil)
+		handler.CORS = CORSConfig{
+			AllowedOrigins: []string{"http://localhost:3000"},
+			AllowedHeaders: []string{"Authorization", "UserID"},
+			MaxAge:         time.Minute,
+		}
+		router := handler.SetupRouter()
+
+		t.Run("preflight from an allowed origin", func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodOptions, "/v1/job-applications/some-id", nil)
+			assert.NoError(t, err)
+			req.Header.Set("Origin", "http://localhost:3000")
+			req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusNoContent, recorder.Code)
+			assert.Equal(t, "http://localhost:3000", recorder.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "true", recorder.Header().Get("Access-Control-Allow-Credentials"))
+			assert.Equal(t, "GET, DELETE, OPTIONS", recorder.Header().Get("Access-Control-Allow-Methods"))
+			assert.Equal(t, "Authorization, UserID", recorder.Header().Get("Access-Control-Allow-Headers"))
+			assert.Equal(t, "60", recorder.Header().Get("Access-Control-Max-Age"))
+		})
+
+		t.Run("preflight from a disallowed origin", func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodOptions, "/v1/job-applications", nil)
+			assert.NoError(t, err)
+			req.Header.Set("Origin", "http://evil.com")
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusForbidden, recorder.Code)
+			assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+		})
+	})
+
+	// TODO: Write tests for auth endpoint
+	// TODO: Write tests for job applications
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	t.Run("covers every registered route", func(t *testing.T) {
+		handler := NewHandler(nil, nil)
+		router := handler.SetupRouter()
+
+		paths, ok := OpenAPISpec()["paths"].(map[string]interface{})
+		assert.True(t, ok)
+		for _, route := range router.Routes() {
+			pathItem, ok := paths[openAPIPath(route.Path)].(map[string]interface{})
+			if !assert.Truef(t, ok, "route %s is missing from the OpenAPI spec", route.Path) {
+				continue
+			}
+			_, ok = pathItem[strings.ToLower(route.Method)]
+			assert.Truef(t, ok, "route %s %s is missing from the OpenAPI spec", route.Method, route.Path)
+		}
+	})
+
+	t.Run("served without authorization", func(t *testing.T) {
+		handler := NewHandler(nil, nil)
+		router := handler.SetupRouter()
+		recorder := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+		assert.NoError(t, err)
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var spec map[string]interface{}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &spec))
+		assert.Equal(t, "3.0.3", spec["openapi"])
+	})
+}
+
+func TestCoverLetterIntegration(t *testing.T) {
+	profileId := uuid.New()
+	coverLetterId := uuid.New()
+	accessToken := "some_token"
+	careerProfile := &types.CareerProfile{
+		ID:              profileId,
+		FirstName:       "John",
+		LastName:        "Doe",
+		Headline:        "Manager",
+		ExperienceYears: 5,
+		ContactInfo:     &types.ContactInfo{Email: "john@email.com"},
+	}
+	requestData := types.CoverLetterRequest{
+		ProfileID: profileId,
+		JobPosting: types.JobPosting{
+			CompanyName: "Acme",
+			JobRole:     "Manager",
+			Details:     "Great worker",
+			Skills:      "Management",
+		},
+	}
+
+	// setup returns the API router generating cover letters with the fake OpenAI server
+	// usageRecords and savedCoverLetters are the usage records and cover letters saved since the last setup
+	var usageRecords []types.UsageRecord
+	var savedCoverLetters []types.CoverLetter
+	setup := func(t *testing.T) (*gin.Engine, *openaitest.Server) {
+		util.SetupTestEnvironment(t)
+		ctrl := gomock.NewController(t)
+		mockStore := mocks.NewMockStore(ctrl)
+		mockStore.EXPECT().ValidateAccessToken(gomock.Eq(profileId), gomock.Eq(accessToken), gomock.Any()).Return(true, nil).AnyTimes()
+		mockStore.EXPECT().GetCareerProfileByID(gomock.Eq(profileId)).Return(careerProfile, nil).AnyTimes()
+		savedCoverLetters = nil
+		mockStore.EXPECT().StoreCoverLetter(gomock.Any()).DoAndReturn(func(coverLetter *types.CoverLetter) (*types.CoverLetter, error) {
+			coverLetter.ID = coverLetterId
+			savedCoverLetters = append(savedCoverLetters, *coverLetter)
+			return coverLetter, nil
+		}).AnyTimes()
+		usageRecords = nil
+		mockStore.EXPECT().StoreUsageRecord(gomock.Any()).DoAndReturn(func(usageRecord *types.UsageRecord) error {
+			usageRecords = append(usageRecords, *usageRecord)
+			return nil
+		}).AnyTimes()
+		server := openaitest.NewServer(t)
+		handler := NewHandler(mockStore, openai.NewOpenAIClientWithProvider(server.Provider()))
+		return handler.SetupRouter(), server
+	}
+	serve := func(t *testing.T, router *gin.Engine, apiEndpoint string, payload interface{}) *httptest.ResponseRecorder {
+		requestBody, err := json.Marshal(payload)
+		assert.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, apiEndpoint, bytes.NewBuffer(requestBody))
+		assert.NoError(t, err)
+		req.Header.Set("UserID", profileId.String())
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	t.Run("generates a cover letter", func(t *testing.T) {
+		router, server := setup(t)
+		server.Enqueue(openaitest.Response{
+			Content: `{"salutation": "Dear Hiring Manager,", "paragraphs": ["I would love to manage at Acme, as [Referrer] suggested."], "closing": "Best regards,"}`,
+			Usage:   &types.TokenUsage{PromptTokens: 120, CompletionTokens: 18, TotalTokens: 138},
+		})
+
+		recorder := serve(t, router, "/v1/cover-letter", requestData)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response struct {
+			Data string                 `json:"data"`
+			Meta map[string]interface{} `json:"meta"`
+		}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.True(t, strings.HasPrefix(response.Data, "John Doe\njohn@email.com\n\n"))
+		assert.True(t, strings.HasSuffix(response.Data, "Dear Hiring Manager,\n\nI would love to manage at Acme, as [Referrer] suggested.\n\nBest regards,\nJohn Doe"))
+		// The placeholders left by the model are reported to be filled by the user
+		assert.Equal(t, map[string]interface{}{
+			"model":           "gpt-3.5-turbo",
+			"prompt_version":  "cover_letter/v2",
+			"usage":           map[string]interface{}{"prompt_tokens": float64(120), "completion_tokens": float64(18), "total_tokens": float64(138)},
+			"cover_letter_id": coverLetterId.String(),
+			"placeholders":    []interface{}{"[Referrer]"},
+		}, response.Meta)
+		assert.Contains(t, server.Requests()[0].Messages[1].Content, "Company:Acme\nJob Role:Manager")
+	})
+
+	t.Run("streams a cover letter", func(t *testing.T) {
+		router, server := setup(t)
+		server.Enqueue(openaitest.Response{Chunks: []string{`{"salutation": "Dear `, `Hiring Manager,", "paragraphs": ["Hi"]}`}, Usage: &types.TokenUsage{PromptTokens: 120, CompletionTokens: 4, TotalTokens: 124}})
+
+		recorder := serve(t, router, "/v1/cover-letter/stream", requestData)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+		body := recorder.Body.String()
+		assert.True(t, strings.HasPrefix(body, "event:token\ndata:{\"content\":\"Dear \"}\n\nevent:token\ndata:{\"content\":\"Hiring Manager,\\n\\nHi\"}\n\nevent:done\n"))
+		assert.Contains(t, body, fmt.Sprintf("Dear Hiring Manager,\\n\\nHi\\n\\nSincerely,\\nJohn Doe\",\"meta\":{\"cover_letter_id\":\"%s\",\"model\":\"gpt-3.5-turbo\",\"prompt_version\":\"cover_letter/v2\",\"usage\":{\"prompt_tokens\":120,\"completion_tokens\":4,\"total_tokens\":124}}}\n\n", coverLetterId))
+	})
+
+	t.Run("records the usage of each call", func(t *testing.T) {
+		router, server := setup(t)
+		cutOff := &types.TokenUsage{PromptTokens: 100, CompletionTokens: 512, TotalTokens: 612}
+		server.Enqueue(
+			openaitest.Response{Content: `{"paragraphs": ["I am`, FinishReason: "length", Usage: cutOff},
+			openaitest.Response{Content: ` a great fit."]}`, Usage: &types.TokenUsage{PromptTokens: 620, CompletionTokens: 10, TotalTokens: 630}},
+		)
+
+		recorder := serve(t, router, "/v1/cover-letter", requestData)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		if assert.Len(t, usageRecords, 2) {
+			assert.Equal(t, 612, usageRecords[0].Usage.TotalTokens)
+			assert.Equal(t, 630, usageRecords[1].Usage.TotalTokens)
+		}
+
+		// The calls of a failed generation are recorded too
+		router, server = setup(t)
+		server.Enqueue(
+			openaitest.Response{Content: "Dear", FinishReason: "length", Usage: cutOff},
+			openaitest.Response{Content: " Hiring", FinishReason: "length", Usage: cutOff},
+		)
+		recorder = serve(t, router, "/v1/cover-letter", requestData)
+		assert.Equal(t, http.StatusBadGateway, recorder.Code)
+		if assert.Len(t, usageRecords, 2) {
+			assert.Equal(t, "cover_letter", usageRecords[1].Operation)
+			assert.Equal(t, "cover_letter/v2", usageRecords[1].PromptVersion)
+		}
+	})
+
+	t.Run("provider rate limited", func(t *testing.T) {
+		router, server := setup(t)
+		server.Enqueue(openaitest.RateLimited(20 * time.Second))
+
+		recorder := serve(t, router, "/v1/cover-letter", requestData)
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.Equal(t, "20", recorder.Header().Get("Retry-After"))
+		assert.Contains(t, recorder.Body.String(), `"code":"service_unavailable"`)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		t.Setenv("LLM_ALLOWED_MODELS", "gpt-3.5-turbo,gpt-4")
+		router, server := setup(t)
+
+		optionsRequest := requestData
+		optionsRequest.Options = types.CoverLetterOptions{Tone: "concise", WordCount: 200, Creativity: "low", Model: "gpt-4"}
+		recorder := serve(t, router, "/v1/cover-letter", optionsRequest)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		request := server.Requests()[0]
+		assert.Equal(t, "gpt-4", request.Model)
+		assert.Equal(t, float32(0.2), request.Temperature)
+		assert.Contains(t, request.Messages[0].Content, "Limit: 3 paragraphs, 200 words. Be concise and direct")
+
+		optionsRequest.Options = types.CoverLetterOptions{Tone: "casual", WordCount: 5000, Language: "not a language!"}
+		recorder = serve(t, router, "/v1/cover-letter", optionsRequest)
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		expectedDetails := `"details":[{"field":"options.tone","rule":"oneof","message":"must be one of: formal enthusiastic concise"},{"field":"options.word_count","rule":"max","message":"must be at most 1000"},{"field":"options.language","rule":"bcp47_language_tag","message":"must be a valid BCP 47 language tag"}]`
+		assert.Contains(t, recorder.Body.String(), expectedDetails)
+
+		optionsRequest.Options = types.CoverLetterOptions{Model: "gpt-4-32k"}
+		recorder = serve(t, router, "/v1/cover-letter", optionsRequest)
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"details":[{"field":"options.model","rule":"oneof","message":"must be one of: gpt-3.5-turbo gpt-4"}]`)
+		assert.Len(t, server.Requests(), 1)
+	})
+
+	t.Run("ranked variants", func(t *testing.T) {
+		router, server := setup(t)
+		usage := &types.TokenUsage{PromptTokens: 100, CompletionTokens: 10, TotalTokens: 110}
+		server.Enqueue(
+			openaitest.Response{Content: `{"paragraphs": ["First draft."]}`, Usage: usage},
+			openaitest.Response{Content: `{"paragraphs": ["Second draft."]}`, Usage: usage},
+			openaitest.Response{Content: `[{"variant": 1, "score": 70, "reason": "Generic"}, {"variant": 2, "score": 90, "reason": "Specific"}]`, Usage: &types.TokenUsage{PromptTokens: 300, CompletionTokens: 40, TotalTokens: 340}},
+		)
+
+		variantsRequest := requestData
+		variantsRequest.Variants = 2
+		variantsRequest.Rank = true
+		recorder := serve(t, router, "/v1/cover-letter", variantsRequest)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response struct {
+			Data []types.CoverLetterVariant `json:"data"`
+			Meta map[string]interface{}     `json:"meta"`
+		}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Len(t, response.Data, 2)
+		for i, expected := range []struct {
+			score  int
+			reason string
+		}{{90, "Specific"}, {70, "Generic"}} {
+			assert.Equal(t, i+1, response.Data[i].Rank)
+			assert.Equal(t, expected.score, *response.Data[i].Score)
+			assert.Equal(t, expected.reason, response.Data[i].Reason)
+			assert.Equal(t, &coverLetterId, response.Data[i].CoverLetterID)
+			assert.Equal(t, 110, response.Data[i].Usage.TotalTokens)
+		}
+		assert.Equal(t, map[string]interface{}{"prompt_tokens": float64(500), "completion_tokens": float64(60), "total_tokens": float64(560)}, response.Meta["usage"])
+		assert.Equal(t, "cover_letter_ranking/v1", response.Meta["ranking_prompt_version"])
+		assert.Len(t, server.Requests(), 3)
+		if assert.Len(t, usageRecords, 3) {
+			assert.Equal(t, "cover_letter_variant", usageRecords[0].Operation)
+			assert.Equal(t, "cover_letter_ranking", usageRecords[2].Operation)
+			assert.Equal(t, 340, usageRecords[2].Usage.TotalTokens)
+			assert.Equal(t, 0.00021, usageRecords[2].Cost)
+		}
+
+		recorder = serve(t, router, "/v1/cover-letter/stream", variantsRequest)
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"details":[{"field":"n","rule":"max","message":"must be at most 1 when streaming"}]`)
+	})
+
+	t.Run("unranked variants", func(t *testing.T) {
+		router, server := setup(t)
+		server.Enqueue(
+			openaitest.CoverLetterCompletion("First draft."),
+			openaitest.CoverLetterCompletion("Second draft."),
+			openaitest.Completion(`[{"variant": 1, "score": 70}]`),
+		)
+
+		variantsRequest := requestData
+		variantsRequest.Variants = 2
+		variantsRequest.Rank = true
+		recorder := serve(t, router, "/v1/cover-letter", variantsRequest)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response struct {
+			Data []types.CoverLetterVariant `json:"data"`
+			Meta map[string]interface{}     `json:"meta"`
+		}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		// The saved variants are returned unranked when the ranking fails
+		assert.Len(t, response.Data, 2)
+		for _, variant := range response.Data {
+			assert.Equal(t, 0, variant.Rank)
+			assert.Nil(t, variant.Score)
+			assert.Equal(t, &coverLetterId, variant.CoverLetterID)
+		}
+		assert.Equal(t, "invalid cover letter ranking from the LLM provider: 1 of 2 variants ranked", response.Meta["ranking_error"])
+		// The failed ranking is paid for, so its usage is recorded with the variants
+		if assert.Len(t, usageRecords, 3) {
+			assert.Equal(t, "cover_letter_variant", usageRecords[1].Operation)
+			assert.Equal(t, "cover_letter_ranking", usageRecords[2].Operation)
+			assert.Equal(t, "cover_letter_ranking/v1", usageRecords[2].PromptVersion)
+		}
+	})
+
+	t.Run("cached cover letter", func(t *testing.T) {
+		t.Setenv("COVER_LETTER_CACHE", "memory")
+		t.Setenv("RATE_LIMIT_PROFILE_BURST", "10")
+		// The cached cover letters are refunded, so they do not use up the quota of the 3 generations and the next request
+		t.Setenv("COVER_LETTER_DAILY_QUOTA", "4")
+		router, server := setup(t)
+		server.Enqueue(openaitest.CoverLetterCompletion("First draft."), openaitest.CoverLetterCompletion("Refreshed draft."), openaitest.CoverLetterCompletion("Formal draft."))
+		type coverLetterResponse struct {
+			Data string                 `json:"data"`
+			Meta map[string]interface{} `json:"meta"`
+		}
+		generate := func(request types.CoverLetterRequest) coverLetterResponse {
+			recorder := serve(t, router, "/v1/cover-letter", request)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			var response coverLetterResponse
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			return response
+		}
+
+		first := generate(requestData)
+		assert.Nil(t, first.Meta["cached"])
+		// An identical request returns the cached cover letter without calling the provider
+		cached := generate(requestData)
+		assert.Equal(t, first.Data, cached.Data)
+		assert.Equal(t, true, cached.Meta["cached"])
+		assert.Equal(t, map[string]interface{}{"prompt_tokens": float64(0), "completion_tokens": float64(0), "total_tokens": float64(0)}, cached.Meta["usage"])
+		assert.Len(t, server.Requests(), 1)
+		assert.Len(t, usageRecords, 1)
+		// The cached cover letter is linked to the cover letter saved in the history instead of being saved again
+		assert.Equal(t, first.Meta["cover_letter_id"], cached.Meta["cover_letter_id"])
+		assert.Len(t, savedCoverLetters, 1)
+
+		refreshRequest := requestData
+		refreshRequest.ForceRefresh = true
+		refreshed := generate(refreshRequest)
+		assert.Contains(t, refreshed.Data, "Refreshed draft.")
+		assert.Nil(t, refreshed.Meta["cached"])
+		// The refreshed cover letter replaces the cached one
+		assert.Contains(t, generate(requestData).Data, "Refreshed draft.")
+
+		formalRequest := requestData
+		formalRequest.Options.Tone = "formal"
+		assert.Contains(t, generate(formalRequest).Data, "Formal draft.")
+		assert.Len(t, server.Requests(), 3)
+
+		recorder := serve(t, router, "/v1/cover-letter/stream", formalRequest)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.NotContains(t, recorder.Body.String(), "event:token")
+		assert.Contains(t, recorder.Body.String(), `"cached":true`)
+		assert.Len(t, server.Requests(), 3)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		router, server := setup(t)
+
+		recorder := serve(t, router, "/v1/cover-letter", types.CoverLetterRequest{ProfileID: profileId})
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.Empty(t, server.Requests())
+	})
+}
+
+// testAccessToken is the access token of the profiles of the handler tests
+const testAccessToken = "some_token"
+
+// newTestHandler returns a handler with mocks of the store and the LLM client, the store validating the access token
+// of the profile
+func newTestHandler(t *testing.T, profileId uuid.UUID) (*Handler, *mocks.MockStore, *mocks.MockOpenAI) {
+	util.SetupTestEnvironment(t)
+	ctrl := gomock.NewController(t)
+	mockStore := mocks.NewMockStore(ctrl)
+	mockOpenAI := mocks.NewMockOpenAI(ctrl)
+	mockStore.EXPECT().ValidateAccessToken(gomock.Eq(profileId), gomock.Eq(testAccessToken), gomock.Any()).Return(true, nil).AnyTimes()
+	return NewHandler(mockStore, mockOpenAI), mockStore, mockOpenAI
+}
+
+// newTestRouter returns the API router of a handler created with newTestHandler
+func newTestRouter(t *testing.T, profileId uuid.UUID) (*gin.Engine, *mocks.MockStore, *mocks.MockOpenAI) {
+	handler, mockStore, mockOpenAI := newTestHandler(t, profileId)
+	return handler.SetupRouter(), mockStore, mockOpenAI
+}
+
+// newTestRequest returns a request of the profile, authorized with its access token
+func newTestRequest(t *testing.T, method string, apiEndpoint string, body string, profileId uuid.UUID) *http.Request {
+	req, err := http.NewRequest(method, apiEndpoint, strings.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("UserID", profileId.String())
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", testAccessToken))
+	return req
+}
+
+// serveJSON serves a request of the profile with the JSON body, if any, and returns the recorded response
+func serveJSON(t *testing.T, router *gin.Engine, method string, apiEndpoint string, body string, profileId uuid.UUID) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, newTestRequest(t, method, apiEndpoint, body, profileId))
+	return recorder
 }
@@ -1,12 +1,14 @@
 package handler
 
 import (
-	"fmt"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/internal/store"
+	"github.com/jonada182/cover-letter-ai-api/internal/usage"
 	"github.com/jonada182/cover-letter-ai-api/types"
 )
 
@@ -14,99 +16,277 @@ import (
 func (h *Handler) HandleCreateJobApplication(c *gin.Context) {
 	// Receive JobApplication parameters from request payload
 	var jobApplicationRequest types.JobApplication
-	if err := c.ShouldBindJSON(&jobApplicationRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("error retrieving JSON: %s", err.Error())})
-		return
-	}
-
-	if jobApplicationRequest.ProfileID.String() == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "profile id is required"})
-		return
-	}
-
-	if jobApplicationRequest.CompanyName == "" || jobApplicationRequest.JobRole == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "company name and job role are required"})
+	if !bindJSON(c, &jobApplicationRequest) {
 		return
 	}
 
 	// Call store method to upsert Job Application in MongoDB
 	jobApplication, responseMsq, err := h.StoreClient.StoreJobApplication(&jobApplicationRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": responseMsq, "data": jobApplication})
+	respondMessage(c, http.StatusOK, jobApplication, responseMsq)
 }
 
 // HandleGetJobApplications handles a GET method to retrieve job applications from MongoDB
 func (h *Handler) HandleGetJobApplications(c *gin.Context) {
 	profileIdParam, exists := c.Get("ProfileID")
 	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no profile_id provided in the request"})
+		respondError(c, http.StatusBadRequest, "no profile_id provided in the request")
 		return
 	}
 
 	profileId, ok := profileIdParam.(uuid.UUID)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid profile id"})
+		respondError(c, http.StatusBadRequest, "invalid profile id")
 		return
 	}
 
 	// Call store method to retrieve []JobApplication from MongoDB
 	jobApplications, err := h.StoreClient.GetJobApplications(profileId)
 	if err != nil && strings.Contains(err.Error(), "no job applications found") {
-		c.JSON(http.StatusNotFound, gin.H{"error": "no job applications found"})
+		respondError(c, http.StatusNotFound, "no job applications found")
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": &jobApplications})
+	respond(c, http.StatusOK, jobApplications, nil)
 }
 
 // HandleGetJobApplicationByID handles a GET method to retrieve a job application from MongoDB
 func (h *Handler) HandleGetJobApplicationByID(c *gin.Context) {
 	jobApplicationIdParam := c.Param("id")
 	if jobApplicationIdParam == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no job application id provided in the request"})
+		respondError(c, http.StatusBadRequest, "no job application id provided in the request")
 		return
 	}
 
 	jobApplicationId, err := uuid.Parse(jobApplicationIdParam)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	// Call store method to retrieve JobApplication from MongoDB
 	jobApplication, err := h.StoreClient.GetJobApplicationByID(jobApplicationId)
 	if err != nil && strings.Contains(err.Error(), "no document") {
-		c.JSON(http.StatusNotFound, gin.H{"error": "job application not found"})
+		respondError(c, http.StatusNotFound, "job application not found")
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": &jobApplication})
+	respond(c, http.StatusOK, jobApplication, nil)
 }
 
 // HandleDeleteJobApplication handles a DELETE request to delete a job application by ID
 func (h *Handler) HandleDeleteJobApplication(c *gin.Context) {
 	jobApplicationID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job application id"})
+		respondError(c, http.StatusBadRequest, "invalid job application id")
+		return
 	}
 
 	err = h.StoreClient.DeleteJobApplication(jobApplicationID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondMessage(c, http.StatusOK, nil, "job application deleted successfully")
+}
+
+// HandleJobApplicationCoverLetter handles a POST method that generates a cover letter from the company, role, URL
+// and job posting details of a job application, and attaches it to the job application
+func (h *Handler) HandleJobApplicationCoverLetter(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	// The request payload is optional, and only sets the cover letter options
+	var coverLetterRequest types.JobApplicationCoverLetterRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &coverLetterRequest) {
+		return
+	}
+	if !h.validateModel(c, coverLetterRequest.Options.Model) {
+		return
+	}
+	jobApplication, ok := h.profileJobApplication(c, profileId)
+	if !ok {
+		return
+	}
+	jobPosting := jobApplicationPosting(jobApplication)
+
+	// Call OpenAI to generate a cover letter for the job application
+	coverLetter, statusCode, err := h.OpenAIClient.GenerateChatGPTCoverLetter(c, profileId, &jobPosting, &coverLetterRequest.Options, h.StoreClient)
+	if err != nil {
+		h.recordFailedUsage(profileId, usage.OperationCoverLetter, err)
+		respondGenerationError(c, statusCode, err)
+		return
+	}
+
+	h.recordCoverLetterUsage(profileId, usage.OperationCoverLetter, coverLetter)
+	savedCoverLetter := h.saveCoverLetter(&types.CoverLetter{
+		ProfileID:        profileId,
+		JobApplicationID: &jobApplication.ID,
+		JobPosting:       jobPosting,
+		Options:          coverLetterRequest.Options,
+	}, coverLetter)
+	meta := generatedCoverLetterMeta(coverLetter, savedCoverLetter)
+	meta["job_application_id"] = jobApplication.ID
+	respond(c, http.StatusOK, coverLetter.Content, meta)
+}
+
+// HandleGetJobApplicationCoverLetters handles a GET method to retrieve the cover letter versions of a job application
+func (h *Handler) HandleGetJobApplicationCoverLetters(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	jobApplication, ok := h.profileJobApplication(c, profileId)
+	if !ok {
+		return
+	}
+
+	// Call store method to retrieve []CoverLetter of the job application from MongoDB
+	coverLetters, err := h.StoreClient.GetJobApplicationCoverLetters(profileId, jobApplication.ID)
+	if err != nil && strings.Contains(err.Error(), "no cover letters found") {
+		respondError(c, http.StatusNotFound, "no cover letters found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, coverLetters, nil)
+}
+
+// HandleInterviewPrep handles a POST method that generates the preparation of the interviews of a job application:
+// the likely questions with answers suggested from the career profile, and questions to ask the employer
+func (h *Handler) HandleInterviewPrep(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	jobApplication, ok := h.profileJobApplication(c, profileId)
+	if !ok {
+		return
+	}
+	careerProfile, err := h.StoreClient.GetCareerProfileByID(profileId)
+	if err != nil && strings.Contains(err.Error(), "no document") {
+		respondError(c, http.StatusNotFound, "career profile not found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// The interview events tell the LLM provider which interviews to prepare for
+	var interviews []types.JobApplicationEvent
+	if jobApplication.Events != nil {
+		for _, event := range *jobApplication.Events {
+			if event.Type == store.JobApplicationInterview {
+				interviews = append(interviews, event)
+			}
+		}
+	}
+	jobPosting := jobApplicationPosting(jobApplication)
+	generated, statusCode, err := h.OpenAIClient.PrepareChatGPTInterview(c, careerProfile, &jobPosting, interviews)
+	if err != nil {
+		h.recordFailedUsage(profileId, usage.OperationInterviewPrep, err)
+		respondGenerationError(c, statusCode, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "job application deleted successfully"})
+	h.recordUsage(profileId, usage.OperationInterviewPrep, generated.Model, generated.PromptVersion, generated.Usage)
+	interviewPrep := generated.InterviewPrep
+	interviewPrep.ProfileID = profileId
+	interviewPrep.JobApplicationID = jobApplication.ID
+	interviewPrep.Model = generated.Model
+	interviewPrep.PromptVersion = generated.PromptVersion
+	interviewPrep.Usage = generated.Usage
+	savedInterviewPrep, err := h.StoreClient.StoreInterviewPrep(&interviewPrep)
+	if err != nil {
+		log.Printf("error saving interview prep: %s", err.Error())
+		respondError(c, http.StatusInternalServerError, "error saving the interview prep")
+		return
+	}
+
+	respond(c, http.StatusOK, savedInterviewPrep, map[string]interface{}{
+		"model":          generated.Model,
+		"prompt_version": generated.PromptVersion,
+		"usage":          generated.Usage,
+	})
+}
+
+// HandleGetInterviewPrep handles a GET method to retrieve the most recent interview prep of a job application
+func (h *Handler) HandleGetInterviewPrep(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	jobApplication, ok := h.profileJobApplication(c, profileId)
+	if !ok {
+		return
+	}
+
+	interviewPrep, err := h.StoreClient.GetInterviewPrep(profileId, jobApplication.ID)
+	if err != nil && strings.Contains(err.Error(), "no document") {
+		respondError(c, http.StatusNotFound, "interview prep not found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, interviewPrep, nil)
+}
+
+// profileJobApplication returns the job application of the id path parameter, writing an error response
+// when it does not exist or belongs to another profile
+func (h *Handler) profileJobApplication(c *gin.Context, profileId uuid.UUID) (*types.JobApplication, bool) {
+	jobApplicationId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid job application id")
+		return nil, false
+	}
+
+	jobApplication, err := h.StoreClient.GetJobApplicationByID(jobApplicationId)
+	if (err != nil && strings.Contains(err.Error(), "no document")) || (err == nil && jobApplication.ProfileID != profileId) {
+		respondError(c, http.StatusNotFound, "job application not found")
+		return nil, false
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	return jobApplication, true
+}
+
+// jobApplicationPosting returns the job posting stored in a job application
+func jobApplicationPosting(jobApplication *types.JobApplication) types.JobPosting {
+	jobPosting := types.JobPosting{
+		CompanyName: jobApplication.CompanyName,
+		JobRole:     jobApplication.JobRole,
+	}
+	if jobApplication.URL != nil {
+		jobPosting.URL = *jobApplication.URL
+	}
+	if jobApplication.JobDetails != nil {
+		jobPosting.Details = *jobApplication.JobDetails
+	}
+	if jobApplication.Skills != nil {
+		jobPosting.Skills = *jobApplication.Skills
+	}
+	return jobPosting
 }
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonada182/cover-letter-ai-api/internal/jobposting"
+	"github.com/jonada182/cover-letter-ai-api/internal/usage"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// Sources of the parsed job postings
+const (
+	JobPostingSourceLLM      = "llm"
+	JobPostingSourceFallback = "fallback"
+)
+
+// HandleParseJobPosting handles a POST method that extracts the structured fields of a job posting text or HTML
+// with the LLM provider, completing them with the fallback extractor, which is used alone when the provider fails.
+// The job_posting of the response prefills the cover letter and job application requests.
+func (h *Handler) HandleParseJobPosting(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	var parseRequest types.JobPostingParseRequest
+	if !bindJSON(c, &parseRequest) {
+		return
+	}
+
+	text := parseRequest.Text
+	if jobposting.IsHTML(text) {
+		htmlText, err := jobposting.HTMLText(text)
+		if err != nil {
+			log.Printf("Failed to convert the job posting HTML to text:%s", err.Error())
+		} else {
+			text = htmlText
+		}
+	}
+	if strings.TrimSpace(text) == "" {
+		respondErrorDetails(c, http.StatusUnprocessableEntity, ErrorCodeValidationFailed, "request validation failed",
+			[]types.ValidationErrorDetail{{Field: "text", Rule: "required", Message: "is required"}})
+		return
+	}
+	fallback := jobposting.Extract(text)
+
+	generated, _, err := h.OpenAIClient.ParseChatGPTJobPosting(c, text)
+	if err != nil {
+		log.Printf("Failed to parse the job posting with the LLM provider, using the fallback extractor:%s", err.Error())
+		h.recordFailedUsage(profileId, usage.OperationJobPostingParse, err)
+		respond(c, http.StatusOK, jobposting.Prefill(fallback, text), map[string]interface{}{
+			"source": JobPostingSourceFallback,
+		})
+		return
+	}
+
+	h.recordUsage(profileId, usage.OperationJobPostingParse, generated.Model, generated.PromptVersion, generated.Usage)
+	respond(c, http.StatusOK, jobposting.Prefill(jobposting.Merge(generated.JobPosting, fallback), text), map[string]interface{}{
+		"source":         JobPostingSourceLLM,
+		"model":          generated.Model,
+		"prompt_version": generated.PromptVersion,
+		"usage":          generated.Usage,
+	})
+}
+
+// HandleImportJobPosting handles a POST method that fetches a job posting page and returns its job posting,
+// from the schema.org JobPosting JSON-LD of the page or its main content
+func (h *Handler) HandleImportJobPosting(c *gin.Context) {
+	var importRequest types.JobPostingImportRequest
+	if !bindJSON(c, &importRequest) {
+		return
+	}
+
+	document, err := h.JobPostingFetcher.Fetch(c.Request.Context(), importRequest.URL)
+	var netError net.Error
+	switch {
+	case errors.Is(err, jobposting.ErrUnsupportedURL):
+		respondErrorDetails(c, http.StatusUnprocessableEntity, ErrorCodeValidationFailed, "request validation failed",
+			[]types.ValidationErrorDetail{{Field: "url", Rule: "url", Message: jobposting.ErrUnsupportedURL.Error()}})
+		return
+	case errors.Is(err, jobposting.ErrBlockedAddress):
+		// The error of a redirect or connection wraps the blocked address, which is not disclosed
+		respondErrorDetails(c, http.StatusUnprocessableEntity, ErrorCodeValidationFailed, "request validation failed",
+			[]types.ValidationErrorDetail{{Field: "url", Rule: "public_url", Message: jobposting.ErrBlockedAddress.Error()}})
+		return
+	case errors.Is(err, jobposting.ErrNotHTML) || errors.Is(err, jobposting.ErrTooLarge):
+		respondError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	case errors.As(err, &netError) && netError.Timeout():
+		respondError(c, http.StatusGatewayTimeout, "the job posting URL timed out")
+		return
+	case err != nil:
+		log.Printf("Failed to fetch the job posting %s:%s", importRequest.URL, err.Error())
+		respondError(c, http.StatusBadGateway, "error fetching the job posting: "+err.Error())
+		return
+	}
+
+	jobPosting, source, err := jobposting.FromPage(document)
+	if err != nil {
+		respondError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	jobPosting.URL = importRequest.URL
+	respond(c, http.StatusOK, jobPosting, map[string]interface{}{
+		"source": source,
+	})
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
@@ -9,44 +10,94 @@ import (
 	"github.com/google/uuid"
 )
 
-func (h *Handler) middleware() gin.HandlerFunc {
+// authenticate only allows requests with a valid access token for the profile in the UserID header
+func (h *Handler) authenticate() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, UserID")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		accessToken, ok := bearerToken(c)
+		if !ok {
+			respondError(c, http.StatusUnauthorized, "Unauthorized request!")
 			return
 		}
-		if c.Request.URL.Path != "/linkedin/callback" {
-			authorizationHeader := c.GetHeader("Authorization")
-			tokenParts := strings.Split(authorizationHeader, " ")
-			if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized request!"})
-				return
-			}
-			accessToken := tokenParts[1]
-
-			if c.Request.URL.Path != "/auth" {
-				UserID := c.GetHeader("UserID")
-				profileId, err := uuid.Parse(UserID)
-				if err != nil {
-					log.Printf("error when parsing UserID: %s", err.Error())
-					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized request"})
-					return
-				}
-				validToken, err := h.StoreClient.ValidateAccessToken(profileId, accessToken, c.ClientIP())
-				if !validToken || err != nil {
-					log.Printf("error when validating access token: %s", err.Error())
-					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized request"})
-					return
-				}
-				c.Set("ProfileID", profileId)
-			}
-			c.Set("AccessToken", accessToken)
+
+		UserID := c.GetHeader("UserID")
+		profileId, err := uuid.Parse(UserID)
+		if err != nil {
+			log.Printf("error when parsing UserID: %s", err.Error())
+			respondError(c, http.StatusUnauthorized, "Unauthorized request")
+			return
+		}
+		validToken, err := h.StoreClient.ValidateAccessToken(profileId, accessToken, c.ClientIP())
+		if err != nil {
+			log.Printf("error when validating access token: %s", err.Error())
+		}
+		if !validToken || err != nil {
+			respondError(c, http.StatusUnauthorized, "Unauthorized request")
+			return
+		}
+
+		c.Set("ProfileID", profileId)
+		c.Set("AccessToken", accessToken)
+		c.Next()
+	}
+}
+
+// contextProfileID returns the profile id set by authenticate, writing an error response when it is missing
+func contextProfileID(c *gin.Context) (uuid.UUID, bool) {
+	profileIdParam, exists := c.Get("ProfileID")
+	if !exists {
+		respondError(c, http.StatusBadRequest, "no profile_id provided in the request")
+		return uuid.Nil, false
+	}
+
+	profileId, ok := profileIdParam.(uuid.UUID)
+	if !ok {
+		respondError(c, http.StatusBadRequest, "invalid profile id")
+		return uuid.Nil, false
+	}
+	return profileId, true
+}
+
+// requireOwnProfile checks that the profile_id of a request payload is the authenticated profile, writing an error response when it is not
+func requireOwnProfile(c *gin.Context, profileId uuid.UUID) bool {
+	authenticatedProfileId, ok := contextProfileID(c)
+	if !ok {
+		return false
+	}
+	if profileId != authenticatedProfileId {
+		respondError(c, http.StatusForbidden, "profile_id does not match the authenticated profile")
+		return false
+	}
+	return true
+}
+
+// requireAccessToken only allows requests with a bearer access token, without validating it against a profile
+func (h *Handler) requireAccessToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accessToken, ok := bearerToken(c)
+		if !ok {
+			respondError(c, http.StatusUnauthorized, "Unauthorized request!")
+			return
 		}
 
+		c.Set("AccessToken", accessToken)
+		c.Next()
+	}
+}
+
+// bearerToken returns the access token from the Authorization header
+func bearerToken(c *gin.Context) (string, bool) {
+	tokenParts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" || tokenParts[1] == "" {
+		return "", false
+	}
+	return tokenParts[1], true
+}
+
+// deprecated marks the routes of a group as deprecated, pointing clients to the current API version
+func deprecated() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", APIVersionPrefix, c.Request.URL.Path))
 		c.Next()
 	}
 }
@@ -0,0 +1,433 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/internal/store"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// apiOperation describes an API route registered in SetupRouter for the OpenAPI specification
+type apiOperation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Tag         string
+	Public      bool
+	Unversioned bool
+	Request     interface{}
+	Response    interface{}
+	Body        interface{}
+	Message     bool
+	HTML        bool
+	Redirect    bool
+	RateLimited bool
+	// Quota routes also consume a generation from the cover letter or generation quota of the profile
+	Quota     bool
+	TokenOnly bool
+	// Admin routes require the ADMIN_API_KEY instead of a profile access token
+	Admin       bool
+	EventStream bool
+	Generates   bool
+	Query       []string
+	// Files are the content types of a file download response
+	Files []string
+}
+
+// apiOperations lists every route registered in SetupRouter, and must be updated when routes change.
+// Versioned paths are relative to APIVersionPrefix, and documented with their deprecated aliases,
+// while unversioned paths are only registered at the root.
+var apiOperations = []apiOperation{
+	{Method: http.MethodGet, Path: "/", Summary: "Welcome message", Tag: "General", Message: true},
+	{Method: http.MethodGet, Path: "/openapi.json", Summary: "OpenAPI specification", Tag: "General", Public: true, Unversioned: true, Body: map[string]interface{}{}},
+	{Method: http.MethodGet, Path: "/docs", Summary: "Swagger UI", Tag: "General", Public: true, Unversioned: true, HTML: true},
+	{Method: http.MethodPost, Path: "/cover-letter", Summary: "Generate a cover letter", Tag: "Cover Letter", RateLimited: true, Quota: true, Generates: true, Request: types.CoverLetterRequest{}, Response: ""},
+	{Method: http.MethodPost, Path: "/cover-letter/stream", Summary: "Stream a cover letter as Server-Sent Events", Tag: "Cover Letter", RateLimited: true, Quota: true, Generates: true, EventStream: true, Request: types.CoverLetterRequest{}},
+	{Method: http.MethodGet, Path: "/cover-letters", Summary: "List cover letters generated by the current user", Tag: "Cover Letter", Response: []types.CoverLetter{}},
+	{Method: http.MethodGet, Path: "/cover-letters/:id", Summary: "Get a generated cover letter", Tag: "Cover Letter", Response: types.CoverLetter{}},
+	{Method: http.MethodPut, Path: "/cover-letters/:id", Summary: "Save the edits of a generated cover letter", Tag: "Cover Letter", Request: types.CoverLetterUpdateRequest{}, Response: types.CoverLetter{}, Message: true},
+	{Method: http.MethodDelete, Path: "/cover-letters/:id", Summary: "Delete a generated cover letter", Tag: "Cover Letter", Message: true},
+	{Method: http.MethodGet, Path: "/cover-letters/:id/export", Summary: "Export a cover letter as a pdf, docx, md, html or txt document", Tag: "Cover Letter", Query: []string{"format"}, Files: exportContentTypes()},
+	{Method: http.MethodPost, Path: "/cover-letters/:id/revise", Summary: "Revise a cover letter with an instruction, saving a new version", Tag: "Cover Letter", RateLimited: true, Quota: true, Generates: true, Request: types.CoverLetterRevisionRequest{}, Response: types.CoverLetter{}},
+	{Method: http.MethodPost, Path: "/career-profile", Summary: "Create or update a career profile", Tag: "Career Profile", Request: types.CareerProfile{}, Response: types.CareerProfile{}, Message: true},
+	{Method: http.MethodGet, Path: "/career-profile", Summary: "Get the career profile of the current user", Tag: "Career Profile", Response: types.CareerProfile{}},
+	{Method: http.MethodPost, Path: "/job-applications", Summary: "Create or update a job application", Tag: "Job Applications", Request: types.JobApplication{}, Response: types.JobApplication{}, Message: true},
+	{Method: http.MethodGet, Path: "/job-applications", Summary: "List job applications of the current user", Tag: "Job Applications", Response: []types.JobApplication{}},
+	{Method: http.MethodGet, Path: "/job-applications/:id", Summary: "Get a job application", Tag: "Job Applications", Response: types.JobApplication{}},
+	{Method: http.MethodDelete, Path: "/job-applications/:id", Summary: "Delete a job application", Tag: "Job Applications", Message: true},
+	{Method: http.MethodPost, Path: "/job-applications/:id/cover-letter", Summary: "Generate a cover letter for a job application", Tag: "Job Applications", RateLimited: true, Quota: true, Generates: true, Request: types.JobApplicationCoverLetterRequest{}, Response: ""},
+	{Method: http.MethodGet, Path: "/job-applications/:id/cover-letters", Summary: "List the cover letters generated for a job application", Tag: "Job Applications", Response: []types.CoverLetter{}},
+	{Method: http.MethodPost, Path: "/job-applications/:id/interview-prep", Summary: "Generate the likely interview questions of a job application, with suggested STAR answers and questions to ask the employer", Tag: "Job Applications", RateLimited: true, Quota: true, Generates: true, Response: types.InterviewPrep{}},
+	{Method: http.MethodGet, Path: "/job-applications/:id/interview-prep", Summary: "Get the most recent interview prep of a job application", Tag: "Job Applications", Response: types.InterviewPrep{}},
+	{Method: http.MethodPost, Path: "/job-postings/parse", Summary: "Parse a job posting text or HTML into structured fields, to prefill cover letters and job applications", Tag: "Job Postings", RateLimited: true, Request: types.JobPostingParseRequest{}, Response: types.ParsedJobPosting{}},
+	{Method: http.MethodPost, Path: "/job-postings/import", Summary: "Import a job posting from its page URL, using the schema.org JobPosting JSON-LD or the main content of the page", Tag: "Job Postings", RateLimited: true, Request: types.JobPostingImportRequest{}, Response: types.JobPosting{}},
+	{Method: http.MethodPost, Path: "/analysis/skills-gap", Summary: "Compare the skills of the career profile with a job posting, with a match score", Tag: "Analysis", RateLimited: true, Quota: true, Request: types.SkillsGapRequest{}, Response: types.SkillsGapAnalysis{}},
+	{Method: http.MethodPost, Path: "/resume/tailor", Summary: "Tailor a resume to a job posting from the career profile and its work history", Tag: "Resumes", RateLimited: true, Quota: true, Generates: true, Request: types.ResumeTailorRequest{}, Response: types.Resume{}},
+	{Method: http.MethodGet, Path: "/resumes", Summary: "List the resumes tailored by the current user", Tag: "Resumes", Response: []types.Resume{}},
+	{Method: http.MethodGet, Path: "/resumes/:id", Summary: "Get a tailored resume", Tag: "Resumes", Response: types.Resume{}},
+	{Method: http.MethodGet, Path: "/resumes/:id/export", Summary: "Export a tailored resume as a pdf, docx, md, html or txt document", Tag: "Resumes", Query: []string{"format"}, Files: exportContentTypes()},
+	{Method: http.MethodGet, Path: "/me/usage", Summary: "Get the LLM usage and estimated cost of the current user per day", Tag: "Usage", Query: []string{"from", "to"}, Response: types.UsageReport{}},
+	{Method: http.MethodGet, Path: "/admin/usage", Summary: "Get the LLM usage and estimated cost of every profile per day", Tag: "Usage", Admin: true, Query: []string{"from", "to"}, Response: types.UsageReport{}},
+	{Method: http.MethodGet, Path: "/linkedin/callback", Summary: "LinkedIn OAuth callback", Tag: "Auth", Public: true, Redirect: true, Query: []string{"state", "code"}},
+	{Method: http.MethodGet, Path: "/auth", Summary: "Authenticate with a LinkedIn access token", Tag: "Auth", TokenOnly: true, Response: types.AuthResponse{}},
+}
+
+// HandleOpenAPISpec returns the OpenAPI 3 specification of the API
+func (h *Handler) HandleOpenAPISpec(c *gin.Context) {
+	c.JSON(http.StatusOK, OpenAPISpec())
+}
+
+// HandleSwaggerUI returns a Swagger UI page that renders the OpenAPI specification
+func (h *Handler) HandleSwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// OpenAPISpec builds the OpenAPI 3 specification from apiOperations, deriving schemas from types
+func OpenAPISpec() map[string]interface{} {
+	schemas := map[string]interface{}{}
+	paths := map[string]interface{}{}
+
+	for _, operation := range apiOperations {
+		if operation.Unversioned {
+			addPathOperation(paths, operation.Path, operation.Method, operationSpec(operation, schemas))
+			continue
+		}
+		addPathOperation(paths, APIVersionPrefix+operation.Path, operation.Method, operationSpec(operation, schemas))
+		deprecatedSpec := operationSpec(operation, schemas)
+		deprecatedSpec["deprecated"] = true
+		addPathOperation(paths, operation.Path, operation.Method, deprecatedSpec)
+	}
+
+	schemaFor(reflect.TypeOf(types.ValidationErrorDetail{}), schemas)
+	schemas["Error"] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"data": map[string]interface{}{"nullable": true},
+			"error": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"code":    map[string]interface{}{"type": "string"},
+					"message": map[string]interface{}{"type": "string"},
+					"details": map[string]interface{}{
+						"description": "Additional information about the error, e.g. a list of ValidationErrorDetail when the request validation fails",
+					},
+				},
+			},
+		},
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "CoverLetterAI API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"userID":     map[string]interface{}{"type": "apiKey", "in": "header", "name": "UserID"},
+				"adminKey":   map[string]interface{}{"type": "apiKey", "in": "header", "name": AdminKeyHeader},
+			},
+		},
+	}
+}
+
+// operationSpec returns the OpenAPI operation object for an API route
+func operationSpec(operation apiOperation, schemas map[string]interface{}) map[string]interface{} {
+	spec := map[string]interface{}{
+		"summary": operation.Summary,
+		"tags":    []string{operation.Tag},
+	}
+
+	var parameters []interface{}
+	for _, segment := range strings.Split(operation.Path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     strings.TrimPrefix(segment, ":"),
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string", "format": "uuid"},
+			})
+		}
+	}
+	for _, query := range operation.Query {
+		parameters = append(parameters, map[string]interface{}{
+			"name":   query,
+			"in":     "query",
+			"schema": map[string]interface{}{"type": "string"},
+		})
+	}
+	if len(parameters) > 0 {
+		spec["parameters"] = parameters
+	}
+
+	if operation.Request != nil {
+		spec["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": schemaFor(reflect.TypeOf(operation.Request), schemas),
+				},
+			},
+		}
+	}
+
+	responses := map[string]interface{}{
+		"default": jsonResponse("Error response", map[string]interface{}{"$ref": "#/components/schemas/Error"}),
+	}
+	if operation.Request != nil {
+		responses["422"] = jsonResponse("Request validation failed", map[string]interface{}{"$ref": "#/components/schemas/Error"})
+	}
+	switch {
+	case operation.Quota:
+		responses["429"] = jsonResponse("Rate limit or generation quota exceeded", map[string]interface{}{"$ref": "#/components/schemas/Error"})
+	case operation.RateLimited:
+		responses["429"] = jsonResponse("Rate limit exceeded", map[string]interface{}{"$ref": "#/components/schemas/Error"})
+	}
+	if operation.Generates {
+		responses["502"] = jsonResponse("The LLM provider returned an error", map[string]interface{}{"$ref": "#/components/schemas/Error"})
+		responses["503"] = jsonResponse("The LLM provider is rate limited or unavailable, retry after the Retry-After header", map[string]interface{}{"$ref": "#/components/schemas/Error"})
+		responses["504"] = jsonResponse("The LLM provider timed out", map[string]interface{}{"$ref": "#/components/schemas/Error"})
+	}
+	switch {
+	case operation.Redirect:
+		responses["308"] = map[string]interface{}{"description": "Redirect to the client application"}
+	case operation.EventStream:
+		responses["200"] = map[string]interface{}{
+			"description": "Server-Sent Events: token events with the generated content, then a done event with the cover letter, or an error event",
+			"content": map[string]interface{}{
+				"text/event-stream": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			},
+		}
+	case len(operation.Files) > 0:
+		content := map[string]interface{}{}
+		for _, contentType := range operation.Files {
+			content[contentType] = map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}
+		}
+		responses["200"] = map[string]interface{}{"description": "File download", "content": content}
+	case operation.HTML:
+		responses["200"] = map[string]interface{}{
+			"description": "HTML page",
+			"content": map[string]interface{}{
+				"text/html": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			},
+		}
+	case operation.Body != nil:
+		responses["200"] = jsonResponse("Successful response", schemaFor(reflect.TypeOf(operation.Body), schemas))
+	default:
+		responseProperties := map[string]interface{}{
+			"data": map[string]interface{}{"nullable": true},
+		}
+		if operation.Response != nil {
+			responseProperties["data"] = schemaFor(reflect.TypeOf(operation.Response), schemas)
+		}
+		if operation.Message {
+			responseProperties["meta"] = map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"message": map[string]interface{}{"type": "string"},
+				},
+			}
+		}
+		responses["200"] = jsonResponse("Successful response", map[string]interface{}{
+			"type":       "object",
+			"properties": responseProperties,
+		})
+	}
+	if operation.Admin {
+		spec["security"] = []interface{}{map[string]interface{}{"adminKey": []string{}}}
+		responses["401"] = jsonResponse("Unauthorized request", map[string]interface{}{"$ref": "#/components/schemas/Error"})
+		responses["403"] = jsonResponse("The admin API is disabled", map[string]interface{}{"$ref": "#/components/schemas/Error"})
+	} else if !operation.Public {
+		security := map[string]interface{}{"bearerAuth": []string{}, "userID": []string{}}
+		if operation.TokenOnly {
+			delete(security, "userID")
+		}
+		spec["security"] = []interface{}{security}
+		responses["401"] = jsonResponse("Unauthorized request", map[string]interface{}{"$ref": "#/components/schemas/Error"})
+	}
+	spec["responses"] = responses
+
+	return spec
+}
+
+// addPathOperation adds an operation object to the OpenAPI paths for the given route
+func addPathOperation(paths map[string]interface{}, path string, method string, spec map[string]interface{}) {
+	path = openAPIPath(path)
+	pathItem, ok := paths[path].(map[string]interface{})
+	if !ok {
+		pathItem = map[string]interface{}{}
+		paths[path] = pathItem
+	}
+	pathItem[strings.ToLower(method)] = spec
+}
+
+// openAPIPath converts a gin route path (/items/:id) into an OpenAPI path (/items/{id})
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimPrefix(segment, ":") + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// jsonResponse returns an OpenAPI response object with a JSON schema
+func jsonResponse(description string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+var uuidType = reflect.TypeOf(uuid.UUID{})
+
+// schemaFor returns an OpenAPI schema for the given type, registering structs as components
+func schemaFor(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	if t == uuidType {
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := schemaFor(t.Elem(), schemas)
+		if _, isRef := schema["$ref"]; isRef {
+			return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return map[string]interface{}{"type": "object"}
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		name := t.Name()
+		if _, exists := schemas[name]; !exists {
+			// Reserve the name before walking the fields to support recursive types
+			schemas[name] = map[string]interface{}{}
+			properties := map[string]interface{}{}
+			var required []string
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				if !field.IsExported() {
+					continue
+				}
+				jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+				if jsonName == "-" {
+					continue
+				}
+				if jsonName == "" {
+					jsonName = field.Name
+				}
+				fieldSchema := schemaFor(field.Type, schemas)
+				if applyBindingRules(fieldSchema, field.Tag.Get("binding")) {
+					required = append(required, jsonName)
+				}
+				properties[jsonName] = fieldSchema
+			}
+			schema := map[string]interface{}{
+				"type":       "object",
+				"properties": properties,
+			}
+			if len(required) > 0 {
+				schema["required"] = required
+			}
+			schemas[name] = schema
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// applyBindingRules adds the validation rules from a binding tag to a field schema, and reports if the field is required
+func applyBindingRules(schema map[string]interface{}, bindingTag string) bool {
+	required := false
+	for _, rule := range strings.Split(bindingTag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "dive" {
+			// Rules after dive apply to the items of the field
+			break
+		}
+		if name == "required" {
+			required = true
+			continue
+		}
+		if _, isRef := schema["$ref"]; isRef {
+			continue
+		}
+		switch name {
+		case "email":
+			schema["format"] = "email"
+		case "url":
+			schema["format"] = "uri"
+		case "oneof":
+			schema["enum"] = strings.Fields(param)
+		case "event_type":
+			schema["enum"] = []int{
+				store.JobApplicationSubmission,
+				store.JobApplicationInterview,
+				store.JobApplicationAssessment,
+				store.JobApplicationOffer,
+				store.JobApplicationCompletion,
+				store.JobApplicationRejection,
+			}
+		case "min", "max":
+			limit, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			keyword := map[string]string{"string": "Length", "array": "Items"}[fmt.Sprint(schema["type"])]
+			if keyword == "" && name == "min" {
+				schema["minimum"] = limit
+			} else if keyword == "" {
+				schema["maximum"] = limit
+			} else {
+				schema[name+keyword] = limit
+			}
+		}
+	}
+	return required
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<title>CoverLetterAI API</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+	<script>
+		window.onload = () => {
+			window.ui = SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });
+		};
+	</script>
+</body>
+</html>`
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/internal/ratelimit"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+const (
+	ErrorCodeRateLimited   = "rate_limited"
+	ErrorCodeQuotaExceeded = "quota_exceeded"
+)
+
+// quotaScopeGeneration is the store scope of the generation quota
+const quotaScopeGeneration = "generation"
+
+// refundQuotaKey is the context key set by refundQuota
+const refundQuotaKey = "RefundQuota"
+
+// newRateLimiters returns the per profile and per IP limiters for the configured backend
+func newRateLimiters(s types.StoreClient, config ratelimit.Config) (ratelimit.Limiter, ratelimit.Limiter) {
+	if config.Backend == "store" && s != nil {
+		return ratelimit.NewStoreLimiter(s, config.ProfileRatePerMinute, config.ProfileBurst),
+			ratelimit.NewStoreLimiter(s, config.IPRatePerMinute, config.IPBurst)
+	}
+	return ratelimit.NewMemoryLimiter(config.ProfileRatePerMinute, config.ProfileBurst),
+		ratelimit.NewMemoryLimiter(config.IPRatePerMinute, config.IPBurst)
+}
+
+// newQuotas returns the cover letter quota and the quota of the other generations for the configured backend
+func newQuotas(s types.StoreClient, config ratelimit.Config) (ratelimit.Quota, ratelimit.Quota) {
+	if config.Backend == "store" && s != nil {
+		return ratelimit.NewStoreQuota(s, "", config.DailyQuota, config.MonthlyQuota),
+			ratelimit.NewStoreQuota(s, quotaScopeGeneration, config.GenerationDailyQuota, config.GenerationMonthlyQuota)
+	}
+	return ratelimit.NewMemoryQuota(config.DailyQuota, config.MonthlyQuota),
+		ratelimit.NewMemoryQuota(config.GenerationDailyQuota, config.GenerationMonthlyQuota)
+}
+
+// rateLimit limits cover letter generations per IP address and per profile, and enforces the cover letter quota
+func (h *Handler) rateLimit() gin.HandlerFunc {
+	return h.limitQuota(h.Quota, "cover letter")
+}
+
+// generationRateLimit limits the generations other than cover letters, e.g. tailored resumes, per IP address and
+// per profile, and enforces the generation quota
+func (h *Handler) generationRateLimit() gin.HandlerFunc {
+	return h.limitQuota(h.GenerationQuota, "generation")
+}
+
+// limitQuota limits requests per IP address and per profile, and consumes a generation from the quota, named in the
+// error of exceeded quotas
+func (h *Handler) limitQuota(profileQuota ratelimit.Quota, name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		profileId, ok := h.allowIPAndProfile(c)
+		if !ok {
+			return
+		}
+
+		quota, err := profileQuota.Consume(profileId)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !quota.Allowed {
+			retryAfter := time.Until(quota.ResetAt)
+			c.Header("Retry-After", retryAfterSeconds(retryAfter))
+			respondErrorDetails(c, http.StatusTooManyRequests, ErrorCodeQuotaExceeded,
+				fmt.Sprintf("%s %s quota of %d exceeded", quota.Period, name, quota.Limit),
+				gin.H{"period": quota.Period, "limit": quota.Limit, "reset_at": quota.ResetAt.Format(time.RFC3339)})
+			return
+		}
+
+		c.Next()
+
+		// Failed generations, and the requests that did not generate, do not count towards the quota
+		if c.Writer.Status() >= http.StatusBadRequest || c.GetBool(refundQuotaKey) {
+			if err := profileQuota.Refund(profileId, quota.PeriodKeys); err != nil {
+				log.Printf("error when refunding quota: %s", err.Error())
+			}
+		}
+	}
+}
+
+// refundQuota refunds the generation consumed from the quota by limitQuota when the request succeeds
+// without generating, e.g. when the cover letter is cached, or fails after its status was sent, e.g. a failed stream
+func refundQuota(c *gin.Context) {
+	c.Set(refundQuotaKey, true)
+}
+
+// throttle limits requests per IP address and per profile, for the routes that do not generate with the LLM
+// provider and so do not count towards the quota
+func (h *Handler) throttle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := h.allowIPAndProfile(c); ok {
+			c.Next()
+		}
+	}
+}
+
+// allowIPAndProfile takes a token for the IP address and the profile of the request, aborting when either is rate limited
+func (h *Handler) allowIPAndProfile(c *gin.Context) (uuid.UUID, bool) {
+	if !h.allowRequest(c, h.IPLimiter, "ip:"+c.ClientIP()) {
+		return uuid.Nil, false
+	}
+
+	profileIdParam, _ := c.Get("ProfileID")
+	profileId, ok := profileIdParam.(uuid.UUID)
+	if !ok {
+		respondError(c, http.StatusBadRequest, "invalid profile id")
+		return uuid.Nil, false
+	}
+	return profileId, h.allowRequest(c, h.ProfileLimiter, "profile:"+profileId.String())
+}
+
+// allowRequest takes a token for the key and sets the X-RateLimit-* headers, aborting with 429 when rate limited
+func (h *Handler) allowRequest(c *gin.Context, limiter ratelimit.Limiter, key string) bool {
+	result, err := limiter.Allow(key)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return false
+	}
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(result.ResetAfter).Unix(), 10))
+	if !result.Allowed {
+		c.Header("Retry-After", retryAfterSeconds(result.RetryAfter))
+		respondErrorDetails(c, http.StatusTooManyRequests, ErrorCodeRateLimited, "too many requests, please try again later", nil)
+		return false
+	}
+	return true
+}
+
+// retryAfterSeconds formats a duration as a Retry-After header value, rounding up to the next second
+func retryAfterSeconds(duration time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(duration.Seconds())))
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonada182/cover-letter-ai-api/internal/llm"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// respond writes a successful response using the API response envelope
+func respond(c *gin.Context, statusCode int, data interface{}, meta map[string]interface{}) {
+	c.JSON(statusCode, types.Response{
+		Data: data,
+		Meta: meta,
+	})
+}
+
+// respondMessage writes a successful response with a message in the envelope meta
+func respondMessage(c *gin.Context, statusCode int, data interface{}, message string) {
+	respond(c, statusCode, data, map[string]interface{}{"message": message})
+}
+
+// respondError writes an error response using the API response envelope, with an error code derived from the status code
+func respondError(c *gin.Context, statusCode int, message string) {
+	respondErrorDetails(c, statusCode, errorCode(statusCode), message, nil)
+}
+
+// respondGenerationError writes the error response of a failed LLM generation,
+// telling clients when to retry if the provider is temporarily unavailable
+func respondGenerationError(c *gin.Context, statusCode int, err error) {
+	if retryAfter := llm.RetryAfter(err); statusCode == http.StatusServiceUnavailable && retryAfter > 0 {
+		c.Header("Retry-After", retryAfterSeconds(retryAfter))
+	}
+	respondError(c, statusCode, err.Error())
+}
+
+// respondErrorDetails writes an error response using the API response envelope with a specific error code and details
+func respondErrorDetails(c *gin.Context, statusCode int, code string, message string, details interface{}) {
+	c.AbortWithStatusJSON(statusCode, types.Response{
+		Error: &types.ResponseError{
+			Code:    code,
+			Message: message,
+			Details: details,
+		},
+	})
+}
+
+// errorCode returns a snake case error code from a HTTP status code (e.g. 404 -> not_found)
+func errorCode(statusCode int) string {
+	statusText := http.StatusText(statusCode)
+	if statusText == "" {
+		return "error"
+	}
+	statusText = strings.ReplaceAll(statusText, "-", " ")
+	return strings.ReplaceAll(strings.ToLower(statusText), " ", "_")
+}
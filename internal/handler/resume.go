@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/internal/export"
+	"github.com/jonada182/cover-letter-ai-api/internal/usage"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// HandleTailorResume handles a POST method that tailors a resume to a job posting from the career profile and its
+// work history, saving it in the resumes of the profile
+func (h *Handler) HandleTailorResume(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	var resumeRequest types.ResumeTailorRequest
+	if !bindJSON(c, &resumeRequest) {
+		return
+	}
+
+	careerProfile, err := h.StoreClient.GetCareerProfileByID(profileId)
+	if err != nil && strings.Contains(err.Error(), "no document") {
+		respondError(c, http.StatusNotFound, "career profile not found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	generated, statusCode, err := h.OpenAIClient.TailorChatGPTResume(c, careerProfile, &resumeRequest.JobPosting)
+	if err != nil {
+		h.recordFailedUsage(profileId, usage.OperationResumeTailor, err)
+		respondGenerationError(c, statusCode, err)
+		return
+	}
+
+	h.recordUsage(profileId, usage.OperationResumeTailor, generated.Model, generated.PromptVersion, generated.Usage)
+	resume := generated.Resume
+	resume.ProfileID = profileId
+	resume.JobPosting = resumeRequest.JobPosting
+	resume.Model = generated.Model
+	resume.PromptVersion = generated.PromptVersion
+	resume.Usage = generated.Usage
+	savedResume, err := h.StoreClient.StoreResume(&resume)
+	if err != nil {
+		log.Printf("error saving resume: %s", err.Error())
+		respondError(c, http.StatusInternalServerError, "error saving the resume")
+		return
+	}
+
+	respond(c, http.StatusOK, savedResume, map[string]interface{}{
+		"model":          generated.Model,
+		"prompt_version": generated.PromptVersion,
+		"usage":          generated.Usage,
+	})
+}
+
+// HandleGetResumes handles a GET method to retrieve the tailored resumes of the profile, starting with the most recent
+func (h *Handler) HandleGetResumes(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+
+	resumes, err := h.StoreClient.GetResumes(profileId)
+	if err != nil && strings.Contains(err.Error(), "no resumes found") {
+		respondError(c, http.StatusNotFound, "no resumes found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, resumes, nil)
+}
+
+// HandleGetResumeByID handles a GET method to retrieve a tailored resume of the profile
+func (h *Handler) HandleGetResumeByID(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	resumeId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid resume id")
+		return
+	}
+
+	resume, err := h.StoreClient.GetResumeByID(profileId, resumeId)
+	if err != nil && strings.Contains(err.Error(), "no document") {
+		respondError(c, http.StatusNotFound, "resume not found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, resume, nil)
+}
+
+// HandleExportResume handles a GET method that downloads a tailored resume of the profile as a document
+// in the export formats of the cover letters
+func (h *Handler) HandleExportResume(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	resumeId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid resume id")
+		return
+	}
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	resume, err := h.StoreClient.GetResumeByID(profileId, resumeId)
+	if err != nil && strings.Contains(err.Error(), "no document") {
+		respondError(c, http.StatusNotFound, "resume not found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	careerProfile, err := h.StoreClient.GetCareerProfileByID(profileId)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	document, err := export.RenderResume(export.NewResume(resume, careerProfile), format)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, exportFilename("resume", &resume.JobPosting, format)))
+	c.Data(http.StatusOK, export.ContentType(format), document)
+}
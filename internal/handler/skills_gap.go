@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonada182/cover-letter-ai-api/internal/skillsgap"
+	"github.com/jonada182/cover-letter-ai-api/internal/usage"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// Sources of the skills gap analyses
+const (
+	SkillsGapSourceLLM      = "llm"
+	SkillsGapSourceKeywords = "keywords"
+)
+
+// HandleSkillsGap handles a POST method that compares the skills of the career profile with a job posting,
+// combining the normalized keyword match with the analysis of the LLM provider, or returning the keyword match
+// alone when the provider fails
+func (h *Handler) HandleSkillsGap(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	var skillsGapRequest types.SkillsGapRequest
+	if !bindJSON(c, &skillsGapRequest) {
+		return
+	}
+
+	careerProfile, err := h.StoreClient.GetCareerProfileByID(profileId)
+	if err != nil && strings.Contains(err.Error(), "no document") {
+		respondError(c, http.StatusNotFound, "career profile not found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	keywordMatch := skillsgap.Match(careerProfile, &skillsGapRequest.JobPosting)
+	generated, _, err := h.OpenAIClient.AnalyzeChatGPTSkillsGap(c, careerProfile, &skillsGapRequest.JobPosting, &keywordMatch)
+	if err != nil {
+		log.Printf("Failed to analyze the skills gap with the LLM provider, using the keyword match:%s", err.Error())
+		h.recordFailedUsage(profileId, usage.OperationSkillsGap, err)
+		respond(c, http.StatusOK, keywordMatch, map[string]interface{}{
+			"source": SkillsGapSourceKeywords,
+		})
+		return
+	}
+
+	h.recordUsage(profileId, usage.OperationSkillsGap, generated.Model, generated.PromptVersion, generated.Usage)
+	respond(c, http.StatusOK, skillsgap.Combine(keywordMatch, generated.Analysis), map[string]interface{}{
+		"source":         SkillsGapSourceLLM,
+		"model":          generated.Model,
+		"prompt_version": generated.PromptVersion,
+		"usage":          generated.Usage,
+	})
+}
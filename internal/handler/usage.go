@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/internal/llm"
+	"github.com/jonada182/cover-letter-ai-api/internal/usage"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// AdminKeyHeader is the header of the ADMIN_API_KEY required by the admin routes
+const AdminKeyHeader = "X-Admin-Key"
+
+const (
+	// defaultUsageDays is the number of days of a usage report without a from date
+	defaultUsageDays = 30
+	// maxUsageDays is the longest period of a usage report
+	maxUsageDays = 366
+)
+
+// recordUsage saves the token usage and estimated cost of a LLM call made for a profile, only logging the errors,
+// since the generation was already completed and can still be returned
+func (h *Handler) recordUsage(profileId uuid.UUID, operation string, model string, promptVersion string, tokenUsage types.TokenUsage) {
+	err := h.StoreClient.StoreUsageRecord(&types.UsageRecord{
+		ProfileID:     profileId,
+		Operation:     operation,
+		Model:         model,
+		PromptVersion: promptVersion,
+		Usage:         tokenUsage,
+		Cost:          h.Prices.Cost(model, tokenUsage),
+		Date:          time.Now().Format(usage.DateFormat),
+	})
+	if err != nil {
+		log.Printf("error saving usage record: %s", err.Error())
+	}
+}
+
+// recordCoverLetterUsage records the usage of each provider call of a generated cover letter, e.g. the completion and
+// its continuations
+func (h *Handler) recordCoverLetterUsage(profileId uuid.UUID, operation string, coverLetter *types.GeneratedCoverLetter) {
+	calls := coverLetter.Calls
+	if len(calls) == 0 {
+		calls = []types.ProviderCall{{Model: coverLetter.Model, PromptVersion: coverLetter.PromptVersion, Usage: coverLetter.Usage}}
+	}
+	for _, call := range calls {
+		h.recordUsage(profileId, operation, call.Model, call.PromptVersion, call.Usage)
+	}
+}
+
+// recordFailedUsage records the usage of the provider calls made by a failed generation, when its error has any
+func (h *Handler) recordFailedUsage(profileId uuid.UUID, operation string, err error) {
+	var usageError *llm.UsageError
+	if !errors.As(err, &usageError) {
+		return
+	}
+	for _, call := range usageError.Calls {
+		h.recordUsage(profileId, operation, call.Model, call.PromptVersion, call.Usage)
+	}
+}
+
+// HandleGetUsage handles a GET method to retrieve the LLM usage of the profile per day, between the from and to
+// query dates, for the last 30 days by default
+func (h *Handler) HandleGetUsage(c *gin.Context) {
+	profileId, ok := contextProfileID(c)
+	if !ok {
+		return
+	}
+	from, to, ok := usagePeriod(c)
+	if !ok {
+		return
+	}
+
+	// Call store method to aggregate the usage records of the profile from MongoDB
+	dailyUsage, err := h.StoreClient.GetDailyUsage(&profileId, from, to)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, usage.NewReport(from, to, *dailyUsage, false), nil)
+}
+
+// HandleAdminUsage handles a GET method to retrieve the LLM usage of every profile per day, between the from and to
+// query dates, with the total of each profile
+func (h *Handler) HandleAdminUsage(c *gin.Context) {
+	from, to, ok := usagePeriod(c)
+	if !ok {
+		return
+	}
+
+	// Call store method to aggregate the usage records of every profile from MongoDB
+	dailyUsage, err := h.StoreClient.GetDailyUsage(nil, from, to)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, usage.NewReport(from, to, *dailyUsage, true), nil)
+}
+
+// usagePeriod returns the from and to dates of a usage report query, writing an error response when they are invalid
+func usagePeriod(c *gin.Context) (string, string, bool) {
+	// The dates are parsed without a time, so the period is a number of whole days
+	to, _ := time.Parse(usage.DateFormat, time.Now().Format(usage.DateFormat))
+	if value := c.Query("to"); value != "" {
+		date, err := time.Parse(usage.DateFormat, value)
+		if err != nil {
+			respondErrorDetails(c, http.StatusUnprocessableEntity, ErrorCodeValidationFailed, "request validation failed",
+				[]types.ValidationErrorDetail{{Field: "to", Rule: "datetime", Message: "must be a date formatted as " + usage.DateFormat}})
+			return "", "", false
+		}
+		to = date
+	}
+	from := to.AddDate(0, 0, 1-defaultUsageDays)
+	if value := c.Query("from"); value != "" {
+		date, err := time.Parse(usage.DateFormat, value)
+		if err != nil {
+			respondErrorDetails(c, http.StatusUnprocessableEntity, ErrorCodeValidationFailed, "request validation failed",
+				[]types.ValidationErrorDetail{{Field: "from", Rule: "datetime", Message: "must be a date formatted as " + usage.DateFormat}})
+			return "", "", false
+		}
+		from = date
+	}
+
+	if from.After(to) {
+		respondErrorDetails(c, http.StatusUnprocessableEntity, ErrorCodeValidationFailed, "request validation failed",
+			[]types.ValidationErrorDetail{{Field: "from", Rule: "ltefield", Message: "must be before the to date"}})
+		return "", "", false
+	}
+	if to.Sub(from) >= maxUsageDays*24*time.Hour {
+		respondErrorDetails(c, http.StatusUnprocessableEntity, ErrorCodeValidationFailed, "request validation failed",
+			[]types.ValidationErrorDetail{{Field: "from", Rule: "max", Message: "the period must be at most 366 days"}})
+		return "", "", false
+	}
+	return from.Format(usage.DateFormat), to.Format(usage.DateFormat), true
+}
+
+// requireAdminKey only allows requests with the ADMIN_API_KEY in the X-Admin-Key header,
+// and rejects every request when no admin key is configured
+func (h *Handler) requireAdminKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.AdminAPIKey == "" {
+			respondError(c, http.StatusForbidden, "the admin API is disabled")
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader(AdminKeyHeader)), []byte(h.AdminAPIKey)) != 1 {
+			respondError(c, http.StatusUnauthorized, "Unauthorized request")
+			return
+		}
+		c.Next()
+	}
+}
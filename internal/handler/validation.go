@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/jonada182/cover-letter-ai-api/internal/store"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+const ErrorCodeValidationFailed = "validation_failed"
+
+func init() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// Use the JSON field names in validation errors
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	validate.RegisterValidation("event_type", validateEventType)
+}
+
+// validateEventType checks that a field is one of the job application event types
+func validateEventType(fl validator.FieldLevel) bool {
+	switch fl.Field().Uint() {
+	case store.JobApplicationSubmission,
+		store.JobApplicationInterview,
+		store.JobApplicationAssessment,
+		store.JobApplicationOffer,
+		store.JobApplicationCompletion,
+		store.JobApplicationRejection:
+		return true
+	}
+	return false
+}
+
+// bindJSON binds and validates the request payload, writing an error response when it is invalid
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		details := make([]types.ValidationErrorDetail, 0, len(validationErrors))
+		for _, fieldError := range validationErrors {
+			details = append(details, validationErrorDetail(fieldError))
+		}
+		respondErrorDetails(c, http.StatusUnprocessableEntity, ErrorCodeValidationFailed, "request validation failed", details)
+		return false
+	}
+
+	respondError(c, http.StatusBadRequest, fmt.Sprintf("error retrieving JSON: %s", err.Error()))
+	return false
+}
+
+// validateModel checks that a requested model is one of the allowed models, writing an error response when it is not
+func (h *Handler) validateModel(c *gin.Context, model string) bool {
+	if model == "" || slices.Contains(h.AllowedModels, model) {
+		return true
+	}
+	message := "model selection is not enabled"
+	if len(h.AllowedModels) > 0 {
+		message = fmt.Sprintf("must be one of: %s", strings.Join(h.AllowedModels, " "))
+	}
+	respondErrorDetails(c, http.StatusUnprocessableEntity, ErrorCodeValidationFailed, "request validation failed", []types.ValidationErrorDetail{
+		{Field: "options.model", Rule: "oneof", Message: message},
+	})
+	return false
+}
+
+// validationErrorDetail describes a failing field using its JSON path (e.g. job_posting.company_name)
+func validationErrorDetail(fieldError validator.FieldError) types.ValidationErrorDetail {
+	field := fieldError.Namespace()
+	if index := strings.Index(field, "."); index >= 0 {
+		field = field[index+1:]
+	}
+	return types.ValidationErrorDetail{
+		Field:   field,
+		Rule:    fieldError.Tag(),
+		Message: validationMessage(fieldError),
+	}
+}
+
+// validationMessage returns a readable message for a failing validation rule
+func validationMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "bcp47_language_tag":
+		return "must be a valid BCP 47 language tag"
+	case "event_type":
+		return "must be a valid job application event type"
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fieldError.Param())
+	case "min", "max":
+		limit := "at least"
+		if fieldError.Tag() == "max" {
+			limit = "at most"
+		}
+		switch fieldError.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("must be %s %s characters long", limit, fieldError.Param())
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fmt.Sprintf("must contain %s %s items", limit, fieldError.Param())
+		default:
+			return fmt.Sprintf("must be %s %s", limit, fieldError.Param())
+		}
+	default:
+		return fmt.Sprintf("failed the %s rule", fieldError.Tag())
+	}
+}
@@ -0,0 +1,189 @@
+package jobposting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+var (
+	ErrUnsupportedURL   = errors.New("the job posting URL must be an http or https URL")
+	ErrBlockedAddress   = errors.New("the job posting URL resolves to a private or reserved address")
+	ErrNotHTML          = errors.New("the job posting URL did not return an HTML page")
+	ErrTooLarge         = errors.New("the job posting page exceeds the size limit")
+	ErrTooManyRedirects = errors.New("the job posting URL redirected too many times")
+)
+
+// FetchConfig holds the limits of the job posting page requests, read from env variables
+type FetchConfig struct {
+	Timeout      time.Duration
+	MaxBytes     int64
+	MaxRedirects int
+	// AllowPrivateNetworks allows loopback and private addresses, which are blocked to prevent requests to internal
+	// services (SSRF). It is only meant for tests and local development.
+	AllowPrivateNetworks bool
+}
+
+// FetchConfigFromEnv returns the fetch configuration from env variables, using defaults when not set
+func FetchConfigFromEnv() FetchConfig {
+	config := FetchConfig{
+		Timeout:      10 * time.Second,
+		MaxBytes:     2 << 20,
+		MaxRedirects: 5,
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("JOB_POSTING_FETCH_TIMEOUT")); err == nil && timeout > 0 {
+		config.Timeout = timeout
+	}
+	if maxBytes, err := strconv.ParseInt(os.Getenv("JOB_POSTING_FETCH_MAX_BYTES"), 10, 64); err == nil && maxBytes > 0 {
+		config.MaxBytes = maxBytes
+	}
+	return config
+}
+
+// Fetcher downloads job posting pages from user provided URLs
+type Fetcher struct {
+	client *http.Client
+	config FetchConfig
+}
+
+// NewFetcher returns a Fetcher that only connects to public addresses, checked after the DNS resolution of every
+// connection so redirects and DNS rebinding cannot reach internal services
+func NewFetcher(config FetchConfig) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: config.Timeout,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || (!config.AllowPrivateNetworks && isBlockedIP(ip)) {
+				return ErrBlockedAddress
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		// Requests are never sent through a proxy, which would connect to the blocked addresses on our behalf
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   config.Timeout,
+		ResponseHeaderTimeout: config.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	return &Fetcher{
+		client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: transport,
+			CheckRedirect: func(request *http.Request, via []*http.Request) error {
+				if len(via) > config.MaxRedirects {
+					return ErrTooManyRedirects
+				}
+				return checkURL(request.URL)
+			},
+		},
+		config: config,
+	}
+}
+
+// Fetch returns the HTML of a job posting page, decoded to UTF-8
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (string, error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", ErrUnsupportedURL
+	}
+	if err := checkURL(pageURL); err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Accept", "text/html,application/xhtml+xml")
+	request.Header.Set("User-Agent", "cover-letter-ai-api/1.0 (job posting import)")
+	response, err := f.client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return "", fmt.Errorf("the job posting URL returned status %d", response.StatusCode)
+	}
+	if response.ContentLength > f.config.MaxBytes {
+		return "", ErrTooLarge
+	}
+	contentType := response.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); contentType != "" && (err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml")) {
+		return "", ErrNotHTML
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, f.config.MaxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(body)) > f.config.MaxBytes {
+		return "", ErrTooLarge
+	}
+	// The charset is read from the Content-Type header or the meta tags of the page, defaulting to UTF-8
+	encoding, _, _ := charset.DetermineEncoding(body, contentType)
+	document, err := encoding.NewDecoder().Bytes(body)
+	if err != nil {
+		return "", err
+	}
+	return string(document), nil
+}
+
+// checkURL allows the absolute http and https URLs
+func checkURL(pageURL *url.URL) error {
+	if (pageURL.Scheme != "http" && pageURL.Scheme != "https") || pageURL.Hostname() == "" {
+		return ErrUnsupportedURL
+	}
+	return nil
+}
+
+// reservedNetworks are the special purpose networks not covered by the net.IP methods
+var reservedNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",     // "this" network
+		"100.64.0.0/10", // carrier-grade NAT
+		"192.0.0.0/24",  // IETF protocol assignments
+		"198.18.0.0/15", // benchmarking
+		"240.0.0.0/4",   // reserved, and the broadcast address
+		"64:ff9b::/96",  // NAT64, which embeds IPv4 addresses
+		"2001::/32",     // Teredo, which embeds IPv4 addresses
+		"2002::/16",     // 6to4, which embeds IPv4 addresses
+		"2001:db8::/32", // documentation
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// isBlockedIP reports whether an address is not a public unicast address
+func isBlockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
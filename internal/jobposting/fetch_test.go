@@ -0,0 +1,157 @@
+package jobposting
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFixtureServer serves the HTML fixtures of testdata, and the pages used to test the fetch limits
+func newFixtureServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/jobs/", http.StripPrefix("/jobs/", http.FileServer(http.Dir("testdata"))))
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/jobs/json_ld.html", http.StatusFound)
+	})
+	mux.HandleFunc("/redirect/ftp", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "ftp://example.com/job.html", http.StatusFound)
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<p>" + strings.Repeat("a", 8192) + "</p>"))
+	})
+	mux.HandleFunc("/pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = w.Write([]byte("%PDF-1.4"))
+	})
+	mux.HandleFunc("/latin1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		_, _ = w.Write([]byte("<h1>Ingeni\xe9ro</h1>"))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetcher(t *testing.T) {
+	server := newFixtureServer(t)
+	fetcher := NewFetcher(FetchConfig{Timeout: 100 * time.Millisecond, MaxBytes: 4096, MaxRedirects: 2, AllowPrivateNetworks: true})
+	ctx := context.Background()
+
+	t.Run("fetches a page following redirects", func(t *testing.T) {
+		document, err := fetcher.Fetch(ctx, server.URL+"/redirect")
+		assert.NoError(t, err)
+		assert.Contains(t, document, `"@type": "JobPosting"`)
+	})
+
+	t.Run("decodes the charset", func(t *testing.T) {
+		document, err := fetcher.Fetch(ctx, server.URL+"/latin1")
+		assert.NoError(t, err)
+		assert.Equal(t, "<h1>Ingeniéro</h1>", document)
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		_, err := fetcher.Fetch(ctx, server.URL+"/large")
+		assert.ErrorIs(t, err, ErrTooLarge)
+		_, err = fetcher.Fetch(ctx, server.URL+"/pdf")
+		assert.ErrorIs(t, err, ErrNotHTML)
+		_, err = fetcher.Fetch(ctx, server.URL+"/jobs/missing.html")
+		assert.EqualError(t, err, "the job posting URL returned status 404")
+
+		_, err = fetcher.Fetch(ctx, server.URL+"/slow")
+		var netError net.Error
+		assert.True(t, errors.As(err, &netError) && netError.Timeout())
+	})
+
+	t.Run("only http and https URLs", func(t *testing.T) {
+		_, err := fetcher.Fetch(ctx, "file:///etc/passwd")
+		assert.ErrorIs(t, err, ErrUnsupportedURL)
+		_, err = fetcher.Fetch(ctx, "/jobs/json_ld.html")
+		assert.ErrorIs(t, err, ErrUnsupportedURL)
+		_, err = fetcher.Fetch(ctx, server.URL+"/redirect/ftp")
+		assert.ErrorIs(t, err, ErrUnsupportedURL)
+	})
+
+	t.Run("blocks private addresses", func(t *testing.T) {
+		fetcher := NewFetcher(FetchConfig{Timeout: time.Second, MaxBytes: 1024, MaxRedirects: 2})
+
+		_, err := fetcher.Fetch(ctx, server.URL+"/jobs/json_ld.html")
+		assert.ErrorIs(t, err, ErrBlockedAddress)
+		_, err = fetcher.Fetch(ctx, "http://localhost:"+strconv.Itoa(server.Listener.Addr().(*net.TCPAddr).Port)+"/jobs/json_ld.html")
+		assert.ErrorIs(t, err, ErrBlockedAddress)
+	})
+}
+
+func TestIsBlockedIP(t *testing.T) {
+	for address, blocked := range map[string]bool{
+		"127.0.0.1":        true,
+		"10.0.0.8":         true,
+		"172.16.3.4":       true,
+		"192.168.1.1":      true,
+		"169.254.169.254":  true,
+		"100.64.0.1":       true,
+		"0.0.0.0":          true,
+		"::1":              true,
+		"fd00::1":          true,
+		"fe80::1":          true,
+		"::ffff:127.0.0.1": true,
+		"64:ff9b::a00:1":   true,
+		"2002:7f00:1::1":   true,
+		"2002:a00:1::1":    true,
+		"2001::f5ff:fffe":  true,
+		"8.8.8.8":          false,
+		"2606:4700::1111":  false,
+	} {
+		assert.Equal(t, blocked, isBlockedIP(net.ParseIP(address)), address)
+	}
+}
+
+func TestFromPage(t *testing.T) {
+	t.Run("schema.org JobPosting JSON-LD", func(t *testing.T) {
+		document, err := os.ReadFile("testdata/json_ld.html")
+		assert.NoError(t, err)
+
+		jobPosting, source, err := FromPage(string(document))
+		assert.NoError(t, err)
+		assert.Equal(t, SourceJSONLD, source)
+		assert.Equal(t, types.JobPosting{
+			CompanyName: "Acme",
+			JobRole:     "Senior Backend Engineer",
+			Details:     "Build the payments platform.\n- Design Go services\n- Mentor engineers\n\nQualifications:\n5+ years of backend experience",
+			Skills:      "Go, PostgreSQL, Kubernetes",
+		}, jobPosting)
+	})
+
+	t.Run("main content of the page", func(t *testing.T) {
+		document, err := os.ReadFile("testdata/html.html")
+		assert.NoError(t, err)
+
+		jobPosting, source, err := FromPage(string(document))
+		assert.NoError(t, err)
+		assert.Equal(t, SourceHTML, source)
+		assert.Equal(t, types.JobPosting{
+			CompanyName: "Globex",
+			JobRole:     "Data Analyst",
+			Details:     "Data Analyst\nJoin the analytics team of Globex in Lisbon.\nRequirements\n- SQL\n- Python\nNice to have\n- dbt\nBenefits\n- Remote days",
+			Skills:      "SQL, Python, dbt",
+		}, jobPosting)
+	})
+
+	t.Run("page without text", func(t *testing.T) {
+		_, _, err := FromPage("<html><head><script>track()</script></head><body></body></html>")
+		assert.ErrorIs(t, err, ErrNoContent)
+	})
+}
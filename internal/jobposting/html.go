@@ -0,0 +1,92 @@
+package jobposting
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlTag matches the opening or closing tags of the common HTML elements of a job posting page
+var htmlTag = regexp.MustCompile(`(?i)<\s*/?\s*(html|body|div|p|br|ul|ol|li|h[1-6]|span|strong|b|em|section|article|table|tr|td)\b[^>]*>`)
+
+// IsHTML reports whether the text is an HTML document or fragment rather than plain text
+func IsHTML(text string) bool {
+	return htmlTag.MatchString(text)
+}
+
+// skippedElements are the elements without visible text
+var skippedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Head:     true,
+	atom.Template: true,
+	atom.Svg:      true,
+}
+
+// blockElements are the elements that start a new line of text
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true, atom.Br: true,
+	atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Footer: true, atom.Form: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Li: true, atom.Main: true, atom.Nav: true, atom.Ol: true,
+	atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true, atom.Tr: true, atom.Ul: true,
+}
+
+// HTMLText returns the visible text of an HTML document, with a line for each block element and list items
+// starting with "- ", so the text keeps the headings and bullet points of the posting
+func HTMLText(document string) (string, error) {
+	root, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		return "", err
+	}
+	return nodeText(root), nil
+}
+
+// nodeText returns the visible text of an HTML node, as returned by HTMLText
+func nodeText(node *html.Node) string {
+	var text strings.Builder
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			if skippedElements[node.DataAtom] {
+				return
+			}
+			if blockElements[node.DataAtom] {
+				text.WriteString("\n")
+			}
+			if node.DataAtom == atom.Li {
+				text.WriteString("- ")
+			}
+		}
+		if node.Type == html.TextNode {
+			// The whitespace around the text separates it from the inline elements next to it
+			if strings.TrimLeftFunc(node.Data, unicode.IsSpace) != node.Data {
+				text.WriteString(" ")
+			}
+			text.WriteString(strings.Join(strings.Fields(node.Data), " "))
+			if strings.TrimRightFunc(node.Data, unicode.IsSpace) != node.Data {
+				text.WriteString(" ")
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if node.Type == html.ElementNode && blockElements[node.DataAtom] {
+			text.WriteString("\n")
+		}
+	}
+	walk(node)
+
+	var lines []string
+	for _, line := range strings.Split(text.String(), "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" && line != "-" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,332 @@
+// Package jobposting extracts the structured fields of a job posting text, as a fallback for the LLM parser,
+// imports job postings from their page URL, and builds the job posting used to prefill cover letters and job applications
+package jobposting
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// Limits of the prefilled job posting, from the validation of the cover letter and job application requests
+const (
+	MaxDetailsLength = 20000
+	MaxSkillsLength  = 2000
+	MaxFieldLength   = 200
+)
+
+// Seniority levels, from the least to the most senior
+var SeniorityLevels = []string{"intern", "junior", "mid", "senior", "lead", "principal", "director", "executive"}
+
+// SalaryPeriods are the pay periods of a salary range
+var SalaryPeriods = []string{"hour", "day", "week", "month", "year"}
+
+// section is a part of the posting listing responsibilities or skills
+type section int
+
+const (
+	otherSection section = iota
+	responsibilitiesSection
+	requiredSection
+	niceToHaveSection
+)
+
+// sectionHeadings are the words of the headings of each section. The nice to have headings are matched first,
+// since they often mention the qualifications too (e.g. "Preferred qualifications")
+var sectionHeadings = []struct {
+	section section
+	words   []string
+}{
+	{niceToHaveSection, []string{"nice to have", "nice-to-have", "bonus", "preferred", "plus if", "desirable", "good to have"}},
+	{responsibilitiesSection, []string{"responsibilit", "what you'll do", "what you will do", "what you’ll do", "your role", "the role", "duties", "day to day", "day-to-day", "your mission"}},
+	{requiredSection, []string{"requirement", "qualification", "must have", "must-have", "what you'll bring", "what you will bring", "what you’ll bring", "what we're looking for", "what we are looking for", "about you", "you have", "skills"}},
+}
+
+// labeledField matches the "Label: value" lines of the posting
+var labeledField = regexp.MustCompile(`^(?i)(company|company name|employer|organization|job title|title|role|position|location|based in|salary|compensation|pay|seniority|level)\s*:\s*(.+)$`)
+
+// bullet matches the list markers at the start of a line
+var bullet = regexp.MustCompile(`^\s*(?:[-*•·◦▪–]|\d{1,2}[.)])\s+`)
+
+// Extract returns the fields found in the text of a job posting with headings, labels and patterns, without the LLM.
+// The fields that are not found are left empty.
+func Extract(text string) types.ParsedJobPosting {
+	var parsed types.ParsedJobPosting
+	current := otherSection
+	firstLine := ""
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if firstLine == "" {
+			firstLine = line
+		}
+
+		if match := labeledField.FindStringSubmatch(line); match != nil {
+			value := strings.TrimSpace(match[2])
+			switch strings.ToLower(match[1]) {
+			case "company", "company name", "employer", "organization":
+				setIfEmpty(&parsed.CompanyName, value)
+			case "job title", "title", "role", "position":
+				setIfEmpty(&parsed.JobRole, value)
+			case "location", "based in":
+				setIfEmpty(&parsed.Location, value)
+			case "salary", "compensation", "pay":
+				if parsed.Salary == nil {
+					parsed.Salary = extractSalary(value)
+				}
+			case "seniority", "level":
+				setIfEmpty(&parsed.Seniority, seniority(value))
+			}
+			continue
+		}
+
+		item := bullet.ReplaceAllString(line, "")
+		if item == line && isHeading(line) {
+			current = headingSection(line)
+			continue
+		}
+		item = strings.TrimRight(item, " .;,")
+		switch current {
+		case responsibilitiesSection:
+			parsed.Responsibilities = append(parsed.Responsibilities, item)
+		case requiredSection:
+			parsed.RequiredSkills = append(parsed.RequiredSkills, item)
+		case niceToHaveSection:
+			parsed.NiceToHaveSkills = append(parsed.NiceToHaveSkills, item)
+		}
+	}
+
+	// Postings usually start with the title, often followed by the company (e.g. "Backend Engineer at Acme")
+	if parsed.JobRole == "" && firstLine != "" && utf8.RuneCountInString(firstLine) <= 100 && !isHeading(firstLine) && !labeledField.MatchString(firstLine) {
+		role, company, found := cutAny(firstLine, " at ", " @ ", " | ")
+		parsed.JobRole = strings.TrimSpace(role)
+		if found {
+			setIfEmpty(&parsed.CompanyName, strings.TrimSpace(company))
+		}
+	}
+	if parsed.Location == "" && remote.MatchString(text) {
+		parsed.Location = "Remote"
+	}
+	if parsed.Seniority == "" {
+		parsed.Seniority = seniority(parsed.JobRole)
+	}
+	if parsed.Seniority == "" {
+		parsed.Seniority = seniorityFromExperience(text)
+	}
+	if parsed.Salary == nil {
+		parsed.Salary = extractSalary(text)
+	}
+	return parsed
+}
+
+// otherHeadings matches the headings of the parts of the posting that end the responsibilities and skills sections
+var otherHeadings = regexp.MustCompile(`(?i)^(about|benefits|perks|what we offer|who we are|why join|why you|how to apply|compensation|salary|our |equal opportunity)`)
+
+// isHeading reports whether a line is a heading rather than a list item: a short line ending with a colon,
+// or a short line without final punctuation naming a known part of the posting
+func isHeading(line string) bool {
+	if utf8.RuneCountInString(line) > 60 {
+		return false
+	}
+	if strings.HasSuffix(line, ":") {
+		return true
+	}
+	if strings.ContainsAny(line[len(line)-1:], ".;,!?") || len(strings.Fields(line)) > 6 {
+		return false
+	}
+	return headingSection(line) != otherSection || otherHeadings.MatchString(line)
+}
+
+// headingSection returns the section of a heading, or otherSection for the headings of other parts of the posting
+func headingSection(heading string) section {
+	heading = strings.ToLower(heading)
+	for _, headings := range sectionHeadings {
+		for _, word := range headings.words {
+			if strings.Contains(heading, word) {
+				return headings.section
+			}
+		}
+	}
+	return otherSection
+}
+
+// cutAny slices the text around the first of the separators found
+func cutAny(text string, separators ...string) (before string, after string, found bool) {
+	for _, separator := range separators {
+		if before, after, found := strings.Cut(text, separator); found {
+			return before, after, true
+		}
+	}
+	return text, "", false
+}
+
+func setIfEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+var remote = regexp.MustCompile(`(?i)\bremote\b`)
+
+// seniorityWords are the words of the job titles for each seniority level, matched in order
+var seniorityWords = []struct {
+	seniority string
+	words     *regexp.Regexp
+}{
+	{"intern", regexp.MustCompile(`(?i)\b(intern|internship|trainee)\b`)},
+	{"executive", regexp.MustCompile(`(?i)\b(chief|cto|ceo|cfo|coo|vp|vice president)\b`)},
+	{"director", regexp.MustCompile(`(?i)\b(director|head of)\b`)},
+	{"principal", regexp.MustCompile(`(?i)\b(principal|distinguished)\b`)},
+	{"lead", regexp.MustCompile(`(?i)\b(lead|staff)\b`)},
+	{"senior", regexp.MustCompile(`(?i)\b(senior|sr\.?|iii)\b`)},
+	{"junior", regexp.MustCompile(`(?i)\b(junior|jr\.?|entry[- ]level|graduate|associate)\b`)},
+	{"mid", regexp.MustCompile(`(?i)\b(mid|mid[- ]level|intermediate|ii)\b`)},
+}
+
+// seniority returns the seniority level of a job title, or an empty string when it does not mention it
+func seniority(title string) string {
+	for _, level := range SeniorityLevels {
+		if strings.EqualFold(strings.TrimSpace(title), level) {
+			return level
+		}
+	}
+	for _, level := range seniorityWords {
+		if level.words.MatchString(title) {
+			return level.seniority
+		}
+	}
+	return ""
+}
+
+var yearsOfExperience = regexp.MustCompile(`(?i)\b(\d{1,2})\+?\s*(?:-\s*\d{1,2}\s*)?\+?\s*years?`)
+
+// seniorityFromExperience returns the seniority level of the years of experience required by the posting
+func seniorityFromExperience(text string) string {
+	match := yearsOfExperience.FindStringSubmatch(text)
+	if match == nil {
+		return ""
+	}
+	years, _ := strconv.Atoi(match[1])
+	switch {
+	case years < 2:
+		return "junior"
+	case years < 5:
+		return "mid"
+	default:
+		return "senior"
+	}
+}
+
+// salary matches a salary amount or range, with the currency before or after the amounts and an optional period
+// (e.g. "$120,000 - $150,000 per year", "€50k–60k", "USD 45/hour")
+var salary = regexp.MustCompile(`(?i)(?:([$€£]|\b(?:usd|eur|gbp|cad|aud|chf)\b)\s?)(\d[\d,.]*)\s*(k)?(?:\s*(?:-|–|—|to)\s*(?:[$€£]|(?:usd|eur|gbp|cad|aud|chf)\b)?\s?(\d[\d,.]*)\s*(k)?)?\s*(?:\b(?:usd|eur|gbp|cad|aud|chf)\b)?(?:\s*(?:/|per|an|a)\s*(hour|hr|day|week|month|year|yr|annum)\b)?`)
+
+var currencySymbols = map[string]string{"$": "USD", "€": "EUR", "£": "GBP"}
+
+// extractSalary returns the first salary range of the text with a range or a period, which tells it apart
+// from other amounts of the posting (e.g. funding), or nil when there is none
+func extractSalary(text string) *types.SalaryRange {
+	for _, match := range salary.FindAllStringSubmatch(text, -1) {
+		if match[4] == "" && match[6] == "" {
+			continue
+		}
+		minimum := salaryAmount(match[2], match[3] != "" || (match[5] != "" && !strings.Contains(match[2], ",")))
+		maximum := minimum
+		if match[4] != "" {
+			maximum = salaryAmount(match[4], match[5] != "")
+		}
+		if minimum <= 0 || maximum < minimum {
+			continue
+		}
+
+		currency := strings.ToUpper(match[1])
+		if symbol, ok := currencySymbols[match[1]]; ok {
+			currency = symbol
+		}
+		period := strings.ToLower(match[6])
+		switch period {
+		case "hr":
+			period = "hour"
+		case "yr", "annum":
+			period = "year"
+		case "":
+			// Without a period, the ranges of thousands are yearly salaries
+			if minimum >= 10000 {
+				period = "year"
+			}
+		}
+		return &types.SalaryRange{Min: minimum, Max: maximum, Currency: currency, Period: period}
+	}
+	return nil
+}
+
+// salaryAmount parses an amount with thousands separators, in thousands when inThousands is set
+func salaryAmount(amount string, inThousands bool) float64 {
+	amount = strings.TrimRight(strings.ReplaceAll(amount, ",", ""), ".")
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return 0
+	}
+	if inThousands && value < 1000 {
+		value *= 1000
+	}
+	return value
+}
+
+// Merge fills the empty fields of the LLM parsed job posting with the fields of the fallback extractor
+func Merge(parsed types.ParsedJobPosting, fallback types.ParsedJobPosting) types.ParsedJobPosting {
+	setIfEmpty(&parsed.CompanyName, fallback.CompanyName)
+	setIfEmpty(&parsed.JobRole, fallback.JobRole)
+	setIfEmpty(&parsed.Location, fallback.Location)
+	setIfEmpty(&parsed.Seniority, fallback.Seniority)
+	if parsed.Salary == nil {
+		parsed.Salary = fallback.Salary
+	}
+	if len(parsed.RequiredSkills) == 0 {
+		parsed.RequiredSkills = fallback.RequiredSkills
+	}
+	if len(parsed.NiceToHaveSkills) == 0 {
+		parsed.NiceToHaveSkills = fallback.NiceToHaveSkills
+	}
+	if len(parsed.Responsibilities) == 0 {
+		parsed.Responsibilities = fallback.Responsibilities
+	}
+	return parsed
+}
+
+// Prefill sets the job posting of the parsed fields, with the posting text as the details, truncated to
+// the limits of the cover letter and job application requests. The lists are never nil.
+func Prefill(parsed types.ParsedJobPosting, text string) types.ParsedJobPosting {
+	if parsed.RequiredSkills == nil {
+		parsed.RequiredSkills = []string{}
+	}
+	if parsed.NiceToHaveSkills == nil {
+		parsed.NiceToHaveSkills = []string{}
+	}
+	if parsed.Responsibilities == nil {
+		parsed.Responsibilities = []string{}
+	}
+	if parsed.Seniority != "" {
+		parsed.Seniority = seniority(parsed.Seniority)
+	}
+	parsed.JobPosting = types.JobPosting{
+		CompanyName: truncate(parsed.CompanyName, MaxFieldLength),
+		JobRole:     truncate(parsed.JobRole, MaxFieldLength),
+		Details:     truncate(strings.TrimSpace(text), MaxDetailsLength),
+		Skills:      truncate(strings.Join(append(append([]string{}, parsed.RequiredSkills...), parsed.NiceToHaveSkills...), ", "), MaxSkillsLength),
+	}
+	return parsed
+}
+
+// truncate returns the first maxLength characters of the text, as validated by the max binding
+func truncate(text string, maxLength int) string {
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+	return strings.TrimSpace(string([]rune(text)[:maxLength]))
+}
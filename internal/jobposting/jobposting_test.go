@@ -0,0 +1,104 @@
+package jobposting
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const postingText = `Senior Backend Engineer at Acme
+Location: Berlin, Germany (hybrid)
+Salary: €70k - €90k per year
+
+About us
+We build logistics software for 2,000 companies and raised $20M last year.
+
+What you'll do:
+- Design and build Go services.
+- Own the reliability of the payments APIs
+
+Requirements:
+• 5+ years of experience with Go
+• PostgreSQL
+• Kubernetes
+
+Nice to have:
+* gRPC
+* Terraform
+
+Benefits
+- 30 days of vacation`
+
+func TestExtract(t *testing.T) {
+	t.Run("fields of a plain text posting", func(t *testing.T) {
+		parsed := Extract(postingText)
+
+		assert.Equal(t, "Acme", parsed.CompanyName)
+		assert.Equal(t, "Senior Backend Engineer", parsed.JobRole)
+		assert.Equal(t, "Berlin, Germany (hybrid)", parsed.Location)
+		assert.Equal(t, "senior", parsed.Seniority)
+		assert.Equal(t, &types.SalaryRange{Min: 70000, Max: 90000, Currency: "EUR", Period: "year"}, parsed.Salary)
+		assert.Equal(t, []string{"Design and build Go services", "Own the reliability of the payments APIs"}, parsed.Responsibilities)
+		assert.Equal(t, []string{"5+ years of experience with Go", "PostgreSQL", "Kubernetes"}, parsed.RequiredSkills)
+		assert.Equal(t, []string{"gRPC", "Terraform"}, parsed.NiceToHaveSkills)
+	})
+
+	t.Run("fields of a posting without labels", func(t *testing.T) {
+		parsed := Extract("We are hiring a Data Analyst to join our remote team.\nYou need 3 years of SQL. The pay is $40 - $55 per hour.")
+
+		assert.Equal(t, "", parsed.CompanyName)
+		assert.Equal(t, "Remote", parsed.Location)
+		assert.Equal(t, "mid", parsed.Seniority)
+		assert.Equal(t, &types.SalaryRange{Min: 40, Max: 55, Currency: "USD", Period: "hour"}, parsed.Salary)
+		assert.Empty(t, parsed.RequiredSkills)
+	})
+}
+
+func TestExtractSalary(t *testing.T) {
+	for text, expected := range map[string]*types.SalaryRange{
+		"$120,000 - $150,000":        {Min: 120000, Max: 150000, Currency: "USD", Period: "year"},
+		"£50k to 60k a year":         {Min: 50000, Max: 60000, Currency: "GBP", Period: "year"},
+		"USD 45/hr":                  {Min: 45, Max: 45, Currency: "USD", Period: "hour"},
+		"CAD 90-110k":                {Min: 90000, Max: 110000, Currency: "CAD", Period: "year"},
+		"We raised $20M in funding.": nil,
+	} {
+		assert.Equal(t, expected, extractSalary(text), text)
+	}
+}
+
+func TestHTMLText(t *testing.T) {
+	document := `<html><head><title>Job</title><style>p { color: red }</style></head><body>
+<h1>Backend Engineer</h1><script>track()</script>
+<p>Join <b>Acme</b> and build
+   great things.</p>
+<h2>Requirements</h2><ul><li>Go</li><li>SQL</li></ul>
+</body></html>`
+
+	assert.True(t, IsHTML(document))
+	assert.False(t, IsHTML("Requirements: 3 years of C++ and x < y > z"))
+
+	text, err := HTMLText(document)
+	assert.NoError(t, err)
+	assert.Equal(t, "Backend Engineer\nJoin Acme and build great things.\nRequirements\n- Go\n- SQL", text)
+}
+
+func TestPrefill(t *testing.T) {
+	parsed := Merge(types.ParsedJobPosting{JobRole: "Backend Engineer", Seniority: "Senior", RequiredSkills: []string{"Go", "SQL"}}, Extract(postingText))
+	parsed = Prefill(parsed, strings.Repeat("a", MaxDetailsLength+10))
+
+	assert.Equal(t, "Backend Engineer", parsed.JobRole)
+	assert.Equal(t, "senior", parsed.Seniority)
+	assert.Equal(t, []string{"Go", "SQL"}, parsed.RequiredSkills)
+	assert.Equal(t, []string{"gRPC", "Terraform"}, parsed.NiceToHaveSkills)
+	assert.Equal(t, types.JobPosting{
+		CompanyName: "Acme",
+		JobRole:     "Backend Engineer",
+		Details:     strings.Repeat("a", MaxDetailsLength),
+		Skills:      "Go, SQL, gRPC, Terraform",
+	}, parsed.JobPosting)
+
+	parsed = Prefill(types.ParsedJobPosting{}, "")
+	assert.Equal(t, []string{}, parsed.Responsibilities)
+}
@@ -0,0 +1,236 @@
+package jobposting
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Sources of the imported job postings
+const (
+	// SourceJSONLD is a page with a schema.org JobPosting in its JSON-LD, completed with the main content
+	SourceJSONLD = "json_ld"
+	// SourceHTML is a page without JSON-LD, extracted from its title and main content
+	SourceHTML = "html"
+)
+
+var ErrNoContent = errors.New("the job posting page has no text")
+
+// page is the content of a job posting page used to extract the job posting
+type page struct {
+	title    string
+	ogTitle  string
+	siteName string
+	heading  string
+	main     *html.Node
+	body     *html.Node
+	jsonLD   []string
+}
+
+// FromPage returns the job posting of an HTML page, and its source: the schema.org JobPosting JSON-LD of the page
+// when there is one, with the fields it lacks extracted from the main content of the page
+func FromPage(document string) (types.JobPosting, string, error) {
+	root, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		return types.JobPosting{}, "", err
+	}
+	content := readPage(root)
+
+	mainNode := content.main
+	if mainNode == nil {
+		mainNode = content.body
+	}
+	if mainNode == nil {
+		mainNode = root
+	}
+	mainText := nodeText(mainNode)
+	extracted := Extract(mainText)
+
+	jobPosting := types.JobPosting{
+		CompanyName: content.siteName,
+		JobRole:     firstNonEmpty(content.heading, content.ogTitle, extracted.JobRole, content.title),
+		Details:     mainText,
+		Skills:      strings.Join(append(append([]string{}, extracted.RequiredSkills...), extracted.NiceToHaveSkills...), ", "),
+	}
+	setIfEmpty(&jobPosting.CompanyName, extracted.CompanyName)
+	source := SourceHTML
+
+	for _, data := range content.jsonLD {
+		posting := findJobPosting(data)
+		if posting == nil {
+			continue
+		}
+		source = SourceJSONLD
+		if title := jsonText(posting["title"]); title != "" {
+			jobPosting.JobRole = title
+		}
+		if company := jsonText(posting["hiringOrganization"]); company != "" {
+			jobPosting.CompanyName = company
+		}
+		if details := jsonLDDetails(posting); details != "" {
+			jobPosting.Details = details
+		}
+		if skills := jsonText(posting["skills"]); skills != "" {
+			jobPosting.Skills = skills
+		}
+		break
+	}
+
+	if strings.TrimSpace(jobPosting.Details) == "" && jobPosting.JobRole == "" {
+		return types.JobPosting{}, "", ErrNoContent
+	}
+	jobPosting.CompanyName = truncate(jobPosting.CompanyName, MaxFieldLength)
+	jobPosting.JobRole = truncate(jobPosting.JobRole, MaxFieldLength)
+	jobPosting.Details = truncate(jobPosting.Details, MaxDetailsLength)
+	jobPosting.Skills = truncate(jobPosting.Skills, MaxSkillsLength)
+	return jobPosting, source, nil
+}
+
+// readPage collects the title, meta tags, first heading, main content and JSON-LD scripts of a page
+func readPage(root *html.Node) page {
+	var content page
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			switch node.DataAtom {
+			case atom.Title:
+				if content.title == "" {
+					content.title = nodeText(node)
+				}
+			case atom.Meta:
+				switch attribute(node, "property") {
+				case "og:title":
+					content.ogTitle = strings.TrimSpace(attribute(node, "content"))
+				case "og:site_name":
+					content.siteName = strings.TrimSpace(attribute(node, "content"))
+				}
+			case atom.H1:
+				if content.heading == "" {
+					content.heading = nodeText(node)
+				}
+			case atom.Body:
+				content.body = node
+			case atom.Main, atom.Article:
+				if content.main == nil {
+					content.main = node
+				}
+			case atom.Script:
+				if strings.EqualFold(strings.TrimSpace(attribute(node, "type")), "application/ld+json") && node.FirstChild != nil {
+					content.jsonLD = append(content.jsonLD, node.FirstChild.Data)
+				}
+				return
+			}
+			if content.main == nil && attribute(node, "role") == "main" {
+				content.main = node
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	return content
+}
+
+func attribute(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// findJobPosting returns the first object of a JSON-LD script with the JobPosting type, looking into arrays and @graph
+func findJobPosting(data string) map[string]interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return nil
+	}
+	var find func(value interface{}) map[string]interface{}
+	find = func(value interface{}) map[string]interface{} {
+		switch value := value.(type) {
+		case []interface{}:
+			for _, item := range value {
+				if posting := find(item); posting != nil {
+					return posting
+				}
+			}
+		case map[string]interface{}:
+			if isJobPostingType(value["@type"]) {
+				return value
+			}
+			return find(value["@graph"])
+		}
+		return nil
+	}
+	return find(value)
+}
+
+// isJobPostingType reports whether a JSON-LD @type, a string or an array of strings, is JobPosting
+func isJobPostingType(value interface{}) bool {
+	switch value := value.(type) {
+	case string:
+		return value == "JobPosting" || value == "http://schema.org/JobPosting" || value == "https://schema.org/JobPosting"
+	case []interface{}:
+		for _, item := range value {
+			if isJobPostingType(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonText returns the text of a JSON-LD property: a string, which can be HTML, the name of an object,
+// or the texts of an array joined with commas
+func jsonText(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		if IsHTML(value) {
+			if text, err := HTMLText(value); err == nil {
+				return text
+			}
+		}
+		return strings.TrimSpace(html.UnescapeString(value))
+	case map[string]interface{}:
+		return jsonText(value["name"])
+	case []interface{}:
+		var texts []string
+		for _, item := range value {
+			if text := jsonText(item); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		return strings.Join(texts, ", ")
+	}
+	return ""
+}
+
+// jsonLDDetails returns the description of a JSON-LD JobPosting, followed by its responsibilities and qualifications
+// when they are separate properties
+func jsonLDDetails(posting map[string]interface{}) string {
+	details := []string{jsonText(posting["description"])}
+	for _, property := range []struct {
+		name  string
+		title string
+	}{{"responsibilities", "Responsibilities"}, {"qualifications", "Qualifications"}, {"experienceRequirements", "Experience"}} {
+		if text := jsonText(posting[property.name]); text != "" && !strings.Contains(details[0], text) {
+			details = append(details, property.title+":\n"+text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(details, "\n\n"))
+}
@@ -0,0 +1,275 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+var AnthropicMessagesURL = "https://api.anthropic.com/v1/messages"
+var AnthropicVersion = "2023-06-01"
+var Claude3Haiku = "claude-3-haiku-20240307"
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float32            `json:"temperature"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+type anthropicContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	Model      string             `json:"model"`
+	Content    []anthropicContent `json:"content"`
+	StopReason string             `json:"stop_reason"`
+	Usage      anthropicUsage     `json:"usage"`
+}
+
+type anthropicStreamEvent struct {
+	Type    string            `json:"type"`
+	Message anthropicResponse `json:"message"`
+	Delta   struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage anthropicUsage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// AnthropicProvider implements Provider for the Anthropic messages API
+type AnthropicProvider struct {
+	url          string
+	apiKey       string
+	defaultModel string
+	client       *http.Client
+}
+
+// NewAnthropicProvider returns a Provider for the Anthropic messages API
+func NewAnthropicProvider(messagesURL string, apiKey string, defaultModel string) *AnthropicProvider {
+	return &AnthropicProvider{
+		url:          messagesURL,
+		apiKey:       apiKey,
+		defaultModel: defaultModel,
+		client:       &http.Client{},
+	}
+}
+
+// Name returns the name of the provider
+func (p *AnthropicProvider) Name() string {
+	return ProviderAnthropic
+}
+
+// DefaultModel returns the model used when the request does not set one
+func (p *AnthropicProvider) DefaultModel() string {
+	return p.defaultModel
+}
+
+// CountTokens returns the estimated number of tokens of the messages, with a safety margin since the Anthropic
+// tokenizer is not available
+func (p *AnthropicProvider) CountTokens(model string, messages []types.ChatGTPRequestMessage) int {
+	return countEstimatedTokens(messages)
+}
+
+// ChatCompletion sends the messages to the messages API and returns the generated text
+func (p *AnthropicProvider) ChatCompletion(ctx context.Context, request Request) (*Response, error) {
+	resp, err := p.send(ctx, request, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseData anthropicResponse
+	if err := json.Unmarshal(responseBody, &responseData); err != nil {
+		return nil, err
+	}
+
+	var content strings.Builder
+	if request.JSON {
+		content.WriteString(anthropicJSONPrefill)
+	}
+	for _, block := range responseData.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+
+	return &Response{
+		Model:        responseData.Model,
+		Content:      content.String(),
+		FinishReason: anthropicFinishReason(responseData.StopReason),
+		Usage:        anthropicTokenUsage(responseData.Usage.InputTokens, responseData.Usage.OutputTokens),
+	}, nil
+}
+
+// StreamChatCompletion sends the messages to the messages API with stream enabled, relaying the text deltas to onDelta,
+// and returns ErrStreamTruncated when it ends without the message_stop event
+func (p *AnthropicProvider) StreamChatCompletion(ctx context.Context, request Request, onDelta StreamHandler) (*Response, error) {
+	resp, err := p.send(ctx, request, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := &Response{}
+	var content strings.Builder
+	var inputTokens, outputTokens int
+	stopped := false
+	err = readServerSentEvents(resp.Body, func(_ string, data string) error {
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return err
+		}
+		switch event.Type {
+		case "message_start":
+			response.Model = event.Message.Model
+			inputTokens = event.Message.Usage.InputTokens
+			if request.JSON {
+				content.WriteString(anthropicJSONPrefill)
+				return onDelta(anthropicJSONPrefill)
+			}
+		case "content_block_delta":
+			if event.Delta.Text != "" {
+				content.WriteString(event.Delta.Text)
+				return onDelta(event.Delta.Text)
+			}
+		case "message_delta":
+			if event.Delta.StopReason != "" {
+				response.FinishReason = anthropicFinishReason(event.Delta.StopReason)
+			}
+			outputTokens = event.Usage.OutputTokens
+		case "message_stop":
+			stopped = true
+		case "error":
+			return &APIError{Provider: ProviderAnthropic, StatusCode: anthropicErrorStatus(event.Error.Type), Type: event.Error.Type, Message: event.Error.Message}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !stopped {
+		return nil, ErrStreamTruncated
+	}
+
+	response.Content = content.String()
+	response.Usage = anthropicTokenUsage(inputTokens, outputTokens)
+	return response, nil
+}
+
+// send makes a messages API request, returning the response when it was successful
+func (p *AnthropicProvider) send(ctx context.Context, request Request, stream bool) (*http.Response, error) {
+	model := request.Model
+	if model == "" {
+		model = p.defaultModel
+	}
+	// The messages API takes the system prompt separately from the conversation
+	requestBody := &anthropicRequest{
+		Model:       model,
+		MaxTokens:   request.MaxTokens,
+		Temperature: request.Temperature,
+		Stream:      stream,
+	}
+	var system []string
+	for _, message := range request.Messages {
+		if message.Role == "system" {
+			system = append(system, message.Content)
+			continue
+		}
+		requestBody.Messages = append(requestBody.Messages, anthropicMessage{Role: message.Role, Content: message.Content})
+	}
+	requestBody.System = strings.Join(system, "\n\n")
+	// The messages API has no JSON mode, so the completion is started with the opening brace of the object
+	if request.JSON {
+		requestBody.Messages = append(requestBody.Messages, anthropicMessage{Role: "assistant", Content: anthropicJSONPrefill})
+	}
+
+	requestBodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(requestBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", AnthropicVersion)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError(ProviderAnthropic, resp)
+	}
+	return resp, nil
+}
+
+// anthropicJSONPrefill is the start of the assistant message of the JSON completions, which is not part of the response
+const anthropicJSONPrefill = "{"
+
+// anthropicTokenUsage returns the token usage from the Anthropic input and output tokens
+func anthropicTokenUsage(inputTokens int, outputTokens int) types.TokenUsage {
+	return types.TokenUsage{
+		PromptTokens:     inputTokens,
+		CompletionTokens: outputTokens,
+		TotalTokens:      inputTokens + outputTokens,
+	}
+}
+
+// anthropicErrorStatus returns the status code of an Anthropic error type received mid-stream
+func anthropicErrorStatus(errorType string) int {
+	switch errorType {
+	case "overloaded_error":
+		return 529
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "invalid_request_error":
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// anthropicFinishReason maps an Anthropic stop reason to the OpenAI finish reason values
+func anthropicFinishReason(stopReason string) string {
+	switch stopReason {
+	case "max_tokens":
+		return "length"
+	case "end_turn", "stop_sequence":
+		return "stop"
+	default:
+		return stopReason
+	}
+}
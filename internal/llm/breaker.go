@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// CircuitBreakerConfig configures when the circuit breaker stops calling a failing provider
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before a trial request is allowed
+	OpenTimeout time.Duration
+}
+
+// DefaultCircuitBreakerConfig opens the circuit after 5 consecutive failures for 30 seconds
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+// circuitBreakerProvider fails fast while a provider keeps failing, giving it time to recover
+type circuitBreakerProvider struct {
+	Provider
+	config   CircuitBreakerConfig
+	mutex    sync.Mutex
+	failures int
+	openedAt time.Time
+	// trialRunning is set while a request is testing if the provider recovered (half-open state)
+	trialRunning bool
+	now          func() time.Time
+}
+
+// WithCircuitBreaker returns a Provider that stops calling the provider after consecutive failures
+func WithCircuitBreaker(provider Provider, config CircuitBreakerConfig) Provider {
+	return &circuitBreakerProvider{
+		Provider: provider,
+		config:   config,
+		now:      time.Now,
+	}
+}
+
+// ChatCompletion requests a chat completion when the circuit is closed
+func (p *circuitBreakerProvider) ChatCompletion(ctx context.Context, request Request) (*Response, error) {
+	if err := p.allow(); err != nil {
+		return nil, err
+	}
+	response, err := p.Provider.ChatCompletion(ctx, request)
+	p.record(err)
+	return response, err
+}
+
+// StreamChatCompletion streams a chat completion when the circuit is closed
+func (p *circuitBreakerProvider) StreamChatCompletion(ctx context.Context, request Request, onDelta StreamHandler) (*Response, error) {
+	if err := p.allow(); err != nil {
+		return nil, err
+	}
+	response, err := p.Provider.StreamChatCompletion(ctx, request, onDelta)
+	p.record(err)
+	return response, err
+}
+
+// CountTokens returns the approximate number of tokens of the messages
+func (p *circuitBreakerProvider) CountTokens(model string, messages []types.ChatGTPRequestMessage) int {
+	return p.Provider.CountTokens(model, messages)
+}
+
+// allow returns a CircuitOpenError while the circuit is open, and lets a single trial request through after the timeout
+func (p *circuitBreakerProvider) allow() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.failures < p.config.FailureThreshold {
+		return nil
+	}
+	openFor := p.now().Sub(p.openedAt)
+	if openFor < p.config.OpenTimeout {
+		return &CircuitOpenError{RetryAfter: p.config.OpenTimeout - openFor}
+	}
+	if p.trialRunning {
+		return &CircuitOpenError{RetryAfter: time.Second}
+	}
+	p.trialRunning = true
+	return nil
+}
+
+// record closes the circuit after a success, and counts the provider failures, opening the circuit at the threshold.
+// Cancelled requests and errors in the request itself are not provider failures.
+func (p *circuitBreakerProvider) record(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.trialRunning = false
+	if err == nil {
+		p.failures = 0
+		return
+	}
+	if !isRetryable(err) && !errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	p.failures++
+	if p.failures >= p.config.FailureThreshold {
+		p.openedAt = p.now()
+	}
+}
@@ -0,0 +1,178 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// APIError is an error response from a LLM provider API
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Type       string
+	Code       string
+	Message    string
+	// RetryAfter is the delay requested by the provider before retrying, if any
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+	errorType := e.Code
+	if errorType == "" {
+		errorType = e.Type
+	}
+	if errorType != "" {
+		return fmt.Sprintf("%s API error (status %d, %s): %s", e.Provider, e.StatusCode, errorType, message)
+	}
+	return fmt.Sprintf("%s API error (status %d): %s", e.Provider, e.StatusCode, message)
+}
+
+// Retryable reports if the request can succeed when retried: rate limits, overloads and server errors
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// CircuitOpenError is returned without calling the provider while the circuit breaker is open
+type CircuitOpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return "LLM provider is unavailable after repeated failures, please try again later"
+}
+
+// ErrStreamTruncated is returned when a stream ends without its terminating event, e.g. when the connection is closed,
+// so the streamed completion may be incomplete. It wraps io.ErrUnexpectedEOF, so it is retried before any delta is sent.
+var ErrStreamTruncated = fmt.Errorf("the stream ended before the completion was done: %w", io.ErrUnexpectedEOF)
+
+// UsageError is the error of a generation that failed after calling the provider, with the calls that were made, so
+// their tokens are still recorded
+type UsageError struct {
+	Err   error
+	Calls []types.ProviderCall
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// newAPIError parses the error body of a provider response, which uses the format
+// {"error": {"message": "...", "type": "...", "code": "..."}} for OpenAI and Anthropic
+func newAPIError(provider string, resp *http.Response) *APIError {
+	apiError := &APIError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header),
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var errorBody struct {
+		Error struct {
+			Message string          `json:"message"`
+			Type    string          `json:"type"`
+			Code    json.RawMessage `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &errorBody); err == nil && errorBody.Error.Message != "" {
+		apiError.Message = errorBody.Error.Message
+		apiError.Type = errorBody.Error.Type
+		// The code is either a string or null
+		var code string
+		if json.Unmarshal(errorBody.Error.Code, &code) == nil {
+			apiError.Code = code
+		}
+	} else {
+		apiError.Message = strings.TrimSpace(string(body))
+	}
+	return apiError
+}
+
+// parseRetryAfter returns the delay from the retry-after-ms or Retry-After (seconds or HTTP date) headers
+func parseRetryAfter(header http.Header) time.Duration {
+	if milliseconds, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && milliseconds > 0 {
+		return time.Duration(milliseconds * float64(time.Millisecond))
+	}
+	retryAfter := header.Get("Retry-After")
+	if retryAfter == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(retryAfter, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// isRetryable reports if a failed request can be retried: retryable API errors and network errors,
+// but not cancelled requests or errors in the request itself
+func isRetryable(err error) bool {
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		return apiError.Retryable()
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netError net.Error
+	return errors.As(err, &netError) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// HTTPStatus returns the status code to respond with when a LLM request fails:
+// 503 when the provider is rate limited, overloaded or the circuit is open, 504 when it timed out,
+// and 502 for any other provider error
+func HTTPStatus(err error) int {
+	var circuitOpenError *CircuitOpenError
+	if errors.As(err, &circuitOpenError) {
+		return http.StatusServiceUnavailable
+	}
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		switch apiError.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable, 529:
+			return http.StatusServiceUnavailable
+		case http.StatusGatewayTimeout:
+			return http.StatusGatewayTimeout
+		}
+		return http.StatusBadGateway
+	}
+	var netError net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netError) && netError.Timeout()) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
+
+// RetryAfter returns how long clients should wait before retrying a failed LLM request, if known
+func RetryAfter(err error) time.Duration {
+	var circuitOpenError *CircuitOpenError
+	if errors.As(err, &circuitOpenError) {
+		return circuitOpenError.RetryAfter
+	}
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		return apiError.RetryAfter
+	}
+	return 0
+}
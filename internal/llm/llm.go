@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+const (
+	ProviderOpenAI      = "openai"
+	ProviderAnthropic   = "anthropic"
+	ProviderAzureOpenAI = "azure"
+	ProviderOllama      = "ollama"
+)
+
+// Request is a chat completion request, independent of the LLM provider
+type Request struct {
+	// Model is optional, and the provider default model is used when empty
+	Model       string
+	Messages    []types.ChatGTPRequestMessage
+	Temperature float32
+	MaxTokens   int
+	// JSON asks for the completion to be a JSON object, which the messages must describe
+	JSON bool
+}
+
+// Response is a chat completion response, independent of the LLM provider
+type Response struct {
+	Model   string
+	Content string
+	// FinishReason uses the OpenAI values: "stop" when completed, and "length" when the max tokens were reached
+	FinishReason string
+	// Usage is reported by the provider, or estimated when it is not
+	Usage types.TokenUsage
+	// Calls are the provider calls made to complete the response, which was continued with more calls when there are several
+	Calls []types.ProviderCall
+}
+
+// StreamHandler receives the content deltas of a streamed chat completion, and can stop the stream by returning an error
+type StreamHandler func(delta string) error
+
+// Provider generates chat completions using a LLM vendor API
+type Provider interface {
+	Name() string
+	DefaultModel() string
+	ChatCompletion(ctx context.Context, request Request) (*Response, error)
+	// StreamChatCompletion sends the content deltas to onDelta as they are generated, and returns the full response
+	StreamChatCompletion(ctx context.Context, request Request, onDelta StreamHandler) (*Response, error)
+	// CountTokens returns the number of tokens of the messages for a model, which is the default model when empty
+	CountTokens(model string, messages []types.ChatGTPRequestMessage) int
+}
+
+// NewProviderFromEnv returns the Provider selected by the LLM_PROVIDER env variable, using OpenAI by default
+func NewProviderFromEnv() (Provider, error) {
+	switch provider := envString("LLM_PROVIDER", ProviderOpenAI); provider {
+	case ProviderOpenAI:
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, errors.New("no OpenAI API key present in env file")
+		}
+		return NewOpenAIProvider(envString("OPENAI_COMPLETIONS_URL", OpenAICompletionsURL), apiKey, envString("LLM_MODEL", GPT35)), nil
+	case ProviderAnthropic:
+		apiKey := os.Getenv("ANTHROPIC_API_KEY")
+		if apiKey == "" {
+			return nil, errors.New("no Anthropic API key present in env file")
+		}
+		return NewAnthropicProvider(envString("ANTHROPIC_MESSAGES_URL", AnthropicMessagesURL), apiKey, envString("LLM_MODEL", Claude3Haiku)), nil
+	case ProviderAzureOpenAI:
+		endpoint := os.Getenv("AZURE_OPENAI_ENDPOINT")
+		deployment := os.Getenv("AZURE_OPENAI_DEPLOYMENT")
+		apiKey := os.Getenv("AZURE_OPENAI_API_KEY")
+		if endpoint == "" || deployment == "" || apiKey == "" {
+			return nil, errors.New("Azure OpenAI endpoint, deployment and API key must be present in env file")
+		}
+		return NewAzureOpenAIProvider(endpoint, deployment, envString("AZURE_OPENAI_API_VERSION", AzureOpenAIAPIVersion), apiKey), nil
+	case ProviderOllama:
+		return NewOpenAICompatibleProvider(ProviderOllama, envString("LLM_BASE_URL", OllamaBaseURL), os.Getenv("LLM_API_KEY"), envString("LLM_MODEL", "llama3")), nil
+	default:
+		return nil, fmt.Errorf("unsupported LLM provider: %s", provider)
+	}
+}
+
+// AllowedModelsFromEnv returns the models clients can request, from the comma separated LLM_ALLOWED_MODELS env variable.
+// When it is not set, only the LLM_MODEL can be requested.
+func AllowedModelsFromEnv() []string {
+	var models []string
+	for _, model := range strings.Split(envString("LLM_ALLOWED_MODELS", os.Getenv("LLM_MODEL")), ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+func envString(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
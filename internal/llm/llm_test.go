@@ -0,0 +1,386 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAIProvider(t *testing.T) {
+	messages := []types.ChatGTPRequestMessage{
+		{Role: "system", Content: "You write cover letters"},
+		{Role: "user", Content: "Write a cover letter"},
+	}
+
+	t.Run("ChatCompletion", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer some_key", r.Header.Get("Authorization"))
+			var request types.ChatGPTRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			assert.Equal(t, GPT35, request.Model)
+			assert.Equal(t, messages, request.Messages)
+			assert.False(t, request.Stream)
+			assert.Nil(t, request.ResponseFormat)
+			fmt.Fprint(w, `{"model":"gpt-3.5-turbo-0613","choices":[{"index":0,"message":{"role":"assistant","content":"Dear Hiring Manager,"},"finish_reason":"stop"}],"usage":{"prompt_tokens":21,"completion_tokens":5,"total_tokens":26}}`)
+		}))
+		defer server.Close()
+
+		provider := NewOpenAIProvider(server.URL+"/v1/chat/completions", "some_key", GPT35)
+		response, err := provider.ChatCompletion(context.Background(), Request{Messages: messages, MaxTokens: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, &Response{Model: "gpt-3.5-turbo-0613", Content: "Dear Hiring Manager,", FinishReason: "stop", Usage: types.TokenUsage{PromptTokens: 21, CompletionTokens: 5, TotalTokens: 26}}, response)
+	})
+
+	t.Run("StreamChatCompletion", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request types.ChatGPTRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			// Only OpenAI is asked for the usage of streams
+			assert.Nil(t, request.StreamOptions)
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"model\":\"llama3\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Dear \"}}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hiring Manager,\"},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		}))
+		defer server.Close()
+
+		// Ollama is configured with the base URL of its OpenAI compatible API
+		provider := NewOpenAICompatibleProvider(ProviderOllama, server.URL+"/v1", "", "llama3")
+		var deltas []string
+		response, err := provider.StreamChatCompletion(context.Background(), Request{Messages: messages}, func(delta string) error {
+			deltas = append(deltas, delta)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Dear ", "Hiring Manager,"}, deltas)
+		// The usage is estimated when it is not reported
+		assert.Equal(t, &Response{Model: "llama3", Content: "Dear Hiring Manager,", FinishReason: "stop", Usage: types.TokenUsage{PromptTokens: 18, CompletionTokens: 4, TotalTokens: 22}}, response)
+	})
+
+	t.Run("StreamChatCompletion truncated", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			// The connection is closed before [DONE]
+			fmt.Fprint(w, "data: {\"model\":\"llama3\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Dear \"}}]}\n\n")
+		}))
+		defer server.Close()
+
+		provider := NewOpenAICompatibleProvider(ProviderOllama, server.URL+"/v1", "", "llama3")
+		response, err := provider.StreamChatCompletion(context.Background(), Request{Messages: messages}, func(delta string) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrStreamTruncated)
+		assert.Equal(t, http.StatusBadGateway, HTTPStatus(err))
+		assert.Nil(t, response)
+	})
+
+	t.Run("JSON mode", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request types.ChatGPTRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			assert.Equal(t, &types.ChatGPTResponseFormat{Type: "json_object"}, request.ResponseFormat)
+			fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"{\"salutation\":\"Dear Hiring Manager,\"}"},"finish_reason":"stop"}]}`)
+		}))
+		defer server.Close()
+
+		provider := NewOpenAIProvider(server.URL+"/v1/chat/completions", "some_key", GPT35)
+		response, err := provider.ChatCompletion(context.Background(), Request{Messages: messages, JSON: true})
+		assert.NoError(t, err)
+		assert.Equal(t, `{"salutation":"Dear Hiring Manager,"}`, response.Content)
+	})
+
+	t.Run("Azure OpenAI deployment", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/openai/deployments/cover-letters/chat/completions", r.URL.Path)
+			assert.Equal(t, AzureOpenAIAPIVersion, r.URL.Query().Get("api-version"))
+			assert.Equal(t, "some_key", r.Header.Get("api-key"))
+			fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"Dear Hiring Manager,"},"finish_reason":"length"}]}`)
+		}))
+		defer server.Close()
+
+		provider := NewAzureOpenAIProvider(server.URL, "cover-letters", AzureOpenAIAPIVersion, "some_key")
+		response, err := provider.ChatCompletion(context.Background(), Request{Messages: messages})
+		assert.NoError(t, err)
+		assert.Equal(t, "length", response.FinishReason)
+	})
+}
+
+func TestAnthropicProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "some_key", r.Header.Get("x-api-key"))
+		var request anthropicRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		// The system prompt is sent separately from the conversation
+		assert.Equal(t, "You write cover letters", request.System)
+		assert.Equal(t, []anthropicMessage{{Role: "user", Content: "Write a cover letter"}}, request.Messages)
+		fmt.Fprint(w, `{"model":"claude-3-haiku-20240307","content":[{"type":"text","text":"Dear Hiring Manager,"}],"stop_reason":"max_tokens","usage":{"input_tokens":14,"output_tokens":10}}`)
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider(server.URL, "some_key", Claude3Haiku)
+	response, err := provider.ChatCompletion(context.Background(), Request{
+		Messages: []types.ChatGTPRequestMessage{
+			{Role: "system", Content: "You write cover letters"},
+			{Role: "user", Content: "Write a cover letter"},
+		},
+		MaxTokens: 10,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &Response{Model: Claude3Haiku, Content: "Dear Hiring Manager,", FinishReason: "length", Usage: types.TokenUsage{PromptTokens: 14, CompletionTokens: 10, TotalTokens: 24}}, response)
+}
+
+func TestAnthropicProviderJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request anthropicRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		// The completion is prefilled with the opening brace, and continued by the model
+		assert.Equal(t, []anthropicMessage{{Role: "user", Content: "Reply with JSON"}, {Role: "assistant", Content: "{"}}, request.Messages)
+		if request.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"model\":\"claude-3-haiku-20240307\",\"usage\":{\"input_tokens\":5}}}\n\n")
+			fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"\\\"ok\\\":true}\"}}\n\n")
+			fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+			return
+		}
+		fmt.Fprint(w, `{"model":"claude-3-haiku-20240307","content":[{"type":"text","text":"\"ok\":true}"}],"stop_reason":"end_turn","usage":{"input_tokens":5,"output_tokens":3}}`)
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider(server.URL, "some_key", Claude3Haiku)
+	request := Request{Messages: []types.ChatGTPRequestMessage{{Role: "user", Content: "Reply with JSON"}}, JSON: true}
+	response, err := provider.ChatCompletion(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"ok":true}`, response.Content)
+
+	var deltas []string
+	response, err = provider.StreamChatCompletion(context.Background(), request, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"{", `"ok":true}`}, deltas)
+	assert.Equal(t, `{"ok":true}`, response.Content)
+}
+
+func TestAnthropicProviderStreamTruncated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		// The connection is closed before message_stop
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"model\":\"claude-3-haiku-20240307\",\"usage\":{\"input_tokens\":5}}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Dear \"}}\n\n")
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider(server.URL, "some_key", Claude3Haiku)
+	request := Request{Messages: []types.ChatGTPRequestMessage{{Role: "user", Content: "Write a cover letter"}}}
+	response, err := provider.StreamChatCompletion(context.Background(), request, func(delta string) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrStreamTruncated)
+	assert.Nil(t, response)
+}
+
+func TestContextWindow(t *testing.T) {
+	assert.Equal(t, 16385, ContextWindow(GPT35))
+	assert.Equal(t, 8192, ContextWindow(GPT4))
+	assert.Equal(t, 32768, ContextWindow("gpt-4-32k-0613"))
+	assert.Equal(t, 128000, ContextWindow("gpt-4o-mini"))
+	assert.Equal(t, 200000, ContextWindow(Claude3Haiku))
+	assert.Equal(t, DefaultContextWindow, ContextWindow("llama3"))
+
+	t.Setenv("LLM_CONTEXT_WINDOW", "8000")
+	assert.Equal(t, 8000, ContextWindow("llama3"))
+}
+
+func TestTruncateTokens(t *testing.T) {
+	text := "Lead the operations team.\nReport to the COO. Manage the budget of the department"
+	assert.Equal(t, text, TruncateTokens(text, 100))
+	// The text is cut at the last sentence in the second half
+	assert.Equal(t, "Lead the operations team.\nReport to the COO.", TruncateTokens(text, 12))
+	// or at the last word
+	assert.Equal(t, "Lead the", TruncateTokens(text, 2))
+	assert.Equal(t, "", TruncateTokens(text, 0))
+	assert.LessOrEqual(t, EstimateTokens(TruncateTokens(text, 9)), 9)
+}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, EstimateTokens(""))
+	assert.Equal(t, 4, EstimateTokens("Dear Hiring Manager,"))
+	assert.Equal(t, 3, EstimateTokens("internationalization"))
+}
+
+func TestCountTokens(t *testing.T) {
+	messages := []types.ChatGTPRequestMessage{{Role: "user", Content: "Hello world"}}
+
+	// The OpenAI models are counted with their tokenizer
+	assert.Equal(t, 9, NewOpenAIProvider("", "", GPT35).CountTokens("", messages))
+	assert.Equal(t, 9, NewOpenAIProvider("", "", "gpt-4o").CountTokens("", messages))
+	// The other models are estimated with the safety margin
+	assert.Equal(t, 7, estimateMessagesTokens(messages))
+	assert.Equal(t, 9, NewOpenAIProvider("", "", "llama3").CountTokens("", messages))
+	assert.Equal(t, 9, NewOpenAICompatibleProvider(ProviderOllama, "", "", GPT35).CountTokens("", messages))
+	assert.Equal(t, 9, NewAnthropicProvider("", "", Claude3Haiku).CountTokens("", messages))
+
+	// The requested model is counted with its own tokenizer instead of the one of the default model
+	text := []types.ChatGTPRequestMessage{{Role: "user", Content: "Привет, как дела? Это сопроводительное письмо."}}
+	provider := NewOpenAIProvider("", "", GPT35)
+	assert.NotEqual(t, provider.CountTokens("", text), provider.CountTokens("gpt-4o", text))
+	assert.Equal(t, NewOpenAIProvider("", "", "gpt-4o").CountTokens("", text), provider.CountTokens("gpt-4o", text))
+}
+
+func TestErrorHandling(t *testing.T) {
+	messages := []types.ChatGTPRequestMessage{{Role: "user", Content: "Write a cover letter"}}
+
+	t.Run("APIError", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`)
+		}))
+		defer server.Close()
+
+		provider := NewOpenAIProvider(server.URL, "some_key", GPT35)
+		_, err := provider.ChatCompletion(context.Background(), Request{Messages: messages})
+		var apiError *APIError
+		assert.ErrorAs(t, err, &apiError)
+		assert.Equal(t, &APIError{Provider: ProviderOpenAI, StatusCode: 429, Type: "requests", Code: "rate_limit_exceeded", Message: "Rate limit reached", RetryAfter: 2 * time.Second}, apiError)
+		assert.True(t, apiError.Retryable())
+		assert.Equal(t, "openai API error (status 429, rate_limit_exceeded): Rate limit reached", err.Error())
+		assert.Equal(t, http.StatusServiceUnavailable, HTTPStatus(err))
+		assert.Equal(t, 2*time.Second, RetryAfter(err))
+	})
+
+	t.Run("HTTPStatus", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadGateway, HTTPStatus(&APIError{StatusCode: http.StatusInternalServerError}))
+		assert.Equal(t, http.StatusBadGateway, HTTPStatus(&APIError{StatusCode: http.StatusUnauthorized}))
+		assert.Equal(t, http.StatusServiceUnavailable, HTTPStatus(&APIError{StatusCode: 529}))
+		assert.Equal(t, http.StatusGatewayTimeout, HTTPStatus(context.DeadlineExceeded))
+		assert.Equal(t, http.StatusServiceUnavailable, HTTPStatus(&CircuitOpenError{}))
+		assert.Equal(t, http.StatusBadGateway, HTTPStatus(errors.New("received no choices from openai")))
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts < 3 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, `{"model":"gpt-3.5-turbo","choices":[{"index":0,"message":{"role":"assistant","content":"Dear Hiring Manager,"},"finish_reason":"stop"}]}`)
+		}))
+		defer server.Close()
+
+		var delays []time.Duration
+		provider := WithRetry(NewOpenAIProvider(server.URL, "some_key", GPT35), RetryConfig{MaxRetries: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 10 * time.Second}).(*retryProvider)
+		provider.sleep = func(ctx context.Context, delay time.Duration) error {
+			delays = append(delays, delay)
+			return nil
+		}
+		response, err := provider.ChatCompletion(context.Background(), Request{Messages: messages})
+		assert.NoError(t, err)
+		assert.Equal(t, "Dear Hiring Manager,", response.Content)
+		assert.Equal(t, 3, attempts)
+		// The Retry-After delay is longer than the backoff, so it is honored
+		assert.Equal(t, []time.Duration{time.Second, time.Second}, delays)
+	})
+
+	t.Run("RetryNotRetryable", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Invalid model","type":"invalid_request_error","code":null}}`)
+		}))
+		defer server.Close()
+
+		provider := WithRetry(NewOpenAIProvider(server.URL, "some_key", GPT35), RetryConfig{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+		_, err := provider.ChatCompletion(context.Background(), Request{Messages: messages})
+		assert.EqualError(t, err, "openai API error (status 400, invalid_request_error): Invalid model")
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("RetryStreamStarted", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Dear\"}}]}\n\n")
+			fmt.Fprint(w, "data: {\"error\":{\"message\":\"The server had an error\",\"type\":\"server_error\"}}\n\n")
+		}))
+		defer server.Close()
+
+		provider := WithRetry(NewOpenAIProvider(server.URL, "some_key", GPT35), RetryConfig{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+		_, err := provider.StreamChatCompletion(context.Background(), Request{Messages: messages}, func(delta string) error { return nil })
+		assert.EqualError(t, err, "openai API error (status 500, server_error): The server had an error")
+		// The content already sent to the client can't be taken back, so the stream is not retried
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		var attempts atomic.Int32
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			// The provider hangs until the test ends
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		provider := WithRetry(NewOpenAIProvider(server.URL, "some_key", GPT35), RetryConfig{MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Timeout: 50 * time.Millisecond})
+		_, err := provider.ChatCompletion(context.Background(), Request{Messages: messages})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, http.StatusGatewayTimeout, HTTPStatus(err))
+		// The timed out attempt is retried
+		assert.Equal(t, int32(2), attempts.Load())
+
+		_, err = provider.StreamChatCompletion(context.Background(), Request{Messages: messages}, func(delta string) error { return nil })
+		assert.Equal(t, http.StatusGatewayTimeout, HTTPStatus(err))
+		assert.Equal(t, int32(4), attempts.Load())
+	})
+
+	t.Run("CircuitBreaker", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts <= 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprint(w, `{"model":"gpt-3.5-turbo","choices":[{"index":0,"message":{"role":"assistant","content":"Dear Hiring Manager,"},"finish_reason":"stop"}]}`)
+		}))
+		defer server.Close()
+
+		now := time.Now()
+		provider := WithCircuitBreaker(NewOpenAIProvider(server.URL, "some_key", GPT35), CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute}).(*circuitBreakerProvider)
+		provider.now = func() time.Time { return now }
+
+		for i := 0; i < 2; i++ {
+			_, err := provider.ChatCompletion(context.Background(), Request{Messages: messages})
+			assert.Equal(t, http.StatusBadGateway, HTTPStatus(err))
+		}
+		// The circuit is open, so the provider is not called
+		_, err := provider.ChatCompletion(context.Background(), Request{Messages: messages})
+		assert.Equal(t, &CircuitOpenError{RetryAfter: time.Minute}, err)
+		assert.Equal(t, 2, attempts)
+
+		// A trial request is allowed after the timeout, closing the circuit when it succeeds
+		now = now.Add(time.Minute)
+		response, err := provider.ChatCompletion(context.Background(), Request{Messages: messages})
+		assert.NoError(t, err)
+		assert.Equal(t, "Dear Hiring Manager,", response.Content)
+		_, err = provider.ChatCompletion(context.Background(), Request{Messages: messages})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,237 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+var OpenAICompletionsURL = "https://api.openai.com/v1/chat/completions"
+var OllamaBaseURL = "http://localhost:11434/v1"
+var AzureOpenAIAPIVersion = "2024-02-01"
+var (
+	GPT35 = "gpt-3.5-turbo"
+	GPT4  = "gpt-4"
+)
+
+// OpenAIProvider implements Provider for the OpenAI chat completions API,
+// and for the APIs compatible with it (Azure OpenAI, Ollama and other local endpoints)
+type OpenAIProvider struct {
+	name         string
+	url          string
+	defaultModel string
+	// setAuth sets the authentication headers of a request
+	setAuth func(req *http.Request)
+	client  *http.Client
+}
+
+// NewOpenAIProvider returns a Provider for the OpenAI chat completions API
+func NewOpenAIProvider(completionsURL string, apiKey string, defaultModel string) *OpenAIProvider {
+	return NewOpenAICompatibleProvider(ProviderOpenAI, completionsURL, apiKey, defaultModel)
+}
+
+// NewOpenAICompatibleProvider returns a Provider for an OpenAI compatible API, such as Ollama.
+// The URL can either be the chat completions URL or the base URL of the API (e.g. http://localhost:11434/v1).
+func NewOpenAICompatibleProvider(name string, baseURL string, apiKey string, defaultModel string) *OpenAIProvider {
+	completionsURL := strings.TrimSuffix(baseURL, "/")
+	if !strings.HasSuffix(completionsURL, "/chat/completions") {
+		completionsURL += "/chat/completions"
+	}
+	return &OpenAIProvider{
+		name:         name,
+		url:          completionsURL,
+		defaultModel: defaultModel,
+		setAuth: func(req *http.Request) {
+			if apiKey != "" {
+				req.Header.Set("Authorization", "Bearer "+apiKey)
+			}
+		},
+		client: &http.Client{},
+	}
+}
+
+// NewAzureOpenAIProvider returns a Provider for an Azure OpenAI deployment, where the deployment determines the model
+func NewAzureOpenAIProvider(endpoint string, deployment string, apiVersion string, apiKey string) *OpenAIProvider {
+	completionsURL := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		strings.TrimSuffix(endpoint, "/"), url.PathEscape(deployment), url.QueryEscape(apiVersion))
+	return &OpenAIProvider{
+		name:         ProviderAzureOpenAI,
+		url:          completionsURL,
+		defaultModel: deployment,
+		setAuth: func(req *http.Request) {
+			req.Header.Set("api-key", apiKey)
+		},
+		client: &http.Client{},
+	}
+}
+
+// Name returns the name of the provider
+func (p *OpenAIProvider) Name() string {
+	return p.name
+}
+
+// DefaultModel returns the model used when the request does not set one
+func (p *OpenAIProvider) DefaultModel() string {
+	return p.defaultModel
+}
+
+// CountTokens returns the number of tokens of the messages for the model, or the default model when empty, counted
+// with its tokenizer for the OpenAI models, and estimated with a safety margin for the other models, e.g. the local
+// models of Ollama
+func (p *OpenAIProvider) CountTokens(model string, messages []types.ChatGTPRequestMessage) int {
+	if model == "" {
+		model = p.defaultModel
+	}
+	if p.name == ProviderOpenAI || p.name == ProviderAzureOpenAI {
+		if tokenizer := openAITokenizer(model); tokenizer != nil {
+			return countMessagesTokens(tokenizer, messages)
+		}
+	}
+	return countEstimatedTokens(messages)
+}
+
+// ChatCompletion sends the messages to the chat completions API and returns the first choice
+func (p *OpenAIProvider) ChatCompletion(ctx context.Context, request Request) (*Response, error) {
+	resp, err := p.send(ctx, request, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseData types.ChatGPTResponseData
+	if err := json.Unmarshal(responseBody, &responseData); err != nil {
+		return nil, err
+	}
+	if len(responseData.Choices) == 0 {
+		return nil, fmt.Errorf("received no choices from %s", p.name)
+	}
+
+	response := &Response{
+		Model:        responseData.Model,
+		Content:      responseData.Choices[0].Message.Content,
+		FinishReason: responseData.Choices[0].FinishReason,
+	}
+	if responseData.Usage != nil {
+		response.Usage = *responseData.Usage
+	} else {
+		response.Usage = estimateUsage(request.Messages, response.Content)
+	}
+	return response, nil
+}
+
+// StreamChatCompletion sends the messages to the chat completions API with stream enabled,
+// relaying the content deltas of the first choice to onDelta, and returns ErrStreamTruncated when it ends without [DONE]
+func (p *OpenAIProvider) StreamChatCompletion(ctx context.Context, request Request, onDelta StreamHandler) (*Response, error) {
+	resp, err := p.send(ctx, request, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := &Response{}
+	var content strings.Builder
+	errDone := errors.New("done")
+	err = readServerSentEvents(resp.Body, func(_ string, data string) error {
+		if data == "[DONE]" {
+			return errDone
+		}
+		var chunk types.ChatGPTStreamResponseData
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+		if chunk.Error != nil {
+			// Errors after the response started are sent as a chunk with an error object
+			return &APIError{Provider: p.name, StatusCode: http.StatusInternalServerError, Type: chunk.Error.Type, Code: chunk.Error.Code, Message: chunk.Error.Message}
+		}
+		if chunk.Model != "" {
+			response.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			response.Usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Index != 0 {
+				continue
+			}
+			if choice.FinishReason != nil {
+				response.FinishReason = *choice.FinishReason
+			}
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				if err := onDelta(choice.Delta.Content); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return nil, ErrStreamTruncated
+	}
+	if err != errDone {
+		return nil, err
+	}
+
+	response.Content = content.String()
+	if response.Usage.TotalTokens == 0 {
+		response.Usage = estimateUsage(request.Messages, response.Content)
+	}
+	return response, nil
+}
+
+// send makes a chat completions request, returning the response when it was successful
+func (p *OpenAIProvider) send(ctx context.Context, request Request, stream bool) (*http.Response, error) {
+	model := request.Model
+	if model == "" {
+		model = p.defaultModel
+	}
+	requestBody := &types.ChatGPTRequest{
+		Model:       model,
+		Messages:    request.Messages,
+		Temperature: request.Temperature,
+		MaxTokens:   request.MaxTokens,
+		Stream:      stream,
+	}
+	// The usage of streams is only sent by OpenAI when requested, and other compatible APIs may not support it
+	if stream && p.name == ProviderOpenAI {
+		requestBody.StreamOptions = &types.ChatGPTStreamOptions{IncludeUsage: true}
+	}
+	if request.JSON {
+		requestBody.ResponseFormat = &types.ChatGPTResponseFormat{Type: "json_object"}
+	}
+	requestBodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make a request to the chat completions API using the defined model and messages (prompts)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(requestBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	p.setAuth(req)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError(p.name, resp)
+	}
+	return resp, nil
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// RetryConfig configures the retries of failed LLM requests with exponential backoff
+type RetryConfig struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	// Timeout is the max duration of each attempt, including the streamed content, and attempts have no limit when zero
+	Timeout time.Duration
+}
+
+// RetryConfigFromEnv returns the retry configuration, with the number of retries from LLM_MAX_RETRIES
+// and the timeout of each attempt from LLM_TIMEOUT, 2 minutes by default
+func RetryConfigFromEnv() RetryConfig {
+	maxRetries := 3
+	if value, err := strconv.Atoi(os.Getenv("LLM_MAX_RETRIES")); err == nil && value >= 0 {
+		maxRetries = value
+	}
+	timeout := 2 * time.Minute
+	if value, err := time.ParseDuration(os.Getenv("LLM_TIMEOUT")); err == nil && value > 0 {
+		timeout = value
+	}
+	return RetryConfig{
+		MaxRetries: maxRetries,
+		BaseDelay:  500 * time.Millisecond,
+		MaxDelay:   10 * time.Second,
+		Timeout:    timeout,
+	}
+}
+
+// retryProvider retries the failed requests of a Provider
+type retryProvider struct {
+	Provider
+	config RetryConfig
+	// sleep waits for a delay, returning early with an error when the context is done
+	sleep func(ctx context.Context, delay time.Duration) error
+}
+
+// WithRetry returns a Provider that retries retryable errors and timed out attempts with exponential backoff and jitter,
+// waiting at least the delay requested by the provider with Retry-After
+func WithRetry(provider Provider, config RetryConfig) Provider {
+	return &retryProvider{
+		Provider: provider,
+		config:   config,
+		sleep:    sleepContext,
+	}
+}
+
+// ChatCompletion requests a chat completion, retrying the retryable errors
+func (p *retryProvider) ChatCompletion(ctx context.Context, request Request) (*Response, error) {
+	var response *Response
+	err := p.retry(ctx, func(ctx context.Context) (bool, error) {
+		var err error
+		response, err = p.Provider.ChatCompletion(ctx, request)
+		return true, err
+	})
+	return response, err
+}
+
+// StreamChatCompletion streams a chat completion, retrying the retryable errors
+// only when they happen before any delta was sent
+func (p *retryProvider) StreamChatCompletion(ctx context.Context, request Request, onDelta StreamHandler) (*Response, error) {
+	var response *Response
+	err := p.retry(ctx, func(ctx context.Context) (bool, error) {
+		started := false
+		var err error
+		response, err = p.Provider.StreamChatCompletion(ctx, request, func(delta string) error {
+			started = true
+			return onDelta(delta)
+		})
+		return !started, err
+	})
+	return response, err
+}
+
+// retry calls attempt until it succeeds, fails with an error that cannot be retried, or runs out of retries.
+// Each attempt is cancelled after the timeout, which can be retried unless the request itself is done.
+func (p *retryProvider) retry(ctx context.Context, attempt func(ctx context.Context) (canRetry bool, err error)) error {
+	for retries := 0; ; retries++ {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if p.config.Timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, p.config.Timeout)
+		}
+		canRetry, err := attempt(attemptCtx)
+		timedOut := errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+		cancel()
+		if err == nil || !canRetry || !(isRetryable(err) || timedOut) || retries >= p.config.MaxRetries {
+			return err
+		}
+
+		delay := p.backoff(retries)
+		if retryAfter := RetryAfter(err); retryAfter > delay {
+			if retryAfter > p.config.MaxDelay {
+				// Waiting longer than the max delay would keep the client waiting for too long
+				return err
+			}
+			delay = retryAfter
+		}
+		log.Printf("retrying %s request in %s after error: %s", p.Name(), delay, err.Error())
+		if err := p.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// backoff returns the exponential delay for a retry, with jitter between half and the full delay
+func (p *retryProvider) backoff(retries int) time.Duration {
+	delay := p.config.BaseDelay << retries
+	if delay <= 0 || delay > p.config.MaxDelay {
+		delay = p.config.MaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// CountTokens returns the approximate number of tokens of the messages
+func (p *retryProvider) CountTokens(model string, messages []types.ChatGTPRequestMessage) int {
+	return p.Provider.CountTokens(model, messages)
+}
+
+// sleepContext waits for a delay or until the context is done
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
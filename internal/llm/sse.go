@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// readServerSentEvents reads a text/event-stream body, calling onEvent with the event name and data of each event
+func readServerSentEvents(body io.Reader, onEvent func(event string, data string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// An empty line dispatches the event
+			if len(data) > 0 {
+				if err := onEvent(event, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			event = ""
+			data = nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		return onEvent(event, strings.Join(data, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package llm
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// tokensPerMessage is the overhead of the role and separators of each chat message
+const tokensPerMessage = 4
+
+// estimateSafetyMarginPercent is added to the estimated prompt tokens when they are counted to fit the prompts in the
+// context window, since the estimate is lower than the count of the tokenizers for code, symbols and non-English text
+const estimateSafetyMarginPercent = 15
+
+// EstimateTokens approximates the number of BPE tokens in a text, which is close to the
+// number of tokens of the OpenAI and Anthropic tokenizers for English text:
+// common words are a single token, longer words are split every 7 characters, and each symbol is a token.
+// It is only an estimate, used for the models without a known tokenizer and to find where to truncate texts.
+func EstimateTokens(text string) int {
+	tokens := 0
+	wordLength := 0
+	endWord := func() {
+		if wordLength > 0 {
+			tokens += (wordLength + 6) / 7
+			wordLength = 0
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			wordLength++
+		case unicode.IsSpace(r):
+			endWord()
+		default:
+			endWord()
+			tokens++
+		}
+	}
+	endWord()
+	return tokens
+}
+
+// estimateUsage approximates the token usage of a completion, for the providers that do not report it
+func estimateUsage(messages []types.ChatGTPRequestMessage, content string) types.TokenUsage {
+	usage := types.TokenUsage{
+		PromptTokens:     estimateMessagesTokens(messages),
+		CompletionTokens: EstimateTokens(content),
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return usage
+}
+
+// estimateMessagesTokens approximates the number of tokens of chat messages, including the message overhead
+func estimateMessagesTokens(messages []types.ChatGTPRequestMessage) int {
+	tokens := 0
+	for _, message := range messages {
+		tokens += tokensPerMessage + EstimateTokens(message.Role) + EstimateTokens(message.Content)
+	}
+	return tokens
+}
+
+// countEstimatedTokens estimates the number of tokens of chat messages with the safety margin, for the providers
+// without a tokenizer
+func countEstimatedTokens(messages []types.ChatGTPRequestMessage) int {
+	tokens := estimateMessagesTokens(messages)
+	return tokens + (tokens*estimateSafetyMarginPercent+99)/100
+}
+
+var (
+	tokenizersMutex sync.Mutex
+	// tokenizers are the tiktoken tokenizers of the OpenAI models, by model name, or nil for the unknown models
+	tokenizers = map[string]*tiktoken.Tiktoken{}
+)
+
+func init() {
+	// The BPE ranks are embedded in the binary instead of downloaded when a tokenizer is first used
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+// openAITokenizer returns the tiktoken tokenizer of an OpenAI model, or nil when tiktoken does not know the model
+func openAITokenizer(model string) *tiktoken.Tiktoken {
+	tokenizersMutex.Lock()
+	defer tokenizersMutex.Unlock()
+	tokenizer, ok := tokenizers[model]
+	if !ok {
+		var err error
+		tokenizer, err = tiktoken.EncodingForModel(model)
+		if err != nil {
+			log.Printf("No tokenizer for %s, estimating its tokens: %v", model, err)
+		}
+		tokenizers[model] = tokenizer
+	}
+	return tokenizer
+}
+
+// countMessagesTokens counts the tokens of chat messages with the tokenizer of an OpenAI model, which adds 3 tokens
+// to each message and 3 tokens to prime the reply
+func countMessagesTokens(tokenizer *tiktoken.Tiktoken, messages []types.ChatGTPRequestMessage) int {
+	tokens := 3
+	for _, message := range messages {
+		tokens += 3 + len(tokenizer.EncodeOrdinary(message.Role)) + len(tokenizer.EncodeOrdinary(message.Content))
+	}
+	return tokens
+}
+
+// DefaultContextWindow is the context window of the unknown models, in tokens
+const DefaultContextWindow = 4096
+
+// contextWindows are the context windows of the known models in tokens, by model name prefix
+var contextWindows = map[string]int{
+	"gpt-3.5-turbo":     16385,
+	"gpt-3.5-turbo-16k": 16385,
+	"gpt-4":             8192,
+	"gpt-4-32k":         32768,
+	"gpt-4-turbo":       128000,
+	"gpt-4-1106":        128000,
+	"gpt-4-0125":        128000,
+	"gpt-4o":            128000,
+	"claude-":           200000,
+}
+
+// ContextWindow returns the max number of tokens of the prompt and completion of a model, which is set for every model
+// with the LLM_CONTEXT_WINDOW env variable, e.g. for the local models of Ollama, or known from the model name
+func ContextWindow(model string) int {
+	if contextWindow, err := strconv.Atoi(os.Getenv("LLM_CONTEXT_WINDOW")); err == nil && contextWindow > 0 {
+		return contextWindow
+	}
+	contextWindow, longestPrefix := DefaultContextWindow, 0
+	for prefix, window := range contextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > longestPrefix {
+			contextWindow, longestPrefix = window, len(prefix)
+		}
+	}
+	return contextWindow
+}
+
+// TruncateTokens returns the start of the text with at most maxTokens estimated tokens, ending at the last
+// line or sentence when it is in the second half of the truncated text, or at the last word otherwise
+func TruncateTokens(text string, maxTokens int) string {
+	if EstimateTokens(text) <= maxTokens {
+		return text
+	}
+	if maxTokens <= 0 {
+		return ""
+	}
+
+	// The number of tokens grows with the length of the text, so the longest text that fits is found with a binary search on the word ends
+	var wordEnds []int
+	for i, r := range text {
+		if unicode.IsSpace(r) && i > 0 && !unicode.IsSpace(rune(text[i-1])) {
+			wordEnds = append(wordEnds, i)
+		}
+	}
+	fits := sort.Search(len(wordEnds), func(i int) bool {
+		return EstimateTokens(text[:wordEnds[i]]) > maxTokens
+	})
+	if fits == 0 {
+		return ""
+	}
+	truncated := text[:wordEnds[fits-1]]
+
+	if end := max(strings.LastIndex(truncated, "\n"), strings.LastIndex(truncated, ". ")+1); end > len(truncated)/2 {
+		truncated = truncated[:end]
+	}
+	return strings.TrimSpace(truncated)
+}
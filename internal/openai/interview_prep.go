@@ -0,0 +1,162 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonada182/cover-letter-ai-api/internal/llm"
+	"github.com/jonada182/cover-letter-ai-api/internal/prompt"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// interviewPrepMaxTokens is the max tokens of the interview preparation completion
+const interviewPrepMaxTokens = 2048
+
+// InterviewQuestionCategories are the categories of the interview questions, where general is used for the
+// categories of the completion that are not known
+var InterviewQuestionCategories = []string{"behavioral", "technical", "role", "company", "general"}
+
+// interviewPrepPromptData is the data of the interview preparation prompt template
+type interviewPrepPromptData struct {
+	JobPosting    types.JobPosting
+	Interviews    []interviewPromptEvent
+	CareerProfile string
+	Positions     []workHistoryPosition
+}
+
+// interviewPromptEvent is an interview event of the job application
+type interviewPromptEvent struct {
+	Date        string
+	Description string
+	Notes       string
+}
+
+// llmInterviewPrep is the JSON object of the interview preparation completion
+type llmInterviewPrep struct {
+	Questions      []types.InterviewQuestion `json:"questions"`
+	QuestionsToAsk []string                  `json:"questions_to_ask"`
+}
+
+// PrepareChatGPTInterview writes the likely interview questions of a job posting with the LLM provider, with answers
+// suggested from the career profile and its work history, and questions to ask the employer. The interview events of
+// the job application are added to the prompt, and the job details are truncated to fit in the context window of the model.
+// A completion that cannot be parsed, or was cut off at the max tokens, returns a llm.UsageError to record its usage.
+func (oa *OpenAIClient) PrepareChatGPTInterview(c *gin.Context, careerProfile *types.CareerProfile, jobPosting *types.JobPosting, interviews []types.JobApplicationEvent) (*types.GeneratedInterviewPrep, int, error) {
+	template, err := oa.prompts.Get(prompt.InterviewPrep)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	careerProfileInfo, err := oa.renderCareerProfilePrompt(newCareerProfilePromptData(careerProfile))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	data := interviewPrepPromptData{
+		JobPosting:    *jobPosting,
+		CareerProfile: careerProfileInfo,
+		Positions:     newWorkHistoryPositions(careerProfile),
+	}
+	for _, interview := range interviews {
+		event := interviewPromptEvent{Date: interview.Date, Description: interview.Description}
+		if interview.AdditionalNotes != nil {
+			event.Notes = *interview.AdditionalNotes
+		}
+		data.Interviews = append(data.Interviews, event)
+	}
+	var messages []types.ChatGTPRequestMessage
+	render := func() error {
+		systemPrompt, err := template.RenderSection("system", data)
+		if err != nil {
+			return err
+		}
+		userPrompt, err := template.RenderSection("user", data)
+		if err != nil {
+			return err
+		}
+		messages = []types.ChatGTPRequestMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		}
+		return nil
+	}
+	if err := render(); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	budget := llm.ContextWindow(oa.provider.DefaultModel()) - interviewPrepMaxTokens
+	if promptTokens := oa.provider.CountTokens("", messages); promptTokens > budget && data.JobPosting.Details != "" {
+		data.JobPosting.Details = oa.truncateToFit("", data.JobPosting.Details, promptTokens-budget)
+		if err := render(); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+
+	request := llm.Request{
+		Messages:    messages,
+		Temperature: 0,
+		MaxTokens:   interviewPrepMaxTokens,
+		JSON:        template.HasSection("response_format"),
+	}
+	response, err := oa.provider.ChatCompletion(requestContext(c), request)
+	if err != nil {
+		return nil, llm.HTTPStatus(err), err
+	}
+
+	if response.FinishReason == "length" {
+		return nil, http.StatusBadGateway, oa.failedCompletion(fmt.Errorf("the interview prep was cut off at the max tokens (%d)", interviewPrepMaxTokens), request, response, template.ID())
+	}
+	interviewPrep, err := parseInterviewPrep(response.Content)
+	if err != nil {
+		return nil, http.StatusBadGateway, oa.failedCompletion(err, request, response, template.ID())
+	}
+	model := response.Model
+	if model == "" {
+		model = oa.provider.DefaultModel()
+	}
+	return &types.GeneratedInterviewPrep{
+		InterviewPrep: *interviewPrep,
+		Model:         model,
+		PromptVersion: template.ID(),
+		Usage:         response.Usage,
+	}, http.StatusOK, nil
+}
+
+// parseInterviewPrep parses the JSON object of the interview preparation completion, dropping the empty questions
+func parseInterviewPrep(content string) (*types.InterviewPrep, error) {
+	object, err := extractJSONObject(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid interview prep from the LLM provider: %w", err)
+	}
+	var parsed llmInterviewPrep
+	if err := json.Unmarshal([]byte(object), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid interview prep from the LLM provider: %w", err)
+	}
+
+	interviewPrep := &types.InterviewPrep{
+		Questions:      []types.InterviewQuestion{},
+		QuestionsToAsk: []string{},
+	}
+	for _, question := range parsed.Questions {
+		if question.Question = strings.TrimSpace(question.Question); question.Question == "" {
+			continue
+		}
+		question.Category = strings.ToLower(strings.TrimSpace(question.Category))
+		if !slices.Contains(InterviewQuestionCategories, question.Category) {
+			question.Category = "general"
+		}
+		interviewPrep.Questions = append(interviewPrep.Questions, question)
+	}
+	if len(interviewPrep.Questions) == 0 {
+		return nil, errors.New("invalid interview prep from the LLM provider: no questions")
+	}
+	for _, question := range parsed.QuestionsToAsk {
+		if question = strings.TrimSpace(question); question != "" {
+			interviewPrep.QuestionsToAsk = append(interviewPrep.QuestionsToAsk, question)
+		}
+	}
+	return interviewPrep, nil
+}
@@ -0,0 +1,107 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonada182/cover-letter-ai-api/internal/jobposting"
+	"github.com/jonada182/cover-letter-ai-api/internal/llm"
+	"github.com/jonada182/cover-letter-ai-api/internal/prompt"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// jobPostingMaxTokens is the max tokens of the job posting parsing completion
+const jobPostingMaxTokens = 1024
+
+// jobPostingPromptData is the data of the job posting parsing prompt template
+type jobPostingPromptData struct {
+	Text            string
+	SeniorityLevels []string
+	SalaryPeriods   []string
+}
+
+// ParseChatGPTJobPosting extracts the structured fields of a job posting text with the LLM provider,
+// truncating the text to fit in the context window of the model. A completion that cannot be parsed returns a
+// llm.UsageError to record its usage.
+func (oa *OpenAIClient) ParseChatGPTJobPosting(c *gin.Context, text string) (*types.GeneratedJobPosting, int, error) {
+	template, err := oa.prompts.Get(prompt.JobPosting)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	data := jobPostingPromptData{
+		Text:            text,
+		SeniorityLevels: jobposting.SeniorityLevels,
+		SalaryPeriods:   jobposting.SalaryPeriods,
+	}
+	var messages []types.ChatGTPRequestMessage
+	render := func() error {
+		systemPrompt, err := template.RenderSection("system", data)
+		if err != nil {
+			return err
+		}
+		userPrompt, err := template.RenderSection("user", data)
+		if err != nil {
+			return err
+		}
+		messages = []types.ChatGTPRequestMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		}
+		return nil
+	}
+	if err := render(); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	budget := llm.ContextWindow(oa.provider.DefaultModel()) - jobPostingMaxTokens
+	if promptTokens := oa.provider.CountTokens("", messages); promptTokens > budget {
+		data.Text = oa.truncateToFit("", data.Text, promptTokens-budget)
+		if err := render(); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+
+	request := llm.Request{
+		Messages:    messages,
+		Temperature: 0,
+		MaxTokens:   jobPostingMaxTokens,
+		JSON:        template.HasSection("response_format"),
+	}
+	response, err := oa.provider.ChatCompletion(requestContext(c), request)
+	if err != nil {
+		return nil, llm.HTTPStatus(err), err
+	}
+
+	parsed, err := parseJobPosting(response.Content)
+	if err != nil {
+		return nil, http.StatusBadGateway, oa.failedCompletion(err, request, response, template.ID())
+	}
+	model := response.Model
+	if model == "" {
+		model = oa.provider.DefaultModel()
+	}
+	return &types.GeneratedJobPosting{
+		JobPosting:    *parsed,
+		Model:         model,
+		PromptVersion: template.ID(),
+		Usage:         response.Usage,
+	}, http.StatusOK, nil
+}
+
+// parseJobPosting parses the JSON object of the job posting completion, dropping a salary without amounts
+func parseJobPosting(content string) (*types.ParsedJobPosting, error) {
+	object, err := extractJSONObject(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job posting from the LLM provider: %w", err)
+	}
+	var parsed types.ParsedJobPosting
+	if err := json.Unmarshal([]byte(object), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid job posting from the LLM provider: %w", err)
+	}
+	if parsed.Salary != nil && parsed.Salary.Min <= 0 && parsed.Salary.Max <= 0 {
+		parsed.Salary = nil
+	}
+	return &parsed, nil
+}
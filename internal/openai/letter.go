@@ -0,0 +1,212 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// parseStructuredCoverLetter returns the cover letter body from a JSON completion, ignoring the text around the object
+func parseStructuredCoverLetter(content string) (*types.StructuredCoverLetter, error) {
+	object, err := extractJSONObject(content)
+	if err != nil {
+		return nil, err
+	}
+	var structured types.StructuredCoverLetter
+	if err := json.Unmarshal([]byte(object), &structured); err != nil {
+		return nil, err
+	}
+
+	var paragraphs []string
+	for _, paragraph := range structured.Paragraphs {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	if len(paragraphs) == 0 {
+		return nil, errors.New("no paragraphs")
+	}
+	structured.Paragraphs = paragraphs
+	structured.Salutation = strings.TrimSpace(structured.Salutation)
+	if structured.Salutation == "" {
+		structured.Salutation = "Dear Hiring Manager,"
+	}
+	structured.Closing = strings.TrimSpace(structured.Closing)
+	if structured.Closing == "" {
+		structured.Closing = "Sincerely,"
+	}
+	return &structured, nil
+}
+
+// structuredCoverLetterBody returns the text of the cover letter body, from the salutation to the signature
+func structuredCoverLetterBody(structured *types.StructuredCoverLetter, careerProfile *types.CareerProfile) string {
+	parts := append([]string{structured.Salutation}, structured.Paragraphs...)
+	parts = append(parts, structured.Closing+"\n"+fullName(careerProfile))
+	return strings.Join(parts, "\n\n")
+}
+
+// renderCoverLetter adds the header to the cover letter body: the name and contact information from the career profile,
+// the date, and the recipient, leaving out the contact information that is not set
+func renderCoverLetter(body string, careerProfile *types.CareerProfile, jobPosting *types.JobPosting) string {
+	header := []string{fullName(careerProfile)}
+	if careerProfile.ContactInfo != nil {
+		for _, contact := range []string{careerProfile.ContactInfo.Address, careerProfile.ContactInfo.Email, careerProfile.ContactInfo.Phone, careerProfile.ContactInfo.Website} {
+			if contact = strings.TrimSpace(contact); contact != "" {
+				header = append(header, contact)
+			}
+		}
+	}
+	return strings.Join(header, "\n") + "\n\n" + coverLetterDate() + "\n\nHiring Manager\n" + jobPosting.CompanyName + "\n\n" + body
+}
+
+// coverLetterDate returns the current date as written in the cover letters, e.g. October 1, 2023
+func coverLetterDate() string {
+	return time.Now().Format(types.CoverLetterDateLayout)
+}
+
+func fullName(careerProfile *types.CareerProfile) string {
+	return strings.TrimSpace(careerProfile.FirstName + " " + careerProfile.LastName)
+}
+
+// findPlaceholders returns the distinct bracket placeholders of the content, e.g. [Hiring Manager's Name]
+func findPlaceholders(content string) []string {
+	var placeholders []string
+	for {
+		start := strings.Index(content, "[")
+		if start == -1 {
+			return placeholders
+		}
+		content = content[start+1:]
+		end := strings.IndexAny(content, "[]\n")
+		if end == -1 {
+			return placeholders
+		}
+		if content[end] == ']' && strings.TrimSpace(content[:end]) != "" {
+			placeholder := "[" + content[:end] + "]"
+			if !slices.Contains(placeholders, placeholder) {
+				placeholders = append(placeholders, placeholder)
+			}
+		}
+		content = content[end:]
+	}
+}
+
+// jsonTextStream relays the text of the string values of a streamed JSON object, separated by blank lines,
+// so a cover letter generated as JSON is streamed as text: the salutation, paragraphs and closing
+type jsonTextStream struct {
+	onDelta func(delta string) error
+	// containers is the stack of the open objects and arrays
+	containers []byte
+	expectKey  bool
+	inString   bool
+	isValue    bool
+	escaped    bool
+	// unicode holds the hex digits of a \u escape sequence, and surrogate the first half of a surrogate pair
+	unicode   []byte
+	surrogate rune
+	values    int
+}
+
+func newJSONTextStream(onDelta func(delta string) error) *jsonTextStream {
+	return &jsonTextStream{onDelta: onDelta}
+}
+
+// Write reads the next delta of the JSON completion, sending the text it contains to onDelta
+func (s *jsonTextStream) Write(delta string) error {
+	var text strings.Builder
+	for i := 0; i < len(delta); i++ {
+		character := delta[i]
+		if !s.inString {
+			s.readStructural(character, &text)
+			continue
+		}
+		switch {
+		case s.unicode != nil:
+			s.unicode = append(s.unicode, character)
+			if len(s.unicode) == 4 {
+				s.writeRune(&text)
+			}
+		case s.escaped:
+			s.escaped = false
+			switch character {
+			case 'n':
+				s.write(&text, "\n")
+			case 't':
+				s.write(&text, "\t")
+			case 'u':
+				s.unicode = []byte{}
+			case 'r', 'b', 'f':
+			default:
+				s.write(&text, string(character))
+			}
+		case character == '\\':
+			s.escaped = true
+		case character == '"':
+			s.inString = false
+		case s.isValue:
+			// Copy the bytes of the multibyte characters as they are
+			text.WriteByte(character)
+		}
+	}
+	if text.Len() == 0 {
+		return nil
+	}
+	return s.onDelta(text.String())
+}
+
+// readStructural reads a character outside of the strings, tracking whether the next string is a key or a value
+func (s *jsonTextStream) readStructural(character byte, text *strings.Builder) {
+	switch character {
+	case '{', '[':
+		s.containers = append(s.containers, character)
+		s.expectKey = character == '{'
+	case '}', ']':
+		if len(s.containers) > 0 {
+			s.containers = s.containers[:len(s.containers)-1]
+		}
+	case ',':
+		s.expectKey = len(s.containers) > 0 && s.containers[len(s.containers)-1] == '{'
+	case ':':
+		s.expectKey = false
+	case '"':
+		s.inString = true
+		s.isValue = !s.expectKey
+		if s.isValue {
+			if s.values > 0 {
+				text.WriteString("\n\n")
+			}
+			s.values++
+		}
+	}
+}
+
+// writeRune writes the character of a \u escape sequence, combining the surrogate pairs
+func (s *jsonTextStream) writeRune(text *strings.Builder) {
+	code, err := strconv.ParseUint(string(s.unicode), 16, 16)
+	s.unicode = nil
+	if err != nil {
+		return
+	}
+	r := rune(code)
+	if utf16.IsSurrogate(r) {
+		if s.surrogate == 0 {
+			s.surrogate = r
+			return
+		}
+		r = utf16.DecodeRune(s.surrogate, r)
+	}
+	s.surrogate = 0
+	s.write(text, string(r))
+}
+
+func (s *jsonTextStream) write(text *strings.Builder, value string) {
+	if s.isValue {
+		text.WriteString(value)
+	}
+}
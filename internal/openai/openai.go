@@ -3,192 +3,949 @@ package openai
 //go:generate mockgen -destination=../../mocks/mock_openai.go -package=mocks github.com/jonada182/cover-letter-ai-api/internal/openai OpenAI
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
-	"os"
 	"regexp"
+	"slices"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/internal/cache"
+	"github.com/jonada182/cover-letter-ai-api/internal/llm"
+	"github.com/jonada182/cover-letter-ai-api/internal/prompt"
 	"github.com/jonada182/cover-letter-ai-api/types"
 
 	"github.com/gin-gonic/gin"
-)
-
-var OpenAICompletionsUrl = "https://api.openai.com/v1/chat/completions"
-var (
-	GPT35 = "gpt-3.5-turbo"
-	GPT4 = "gpt-4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
 )
 
 type OpenAIClient struct {
-	apiKey string
-	model  string
+	provider llm.Provider
+	prompts  *prompt.Registry
 }
 
 type OpenAI interface {
-	GenerateChatGPTCoverLetter(c *gin.Context, profileId uuid.UUID, jobPosting *types.JobPosting, s types.StoreClient) (string, int, error)
+	GenerateChatGPTCoverLetter(c *gin.Context, profileId uuid.UUID, jobPosting *types.JobPosting, options *types.CoverLetterOptions, s types.StoreClient) (*types.GeneratedCoverLetter, int, error)
+	StreamChatGPTCoverLetter(c *gin.Context, profileId uuid.UUID, jobPosting *types.JobPosting, options *types.CoverLetterOptions, s types.StoreClient, onDelta func(delta string) error) (*types.GeneratedCoverLetter, int, error)
+	GenerateChatGPTCoverLetterVariants(c *gin.Context, profileId uuid.UUID, jobPosting *types.JobPosting, options *types.CoverLetterOptions, variants int, s types.StoreClient) ([]types.GeneratedCoverLetter, int, error)
+	RankChatGPTCoverLetters(c *gin.Context, jobPosting *types.JobPosting, options *types.CoverLetterOptions, coverLetters []types.GeneratedCoverLetter) (*types.CoverLetterRankings, int, error)
+	ReviseChatGPTCoverLetter(c *gin.Context, coverLetter *types.CoverLetter, instruction string, s types.StoreClient) (*types.GeneratedCoverLetter, int, error)
+	CoverLetterCacheKey(profileId uuid.UUID, jobPosting *types.JobPosting, options *types.CoverLetterOptions, s types.StoreClient) (string, error)
 	GetCareerProfileInfoPrompt(profileId uuid.UUID, s types.StoreClient) (string, *types.CareerProfile, error)
+	ParseChatGPTJobPosting(c *gin.Context, text string) (*types.GeneratedJobPosting, int, error)
+	AnalyzeChatGPTSkillsGap(c *gin.Context, careerProfile *types.CareerProfile, jobPosting *types.JobPosting, keywordMatch *types.SkillsGapAnalysis) (*types.GeneratedSkillsGap, int, error)
+	TailorChatGPTResume(c *gin.Context, careerProfile *types.CareerProfile, jobPosting *types.JobPosting) (*types.GeneratedResume, int, error)
+	PrepareChatGPTInterview(c *gin.Context, careerProfile *types.CareerProfile, jobPosting *types.JobPosting, interviews []types.JobApplicationEvent) (*types.GeneratedInterviewPrep, int, error)
 	ParseCoverLetter(coverLetter *string, careerProfile *types.CareerProfile, jobPosting *types.JobPosting) (string, error)
 }
 
-// NewOpenAIClient initializes an OpenAI client with the API key from the .env file.
+// NewOpenAIClient initializes a client with the LLM provider configured in the .env file,
+// retrying failed requests and failing fast with a circuit breaker while the provider is unavailable.
 func NewOpenAIClient() (*OpenAIClient, error) {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		return nil, errors.New("no OpenAI API key present in env file")
+	provider, err := llm.NewProviderFromEnv()
+	if err != nil {
+		return nil, err
 	}
+	provider = llm.WithRetry(llm.WithCircuitBreaker(provider, llm.DefaultCircuitBreakerConfig()), llm.RetryConfigFromEnv())
+	prompts, err := prompt.NewRegistryFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewOpenAIClientWithPrompts(provider, prompts), nil
+}
+
+// NewOpenAIClientWithProvider initializes a client that generates completions with the given LLM provider,
+// using the latest version of the embedded prompt templates
+func NewOpenAIClientWithProvider(provider llm.Provider) *OpenAIClient {
+	return NewOpenAIClientWithPrompts(provider, prompt.Default())
+}
+
+// NewOpenAIClientWithPrompts initializes a client that generates completions with the given LLM provider and prompt templates
+func NewOpenAIClientWithPrompts(provider llm.Provider, prompts *prompt.Registry) *OpenAIClient {
 	return &OpenAIClient{
-		apiKey: apiKey,
-		model:  GPT35,
-	}, nil
+		provider: provider,
+		prompts:  prompts,
+	}
+}
+
+// GenerateChatGPTCoverLetter uses the configured LLM provider to generate a cover letter using the given parameters
+func (oa *OpenAIClient) GenerateChatGPTCoverLetter(c *gin.Context, profileId uuid.UUID, jobPosting *types.JobPosting, options *types.CoverLetterOptions, s types.StoreClient) (*types.GeneratedCoverLetter, int, error) {
+	letterPrompt, statusCode, err := oa.getCoverLetterPrompt(requestContext(c), profileId, jobPosting, options, s)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	// Request a completion from the LLM provider using the defined messages (prompts)
+	request := letterPrompt.request(options)
+	response, statusCode, err := oa.complete(requestContext(c), request, letterPrompt.Version, nil)
+	if err != nil {
+		return nil, statusCode, letterPrompt.usageError(err)
+	}
+
+	// Return the content for the last received message from the LLM provider if the response was successful
+	coverLetter, statusCode, err := oa.generatedCoverLetter(response, request, letterPrompt, jobPosting)
+	if err != nil {
+		return nil, statusCode, letterPrompt.usageError(err)
+	}
+	letterPrompt.addCalls(coverLetter)
+	return coverLetter, statusCode, nil
+}
+
+// StreamChatGPTCoverLetter generates a cover letter like GenerateChatGPTCoverLetter, sending the generated
+// content to onDelta as it is received, and returns the parsed cover letter once the generation is completed
+func (oa *OpenAIClient) StreamChatGPTCoverLetter(c *gin.Context, profileId uuid.UUID, jobPosting *types.JobPosting, options *types.CoverLetterOptions, s types.StoreClient, onDelta func(delta string) error) (*types.GeneratedCoverLetter, int, error) {
+	letterPrompt, statusCode, err := oa.getCoverLetterPrompt(requestContext(c), profileId, jobPosting, options, s)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	// Stream a completion from the LLM provider using the defined messages (prompts),
+	// relaying the text of the JSON completions instead of the JSON
+	request := letterPrompt.request(options)
+	if request.JSON {
+		onDelta = newJSONTextStream(onDelta).Write
+	}
+	response, statusCode, err := oa.complete(requestContext(c), request, letterPrompt.Version, onDelta)
+	if err != nil {
+		return nil, statusCode, letterPrompt.usageError(err)
+	}
+
+	coverLetter, statusCode, err := oa.generatedCoverLetter(response, request, letterPrompt, jobPosting)
+	if err != nil {
+		return nil, statusCode, letterPrompt.usageError(err)
+	}
+	letterPrompt.addCalls(coverLetter)
+	return coverLetter, statusCode, nil
+}
+
+// GenerateChatGPTCoverLetterVariants generates several cover letters for the same job posting. The completions are
+// requested in parallel, at most MaxConcurrentVariants at a time, since not every LLM provider supports the OpenAI n parameter.
+// The generation stops at the first failed completion, returning its error with the usage of every call that was made.
+func (oa *OpenAIClient) GenerateChatGPTCoverLetterVariants(c *gin.Context, profileId uuid.UUID, jobPosting *types.JobPosting, options *types.CoverLetterOptions, variants int, s types.StoreClient) ([]types.GeneratedCoverLetter, int, error) {
+	letterPrompt, statusCode, err := oa.getCoverLetterPrompt(requestContext(c), profileId, jobPosting, options, s)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	request := letterPrompt.request(options)
+	ctx, cancel := context.WithCancel(requestContext(c))
+	defer cancel()
+
+	coverLetters := make([]types.GeneratedCoverLetter, variants)
+	var mutex sync.Mutex
+	var firstErr error
+	errStatusCode := http.StatusOK
+	// calls are the provider calls, kept to be recorded when a variant fails
+	var calls []types.ProviderCall
+	fail := func(statusCode int, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		var usageError *llm.UsageError
+		if errors.As(err, &usageError) {
+			calls = append(calls, usageError.Calls...)
+			err = usageError.Err
+		}
+		if firstErr == nil {
+			firstErr = err
+			errStatusCode = statusCode
+			cancel()
+		}
+	}
+
+	semaphore := make(chan struct{}, MaxConcurrentVariants)
+	var wg sync.WaitGroup
+	for i := range coverLetters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			if err := ctx.Err(); err != nil {
+				fail(llm.HTTPStatus(err), err)
+				return
+			}
+
+			response, statusCode, err := oa.complete(ctx, request, letterPrompt.Version, nil)
+			if err != nil {
+				fail(statusCode, err)
+				return
+			}
+			coverLetter, statusCode, err := oa.generatedCoverLetter(response, request, letterPrompt, jobPosting)
+			if err != nil {
+				fail(statusCode, err)
+				return
+			}
+			mutex.Lock()
+			calls = append(calls, coverLetter.Calls...)
+			mutex.Unlock()
+			coverLetters[i] = *coverLetter
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		if len(calls) == 0 {
+			return nil, errStatusCode, letterPrompt.usageError(firstErr)
+		}
+		return nil, errStatusCode, letterPrompt.usageError(&llm.UsageError{Err: firstErr, Calls: calls})
+	}
+	// The calls made to build the prompt are shared by the variants, and recorded with the first one
+	letterPrompt.addCalls(&coverLetters[0])
+	return coverLetters, http.StatusOK, nil
 }
 
-// GenerateChatGPTCoverLetter uses the OpenAI completions API to generate a cover letter using the given parameters
-func (oa *OpenAIClient) GenerateChatGPTCoverLetter(c *gin.Context, profileId uuid.UUID, jobPosting *types.JobPosting, s types.StoreClient) (string, int, error) {
-	promptMessages := []types.ChatGTPRequestMessage{
-		{
-			Role:    "system",
-			Content: "You write cover letters when I give you job details. Limit: 3 paragraphs, 300 words. Start with: Dear [Employer's Name],",
+// RankChatGPTCoverLetters scores each cover letter against the job posting with the LLM provider. A completion that
+// cannot be parsed returns a llm.UsageError to record its usage.
+func (oa *OpenAIClient) RankChatGPTCoverLetters(c *gin.Context, jobPosting *types.JobPosting, options *types.CoverLetterOptions, coverLetters []types.GeneratedCoverLetter) (*types.CoverLetterRankings, int, error) {
+	template, err := oa.prompts.Get(prompt.CoverLetterRanking)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	data := coverLetterRankingPromptData{JobPosting: *jobPosting}
+	for i, coverLetter := range coverLetters {
+		data.CoverLetters = append(data.CoverLetters, rankedCoverLetter{Variant: i + 1, Content: coverLetter.Content})
+	}
+	systemPrompt, err := template.RenderSection("system", data)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	userPrompt, err := template.RenderSection("user", data)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	request := llm.Request{
+		Messages: []types.ChatGTPRequestMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
 		},
+		Temperature: 0,
+		MaxTokens:   rankingTokensPerVariant * len(coverLetters),
+	}
+	if options != nil {
+		request.Model = options.Model
+	}
+	response, err := oa.provider.ChatCompletion(requestContext(c), request)
+	if err != nil {
+		return nil, llm.HTTPStatus(err), err
+	}
+
+	rankings, err := parseCoverLetterRankings(response.Content, len(coverLetters))
+	if err != nil {
+		return nil, http.StatusBadGateway, oa.failedCompletion(err, request, response, template.ID())
+	}
+	model := response.Model
+	if model == "" {
+		model = request.Model
+	}
+	if model == "" {
+		model = oa.provider.DefaultModel()
+	}
+	return &types.CoverLetterRankings{
+		Rankings:      rankings,
+		Model:         model,
+		PromptVersion: template.ID(),
+		Usage:         response.Usage,
+	}, http.StatusOK, nil
+}
+
+// rankingTokensPerVariant is the max tokens of the ranking completion for each ranked cover letter
+const rankingTokensPerVariant = 100
+
+// coverLetterRankingPromptData is the data of the cover letter ranking prompt template
+type coverLetterRankingPromptData struct {
+	JobPosting   types.JobPosting
+	CoverLetters []rankedCoverLetter
+}
+
+type rankedCoverLetter struct {
+	Variant int
+	Content string
+}
+
+// extractJSONObject returns the JSON object of a completion, from its first opening brace to its last closing brace,
+// since models sometimes wrap the JSON in a code block or add a sentence around it
+func extractJSONObject(content string) (string, error) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return "", errors.New("no JSON object")
+	}
+	return content[start : end+1], nil
+}
+
+// extractJSONArray returns the JSON array of a completion, like extractJSONObject
+func extractJSONArray(content string) (string, error) {
+	start, end := strings.Index(content, "["), strings.LastIndex(content, "]")
+	if start < 0 || end < start {
+		return "", errors.New("no JSON array")
+	}
+	return content[start : end+1], nil
+}
+
+// parseCoverLetterRankings parses the JSON array of rankings of the completion, which must score every variant once
+func parseCoverLetterRankings(content string, variants int) ([]types.CoverLetterRanking, error) {
+	array, err := extractJSONArray(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cover letter ranking from the LLM provider: %w", err)
+	}
+	var rankings []types.CoverLetterRanking
+	if err := json.Unmarshal([]byte(array), &rankings); err != nil {
+		return nil, fmt.Errorf("invalid cover letter ranking from the LLM provider: %w", err)
+	}
+
+	ranked := make(map[int]bool, len(rankings))
+	for i, ranking := range rankings {
+		if ranking.Variant < 1 || ranking.Variant > variants || ranked[ranking.Variant] {
+			return nil, fmt.Errorf("invalid cover letter ranking from the LLM provider: unexpected variant %d", ranking.Variant)
+		}
+		ranked[ranking.Variant] = true
+		rankings[i].Score = min(max(ranking.Score, 0), 100)
+	}
+	if len(ranked) != variants {
+		return nil, fmt.Errorf("invalid cover letter ranking from the LLM provider: %d of %d variants ranked", len(ranked), variants)
+	}
+	return rankings, nil
+}
+
+// ReviseChatGPTCoverLetter applies a user instruction to a cover letter, continuing the conversation that generated it
+func (oa *OpenAIClient) ReviseChatGPTCoverLetter(c *gin.Context, coverLetter *types.CoverLetter, instruction string, s types.StoreClient) (*types.GeneratedCoverLetter, int, error) {
+	if len(coverLetter.Messages) == 0 {
+		return nil, http.StatusConflict, errors.New("cover letter has no conversation history to revise")
+	}
+	template, err := oa.prompts.Get(prompt.CoverLetterRevision)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	careerProfile, err := s.GetCareerProfileByID(coverLetter.ProfileID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	// The user edits are not part of the conversation, so they are sent with the instruction
+	data := coverLetterRevisionPromptData{Instruction: instruction}
+	if coverLetter.Edited {
+		data.EditedContent = coverLetter.Content
+	}
+	revisionPrompt, err := template.Render(data)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	letterPrompt := &coverLetterPrompt{
+		Messages:      append(slices.Clone(coverLetter.Messages), types.ChatGTPRequestMessage{Role: "user", Content: revisionPrompt}),
+		CareerProfile: careerProfile,
+		Version:       template.ID(),
+		JSON:          template.HasSection("response_format"),
+	}
+
+	request := letterPrompt.request(&coverLetter.Options)
+	response, statusCode, err := oa.complete(requestContext(c), request, letterPrompt.Version, nil)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	return oa.generatedCoverLetter(response, request, letterPrompt, &coverLetter.JobPosting)
+}
+
+// coverLetterRevisionPromptData is the data of the cover letter revision prompt template
+type coverLetterRevisionPromptData struct {
+	Instruction   string
+	EditedContent string
+}
+
+// generatedCoverLetter parses the completed cover letter, recording the model and prompt version used to generate it.
+// The JSON completions are rendered with the header and signature, and the text completions are parsed with ParseCoverLetter.
+func (oa *OpenAIClient) generatedCoverLetter(response *llm.Response, request llm.Request, letterPrompt *coverLetterPrompt, jobPosting *types.JobPosting) (*types.GeneratedCoverLetter, int, error) {
+	model := response.Model
+	if model == "" {
+		model = oa.requestModel(request)
+	}
+	// The completion is paid for even when it cannot be parsed
+	fail := func(statusCode int, err error) (*types.GeneratedCoverLetter, int, error) {
+		return nil, statusCode, &llm.UsageError{Err: err, Calls: response.Calls}
+	}
+
+	var coverLetter string
+	var structured *types.StructuredCoverLetter
+	if request.JSON && response.Content != "" {
+		var err error
+		structured, err = parseStructuredCoverLetter(response.Content)
+		if err != nil {
+			return fail(http.StatusBadGateway, fmt.Errorf("invalid cover letter from the LLM provider: %w", err))
+		}
+		coverLetter = renderCoverLetter(structuredCoverLetterBody(structured, letterPrompt.CareerProfile), letterPrompt.CareerProfile, jobPosting)
+	} else {
+		var err error
+		coverLetter, err = oa.ParseCoverLetter(&response.Content, letterPrompt.CareerProfile, jobPosting)
+		if err != nil {
+			return fail(http.StatusInternalServerError, err)
+		}
 	}
 
-	// Add career profile information to prompt
-	careerProfilePrompt, careerProfile, err := oa.GetCareerProfileInfoPrompt(profileId, s)
+	return &types.GeneratedCoverLetter{
+		Content:       coverLetter,
+		Structured:    structured,
+		Placeholders:  findPlaceholders(coverLetter),
+		Truncated:     letterPrompt.Truncated,
+		Summarized:    letterPrompt.Summarized,
+		Model:         model,
+		PromptVersion: letterPrompt.Version,
+		Usage:         response.Usage,
+		Calls:         response.Calls,
+		Messages:      append(slices.Clone(letterPrompt.Messages), types.ChatGTPRequestMessage{Role: "assistant", Content: response.Content}),
+		Date:          contentDate(coverLetter),
+	}, http.StatusOK, nil
+}
+
+// contentDate returns the current date when it is written in the cover letter content, to be replaced when it is cached
+func contentDate(coverLetter string) string {
+	if date := coverLetterDate(); strings.Contains(coverLetter, date) {
+		return date
+	}
+	return ""
+}
+
+// coverLetterPrompt is the rendered prompt to generate a cover letter
+type coverLetterPrompt struct {
+	Messages      []types.ChatGTPRequestMessage
+	CareerProfile *types.CareerProfile
+	// Version identifies the prompt template used, e.g. cover_letter/v1
+	Version string
+	// JSON is set when the template asks for a JSON completion, declaring a response_format section
+	JSON bool
+	// Truncated are the fields trimmed to fit the prompt in the context window of the model
+	Truncated []string
+	// Summarized are the fields summarized with the LLM provider to fit the prompt in the context window of the model
+	Summarized []string
+	// Calls are the provider calls made to build the prompt, e.g. to summarize the job details
+	Calls []types.ProviderCall
+}
+
+// addCalls adds the provider calls made to build the prompt to the calls and usage of the generated cover letter
+func (p *coverLetterPrompt) addCalls(coverLetter *types.GeneratedCoverLetter) {
+	if len(p.Calls) == 0 {
+		return
+	}
+	for _, call := range p.Calls {
+		coverLetter.Usage = coverLetter.Usage.Add(call.Usage)
+	}
+	coverLetter.Calls = append(slices.Clone(p.Calls), coverLetter.Calls...)
+}
+
+// usageError adds the provider calls made to build the prompt to the calls of a failed generation, to record their usage
+func (p *coverLetterPrompt) usageError(err error) error {
+	if len(p.Calls) == 0 {
+		return err
+	}
+	var usageError *llm.UsageError
+	if errors.As(err, &usageError) {
+		return &llm.UsageError{Err: usageError.Err, Calls: append(slices.Clone(p.Calls), usageError.Calls...)}
+	}
+	return &llm.UsageError{Err: err, Calls: slices.Clone(p.Calls)}
+}
+
+// request returns the completion request for the prompt, with the model, temperature and max tokens from the options
+func (p *coverLetterPrompt) request(options *types.CoverLetterOptions) llm.Request {
+	request := coverLetterRequest(p.Messages, options)
+	request.JSON = p.JSON
+	return request
+}
+
+// coverLetterPromptData is the data of the cover letter prompt template
+type coverLetterPromptData struct {
+	Paragraphs    int
+	WordCount     int
+	Tone          string
+	Language      string
+	JobPosting    types.JobPosting
+	CareerProfile string
+}
+
+// getCoverLetterPrompt returns the prompt messages to generate a cover letter for the job posting and career profile.
+// The prompt is fitted in the context window of the model with the completion, summarizing the job details first,
+// trimming them when the summary fails or is still too long, and then the summary of the career profile,
+// and returns 422 when it does not fit without them.
+func (oa *OpenAIClient) getCoverLetterPrompt(ctx context.Context, profileId uuid.UUID, jobPosting *types.JobPosting, options *types.CoverLetterOptions, s types.StoreClient) (*coverLetterPrompt, int, error) {
+	template, err := oa.prompts.Get(prompt.CoverLetter)
 	if err != nil {
-		return "", http.StatusInternalServerError, err
+		return nil, http.StatusInternalServerError, err
 	}
 
-	// Create cover letter prompt using the jobPosting data
+	careerProfile, err := s.GetCareerProfileByID(profileId)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	profileData := newCareerProfilePromptData(careerProfile)
+
+	// Create cover letter prompt using the jobPosting data and options
+	data := coverLetterPromptData{
+		Paragraphs: DefaultParagraphs,
+		WordCount:  DefaultWordCount,
+		JobPosting: *jobPosting,
+	}
 	emptyLinesPattern := `\s*\n`
 	re := regexp.MustCompile(emptyLinesPattern)
-	jobPostingDetails := re.ReplaceAllString(jobPosting.Details, "\n")
-	promptFormat := "Company:%s\nJob Role:%s\nDetails:\n%s\nSkills:%s"
-	prompt := fmt.Sprintf(promptFormat, jobPosting.CompanyName, jobPosting.JobRole, jobPostingDetails, jobPosting.Skills)
-	coverLetterPrompt := fmt.Sprintf("Write a cover letter for this job:\n%s", prompt)
-	if careerProfilePrompt != "" {
-		coverLetterPrompt = fmt.Sprintf("%s\n\n%s", coverLetterPrompt, careerProfilePrompt)
-	}
-
-	promptMessages = append(promptMessages, types.ChatGTPRequestMessage{
-		Role:    "user",
-		Content: coverLetterPrompt,
-	})
-	fmt.Printf("OpenAI prompt:\n%s\n", coverLetterPrompt)
-
-	requestBody := &types.ChatGPTRequest{
-		Model:       oa.model,
-		Messages:    promptMessages,
-		Temperature: 0.5,
-		MaxTokens:   512,
+	data.JobPosting.Details = re.ReplaceAllString(jobPosting.Details, "\n")
+	model := oa.provider.DefaultModel()
+	if options != nil {
+		if options.Paragraphs > 0 {
+			data.Paragraphs = options.Paragraphs
+		}
+		if options.WordCount > 0 {
+			data.WordCount = options.WordCount
+		}
+		data.Tone = options.Tone
+		if options.Language != "" {
+			data.Language = languageName(options.Language)
+		}
+		if options.Model != "" {
+			model = options.Model
+		}
+	}
+
+	letterPrompt := &coverLetterPrompt{
+		CareerProfile: careerProfile,
+		Version:       template.ID(),
+		JSON:          template.HasSection("response_format"),
+	}
+	render := func() (int, error) {
+		// Add career profile information to prompt
+		data.CareerProfile, err = oa.renderCareerProfilePrompt(profileData)
+		if err != nil {
+			return 0, err
+		}
+		systemPrompt, err := template.RenderSection("system", data)
+		if err != nil {
+			return 0, err
+		}
+		userPrompt, err := template.RenderSection("user", data)
+		if err != nil {
+			return 0, err
+		}
+		letterPrompt.Messages = []types.ChatGTPRequestMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		}
+		return oa.provider.CountTokens(model, letterPrompt.Messages), nil
 	}
-	requestBodyBytes, err := json.Marshal(requestBody)
+
+	promptTokens, err := render()
 	if err != nil {
-		return "", http.StatusInternalServerError, err
+		return nil, http.StatusInternalServerError, err
+	}
+	budget := llm.ContextWindow(model) - coverLetterMaxTokens(options)
+	if excess := promptTokens - budget; excess > 0 && data.JobPosting.Details != "" {
+		maxTokens := oa.countTokens(model, data.JobPosting.Details) - excess
+		if maxTokens >= MinJobDetailsSummaryTokens {
+			summary, truncated, call, err := oa.summarizeJobDetails(ctx, data.JobPosting.Details, model, maxTokens)
+			if call != nil {
+				letterPrompt.Calls = append(letterPrompt.Calls, *call)
+			}
+			if err != nil {
+				log.Printf("Error summarizing the job details, which are trimmed instead: %v", err)
+			} else {
+				data.JobPosting.Details = summary
+				letterPrompt.Summarized = append(letterPrompt.Summarized, "job_details")
+				if truncated {
+					letterPrompt.Truncated = append(letterPrompt.Truncated, "job_details")
+				}
+				if promptTokens, err = render(); err != nil {
+					return nil, http.StatusInternalServerError, letterPrompt.usageError(err)
+				}
+			}
+		}
 	}
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{{"job_details", &data.JobPosting.Details}, {"summary", &profileData.Summary}} {
+		if promptTokens <= budget || *field.value == "" {
+			continue
+		}
+		*field.value = oa.truncateToFit(model, *field.value, promptTokens-budget)
+		letterPrompt.Truncated = append(letterPrompt.Truncated, field.name)
+		if promptTokens, err = render(); err != nil {
+			return nil, http.StatusInternalServerError, letterPrompt.usageError(err)
+		}
+	}
+	if promptTokens > budget {
+		return nil, http.StatusUnprocessableEntity, letterPrompt.usageError(fmt.Errorf("the prompt has %d tokens, and only %d tokens of the context window of %s are left for it", promptTokens, max(budget, 0), model))
+	}
+	log.Printf("Cover letter prompt %s with %d tokens", template.ID(), promptTokens)
+	return letterPrompt, http.StatusOK, nil
+}
 
-	client := &http.Client{}
-	// Make a request to the OpenAI completions API using the defined model and messages (prompts)
-	req, err := http.NewRequest("POST", OpenAICompletionsUrl, bytes.NewReader(requestBodyBytes))
+// MinJobDetailsSummaryTokens is the min length of the summary of the job details, which are trimmed instead
+// when they must be shortened more
+const MinJobDetailsSummaryTokens = 64
+
+// jobDetailsSummaryPromptData is the data of the job details summary prompt template
+type jobDetailsSummaryPromptData struct {
+	Details  string
+	MaxWords int
+}
+
+// summarizeJobDetails summarizes the job details with the LLM provider in at most maxTokens tokens, trimming them first
+// when they do not fit in the context window of the model with the summary, and returns the provider call
+// to record its usage when the provider was called
+func (oa *OpenAIClient) summarizeJobDetails(ctx context.Context, details string, model string, maxTokens int) (summary string, truncated bool, call *types.ProviderCall, err error) {
+	template, err := oa.prompts.Get(prompt.JobDetailsSummary)
 	if err != nil {
-		return "", http.StatusInternalServerError, err
+		return "", false, nil, err
+	}
+	render := func(details string) ([]types.ChatGTPRequestMessage, error) {
+		// A token is about three quarters of a word
+		data := jobDetailsSummaryPromptData{Details: details, MaxWords: maxTokens * 3 / 4}
+		systemPrompt, err := template.RenderSection("system", data)
+		if err != nil {
+			return nil, err
+		}
+		userPrompt, err := template.RenderSection("user", data)
+		if err != nil {
+			return nil, err
+		}
+		return []types.ChatGTPRequestMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		}, nil
+	}
+	request := llm.Request{
+		Model:       model,
+		Temperature: creativityTemperatures["low"],
+		MaxTokens:   maxTokens,
+	}
+	if request.Messages, err = render(details); err != nil {
+		return "", false, nil, err
+	}
+	if excess := oa.provider.CountTokens(model, request.Messages) + maxTokens - llm.ContextWindow(model); excess > 0 {
+		// Trimming the details to the length of the summary leaves nothing to summarize
+		if oa.countTokens(model, details)-excess <= maxTokens {
+			return "", false, nil, fmt.Errorf("the job details are too long to be summarized in the context window of %s", model)
+		}
+		details, truncated = oa.truncateToFit(model, details, excess), true
+		if request.Messages, err = render(details); err != nil {
+			return "", false, nil, err
+		}
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+oa.apiKey)
 
-	// Send the request and handle the response
-	resp, err := client.Do(req)
+	response, err := oa.provider.ChatCompletion(ctx, request)
 	if err != nil {
-		return "", http.StatusInternalServerError, err
+		return "", false, nil, err
+	}
+	call = &types.ProviderCall{Model: model, PromptVersion: template.ID(), Usage: response.Usage}
+	if response.Model != "" {
+		call.Model = response.Model
 	}
+	summary = strings.TrimSpace(response.Content)
+	if summary == "" || response.FinishReason == "length" {
+		return "", false, call, errors.New("the summary of the job details is empty or was cut off at the max tokens")
+	}
+	return summary, truncated, call, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", http.StatusInternalServerError, err
+// CoverLetterCacheKey returns the cache key of a cover letter generation, which changes with the career profile,
+// the job posting, the options, the version of the cover letter prompt template, and the model
+func (oa *OpenAIClient) CoverLetterCacheKey(profileId uuid.UUID, jobPosting *types.JobPosting, options *types.CoverLetterOptions, s types.StoreClient) (string, error) {
+	template, err := oa.prompts.Get(prompt.CoverLetter)
+	if err != nil {
+		return "", err
 	}
+	careerProfile, err := s.GetCareerProfileByID(profileId)
+	if err != nil {
+		return "", err
+	}
+	model := oa.provider.DefaultModel()
+	if options != nil && options.Model != "" {
+		model = options.Model
+	}
+	return cache.Key(careerProfile, jobPosting, options, template.ID(), model), nil
+}
 
-	defer resp.Body.Close()
+// truncatedSuffix marks the texts trimmed to fit in the prompt
+const truncatedSuffix = " (truncated)"
+
+// truncateToFit removes at least the given number of tokens, counted by the provider for the model, from the end of
+// the text, marking it as truncated. The text is truncated on its estimated tokens, so the tokens to remove are scaled
+// by the ratio of the estimated and counted tokens of the text.
+func (oa *OpenAIClient) truncateToFit(model string, text string, tokens int) string {
+	estimated := llm.EstimateTokens(text)
+	if counted := oa.countTokens(model, text); counted > 0 {
+		tokens = (tokens*estimated + counted - 1) / counted
+	}
+	maxTokens := estimated - tokens - llm.EstimateTokens(truncatedSuffix)
+	return llm.TruncateTokens(text, maxTokens) + truncatedSuffix
+}
 
-	responseBody, err := io.ReadAll(resp.Body)
+// countTokens returns the number of tokens of a text counted by the provider for the model, without the overhead of
+// its message
+func (oa *OpenAIClient) countTokens(model string, text string) int {
+	return oa.provider.CountTokens(model, []types.ChatGTPRequestMessage{{Role: "user", Content: text}}) -
+		oa.provider.CountTokens(model, []types.ChatGTPRequestMessage{{Role: "user"}})
+}
+
+// Cover letter defaults, used when the options are omitted
+const (
+	DefaultWordCount  = 300
+	DefaultParagraphs = 3
+	DefaultMaxTokens  = 512
+)
+
+// MaxConcurrentVariants is the max number of completions requested in parallel when generating cover letter variants
+const MaxConcurrentVariants = 3
+
+// MaxContinuations is the max number of times a completion that stopped at the max tokens is continued
+const MaxContinuations = 1
+
+// creativityTemperatures maps the creativity options to the sampling temperature of the completion
+var creativityTemperatures = map[string]float32{
+	"low":    0.2,
+	"medium": 0.5,
+	"high":   0.9,
+}
+
+// coverLetterRequest returns the completion request for the prompt messages,
+// with the model, temperature and max tokens from the options
+func coverLetterRequest(promptMessages []types.ChatGTPRequestMessage, options *types.CoverLetterOptions) llm.Request {
+	if options == nil {
+		options = &types.CoverLetterOptions{}
+	}
+	temperature, ok := creativityTemperatures[options.Creativity]
+	if !ok {
+		temperature = creativityTemperatures["medium"]
+	}
+	return llm.Request{
+		Model:       options.Model,
+		Messages:    promptMessages,
+		Temperature: temperature,
+		MaxTokens:   coverLetterMaxTokens(options),
+	}
+}
+
+// coverLetterMaxTokens returns the max tokens of the cover letter completion, scaled with the requested length,
+// so longer letters are not truncated
+func coverLetterMaxTokens(options *types.CoverLetterOptions) int {
+	if options == nil || options.WordCount <= 0 {
+		return DefaultMaxTokens
+	}
+	return DefaultMaxTokens * options.WordCount / DefaultWordCount
+}
+
+// complete requests a completion, streamed to onDelta when it is not nil, continuing it in the same conversation
+// when it stops at the max tokens, at most MaxContinuations times. A completion that is still cut off returns 502.
+// The usage of each call is kept in the Calls of the response, and the errors after the first call are a
+// llm.UsageError with the calls that were made, including the estimated usage of an interrupted stream.
+func (oa *OpenAIClient) complete(ctx context.Context, request llm.Request, promptVersion string, onDelta llm.StreamHandler) (*llm.Response, int, error) {
+	var calls []types.ProviderCall
+	call := func(usage types.TokenUsage) types.ProviderCall {
+		return types.ProviderCall{Model: oa.requestModel(request), PromptVersion: promptVersion, Usage: usage}
+	}
+	send := func(request llm.Request) (*llm.Response, error) {
+		if onDelta == nil {
+			return oa.provider.ChatCompletion(ctx, request)
+		}
+		var streamed strings.Builder
+		response, err := oa.provider.StreamChatCompletion(ctx, request, func(delta string) error {
+			streamed.WriteString(delta)
+			return onDelta(delta)
+		})
+		if err != nil && streamed.Len() > 0 {
+			// The provider does not report the usage of an interrupted stream, which is estimated from what was streamed
+			partial := types.TokenUsage{
+				PromptTokens:     oa.provider.CountTokens(request.Model, request.Messages),
+				CompletionTokens: llm.EstimateTokens(streamed.String()),
+			}
+			partial.TotalTokens = partial.PromptTokens + partial.CompletionTokens
+			calls = append(calls, call(partial))
+		}
+		return response, err
+	}
+	fail := func(statusCode int, err error) (*llm.Response, int, error) {
+		if len(calls) == 0 {
+			return nil, statusCode, err
+		}
+		return nil, statusCode, &llm.UsageError{Err: err, Calls: calls}
+	}
+
+	response, err := send(request)
 	if err != nil {
-		return "", http.StatusInternalServerError, err
+		return fail(llm.HTTPStatus(err), err)
+	}
+	calls = append(calls, call(response.Usage))
+	for continuations := 0; response.FinishReason == "length"; continuations++ {
+		if continuations == MaxContinuations {
+			return fail(http.StatusBadGateway, fmt.Errorf("the completion was cut off at the max tokens (%d), even after continuing it", request.MaxTokens))
+		}
+		template, err := oa.prompts.Get(prompt.CoverLetterContinuation)
+		if err != nil {
+			return fail(http.StatusInternalServerError, err)
+		}
+		continuationPrompt, err := template.Render(nil)
+		if err != nil {
+			return fail(http.StatusInternalServerError, err)
+		}
+
+		// The continuation completes the text of the previous reply, so it is not a JSON object by itself
+		continuation := request
+		continuation.JSON = false
+		continuation.Messages = append(slices.Clone(request.Messages),
+			types.ChatGTPRequestMessage{Role: "assistant", Content: response.Content},
+			types.ChatGTPRequestMessage{Role: "user", Content: continuationPrompt},
+		)
+		next, err := send(continuation)
+		if err != nil {
+			return fail(llm.HTTPStatus(err), err)
+		}
+		calls = append(calls, call(next.Usage))
+		response = &llm.Response{
+			Model:        response.Model,
+			Content:      response.Content + next.Content,
+			FinishReason: next.FinishReason,
+			Usage:        response.Usage.Add(next.Usage),
+		}
 	}
+	response.Calls = calls
+	return response, http.StatusOK, nil
+}
 
-	var responseData types.ChatGPTResponseData
-	if err := json.Unmarshal(responseBody, &responseData); err != nil {
-		return "", http.StatusInternalServerError, err
+// failedCompletion returns the error of a completion that cannot be used, e.g. when it cannot be parsed, as a
+// llm.UsageError with its provider call, since the completion is paid for anyway
+func (oa *OpenAIClient) failedCompletion(err error, request llm.Request, response *llm.Response, promptVersion string) error {
+	model := response.Model
+	if model == "" {
+		model = oa.requestModel(request)
 	}
-	coverLetter := responseData.Choices[0].Message.Content
-	coverLetter, err = oa.ParseCoverLetter(&coverLetter, careerProfile, jobPosting)
+	return &llm.UsageError{Err: err, Calls: []types.ProviderCall{{Model: model, PromptVersion: promptVersion, Usage: response.Usage}}}
+}
+
+// requestModel returns the model of a request, which is the default model of the provider when not set
+func (oa *OpenAIClient) requestModel(request llm.Request) string {
+	if request.Model == "" {
+		return oa.provider.DefaultModel()
+	}
+	return request.Model
+}
+
+// languageName returns the English name of a BCP 47 language tag (e.g. pt-BR -> Brazilian Portuguese)
+func languageName(tag string) string {
+	languageTag, err := language.Parse(tag)
 	if err != nil {
-		return "", http.StatusInternalServerError, err
+		return tag
+	}
+	if name := display.English.Tags().Name(languageTag); name != "" {
+		return name
 	}
+	return tag
+}
 
-	// Return the content for the last received message from OpenAI if the response was successful
-	return coverLetter, http.StatusOK, nil
+// requestContext returns the context of the request being handled, so LLM calls are cancelled with it
+func requestContext(c *gin.Context) context.Context {
+	if c == nil || c.Request == nil {
+		return context.Background()
+	}
+	return c.Request.Context()
 }
 
 // GetCareerProfileInfoPrompt returns a prompt string with the CareerProfile data retrieved using the given email
 func (oa *OpenAIClient) GetCareerProfileInfoPrompt(profileId uuid.UUID, s types.StoreClient) (string, *types.CareerProfile, error) {
-	info := ""
-
 	careerProfile, err := s.GetCareerProfileByID(profileId)
 	if err != nil {
 		return "", &types.CareerProfile{}, err
 	}
 
-	// Concatenate each of the career profile fields into a single message
-	var builder strings.Builder
-	builder.WriteString("Here is my career profile information:")
-	if careerProfile.Headline != "" {
-		builder.WriteString(fmt.Sprintf("\nHeadline:%s,", careerProfile.Headline))
+	info, err := oa.renderCareerProfilePrompt(newCareerProfilePromptData(careerProfile))
+	if err != nil {
+		return "", careerProfile, err
 	}
-	if careerProfile.ExperienceYears > 0 {
-		builder.WriteString(fmt.Sprintf("\nExperience:%d years,", careerProfile.ExperienceYears))
+
+	return info, careerProfile, nil
+}
+
+// renderCareerProfilePrompt renders each of the career profile fields into a single message
+func (oa *OpenAIClient) renderCareerProfilePrompt(data careerProfilePromptData) (string, error) {
+	template, err := oa.prompts.Get(prompt.CareerProfile)
+	if err != nil {
+		return "", err
 	}
-	if careerProfile.Skills != nil && len(*careerProfile.Skills) > 0 {
-		builder.WriteString(fmt.Sprintf("\nSkills:%s,", strings.Join(*careerProfile.Skills, ",")))
+	return template.Render(data)
+}
+
+// careerProfilePromptData is the data of the career profile prompt template
+type careerProfilePromptData struct {
+	Headline        string
+	ExperienceYears uint
+	Skills          []string
+	Summary         string
+}
+
+func newCareerProfilePromptData(careerProfile *types.CareerProfile) careerProfilePromptData {
+	data := careerProfilePromptData{
+		Headline:        careerProfile.Headline,
+		ExperienceYears: careerProfile.ExperienceYears,
+	}
+	if careerProfile.Skills != nil {
+		data.Skills = *careerProfile.Skills
 	}
-	if careerProfile.Summary != nil && *careerProfile.Summary != "" {
-		builder.WriteString(fmt.Sprintf("\nSummary:%s,", *careerProfile.Summary))
+	if careerProfile.Summary != nil {
+		data.Summary = *careerProfile.Summary
 	}
-	info = builder.String()
+	return data
+}
 
-	return info, careerProfile, nil
+// workHistoryPosition is a position of the work history, numbered for the completions to refer to it
+type workHistoryPosition struct {
+	Number int
+	types.WorkExperience
+}
+
+// newWorkHistoryPositions returns the numbered positions of the work history of the career profile
+func newWorkHistoryPositions(careerProfile *types.CareerProfile) []workHistoryPosition {
+	var positions []workHistoryPosition
+	if careerProfile.WorkHistory != nil {
+		for i, workExperience := range *careerProfile.WorkHistory {
+			positions = append(positions, workHistoryPosition{Number: i + 1, WorkExperience: workExperience})
+		}
+	}
+	return positions
 }
 
-// ParseCoverLetter adds contact information to the cover letter from the CareerProfile
+// ParseCoverLetter adds the header with the contact information from the CareerProfile to a cover letter
+// generated as text, replacing the placeholders in brackets that have a known value
 func (oa *OpenAIClient) ParseCoverLetter(coverLetter *string, careerProfile *types.CareerProfile, jobPosting *types.JobPosting) (string, error) {
 	if *coverLetter == "" {
 		return "", errors.New("received an empty cover letter from OpenAI")
 	}
 
-	template := "[Your Name]\n[Your Address]\n[City, State, ZIP]\n[Email Address]\n[Phone Number]\n[Date]\n\n[Employer's Name]\n[Company Name]\n[Company Address]\n[City, State, ZIP]\n\n"
-	parsedLetter := template + *coverLetter
-
-	year, month, day := time.Now().Date()
 	coverLetterKeywords := map[string]string{
-		"[Your Name]":             fmt.Sprintf("%s %s", careerProfile.FirstName, careerProfile.LastName),
-		"[Your Address]":          careerProfile.ContactInfo.Address,
-		"[Email Address]":         careerProfile.ContactInfo.Email,
-		"[Phone Number]":          careerProfile.ContactInfo.Phone,
-		"[Date]":                  fmt.Sprintf("%s %d, %d", month.String(), day, year),
-		"[Today's Date]":          fmt.Sprintf("%s %d, %d", month.String(), day, year),
+		"[Your Name]":             fullName(careerProfile),
+		"[Date]":                  coverLetterDate(),
+		"[Today's Date]":          coverLetterDate(),
 		"[Employer's Name]":       "Hiring Manager",
 		"[Recipient's Name]":      "Hiring Manager",
+		"[Hiring Manager]":        "Hiring Manager",
 		"[Company Name]":          jobPosting.CompanyName,
-		"[Company Address]":       "",
-		"[City, State, ZIP]":      "",
-		"[City, State, ZIP Code]": "",
+		"[Job Title]":             jobPosting.JobRole,
+		"[Position]":              jobPosting.JobRole,
+		"[Position Title]":        jobPosting.JobRole,
+		"[Job Role]":              jobPosting.JobRole,
+		"[Hiring Manager's Name]": "Hiring Manager",
+	}
+	if careerProfile.ContactInfo != nil {
+		coverLetterKeywords["[Email Address]"] = careerProfile.ContactInfo.Email
+		coverLetterKeywords["[Phone Number]"] = careerProfile.ContactInfo.Phone
 	}
 
+	parsedLetter := *coverLetter
 	for keyword, value := range coverLetterKeywords {
-		parsedLetter = strings.Replace(parsedLetter, keyword, value, -1)
+		if value != "" {
+			parsedLetter = strings.ReplaceAll(parsedLetter, keyword, value)
+		}
 	}
 
-	return parsedLetter, nil
+	return renderCoverLetter(strings.TrimSpace(parsedLetter), careerProfile, jobPosting), nil
 }
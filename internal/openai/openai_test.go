@@ -0,0 +1,643 @@
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/internal/llm"
+	"github.com/jonada182/cover-letter-ai-api/internal/openai/openaitest"
+	"github.com/jonada182/cover-letter-ai-api/internal/prompt"
+	"github.com/jonada182/cover-letter-ai-api/mocks"
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOpenAIClient(t *testing.T) {
+	profileId := uuid.New()
+	summary := "I lead teams"
+	careerProfile := &types.CareerProfile{
+		ID:              profileId,
+		FirstName:       "John",
+		LastName:        "Doe",
+		Headline:        "Manager",
+		ExperienceYears: 5,
+		Skills:          &[]string{"Leadership", "Planning"},
+		Summary:         &summary,
+		ContactInfo:     &types.ContactInfo{Email: "john@email.com", Phone: "555-0100"},
+	}
+	jobPosting := &types.JobPosting{
+		CompanyName: "Acme",
+		JobRole:     "Operations Manager",
+		Details:     "Lead the operations team\n\n\nReport to the COO",
+		Skills:      "Management",
+	}
+
+	// setup returns a client using the fake server, and a store mock returning the career profile
+	setup := func(t *testing.T) (*openaitest.Server, *OpenAIClient, *mocks.MockStore, *gin.Context) {
+		ctrl := gomock.NewController(t)
+		mockStore := mocks.NewMockStore(ctrl)
+		mockStore.EXPECT().GetCareerProfileByID(gomock.Eq(profileId)).Return(careerProfile, nil).AnyTimes()
+		server := openaitest.NewServer(t)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/cover-letter", nil)
+		return server, NewOpenAIClientWithProvider(server.Provider()), mockStore, c
+	}
+
+	t.Run("GenerateChatGPTCoverLetter", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+
+		coverLetter, statusCode, err := client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		// The header and signature are added to the JSON completion, without lines for the missing contact information
+		assert.True(t, strings.HasPrefix(coverLetter.Content, "John Doe\njohn@email.com\n555-0100\n\n"))
+		assert.Contains(t, coverLetter.Content, "\n\nHiring Manager\nAcme\n\nDear Hiring Manager,\n\nI am excited to apply for this role.\n\nSincerely,\nJohn Doe")
+		assert.Equal(t, &types.StructuredCoverLetter{Salutation: "Dear Hiring Manager,", Paragraphs: []string{"I am excited to apply for this role."}, Closing: "Sincerely,"}, coverLetter.Structured)
+		assert.Empty(t, coverLetter.Placeholders)
+		assert.Equal(t, llm.GPT35, coverLetter.Model)
+		assert.Equal(t, "cover_letter/v2", coverLetter.PromptVersion)
+		// The date is recorded to be replaced in the cached cover letters
+		assert.Equal(t, time.Now().Format(types.CoverLetterDateLayout), coverLetter.Date)
+		assert.Contains(t, coverLetter.Content, coverLetter.Date)
+
+		// Check the prompt sent to the chat completions API
+		requests := server.Requests()
+		assert.Len(t, requests, 1)
+		assert.Equal(t, llm.GPT35, requests[0].Model)
+		assert.Equal(t, float32(0.5), requests[0].Temperature)
+		assert.Equal(t, 512, requests[0].MaxTokens)
+		assert.False(t, requests[0].Stream)
+		assert.Equal(t, &types.ChatGPTResponseFormat{Type: "json_object"}, requests[0].ResponseFormat)
+		assert.Len(t, requests[0].Messages, 2)
+		assert.Equal(t, "system", requests[0].Messages[0].Role)
+		assert.Equal(t, "user", requests[0].Messages[1].Role)
+		prompt := requests[0].Messages[1].Content
+		assert.Contains(t, prompt, "Company:Acme\nJob Role:Operations Manager\nDetails:\nLead the operations team\nReport to the COO\nSkills:Management")
+		assert.Contains(t, prompt, "Headline:Manager,\nExperience:5 years,\nSkills:Leadership,Planning,\nSummary:I lead teams,")
+	})
+
+	t.Run("options", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		options := &types.CoverLetterOptions{
+			Tone:       "enthusiastic",
+			WordCount:  450,
+			Paragraphs: 4,
+			Language:   "es",
+			Creativity: "high",
+			Model:      llm.GPT4,
+		}
+
+		_, statusCode, err := client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, options, mockStore)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+
+		request := server.Requests()[0]
+		assert.Equal(t, llm.GPT4, request.Model)
+		assert.Equal(t, float32(0.9), request.Temperature)
+		assert.Equal(t, 768, request.MaxTokens)
+		assert.True(t, strings.HasPrefix(request.Messages[0].Content, "You write the body of cover letters when I give you job details. Limit: 4 paragraphs, 450 words. Use an enthusiastic and energetic tone. Write it in Spanish.\nReply only with a JSON object"))
+	})
+
+	t.Run("default options", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+
+		_, _, err := client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore)
+		assert.NoError(t, err)
+		request := server.Requests()[0]
+		assert.True(t, strings.HasPrefix(request.Messages[0].Content, "You write the body of cover letters when I give you job details. Limit: 3 paragraphs, 300 words.\nReply only with a JSON object"))
+		assert.Equal(t, "Brazilian Portuguese", languageName("pt-BR"))
+	})
+
+	t.Run("job posting url", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		jobPostingWithURL := *jobPosting
+		jobPostingWithURL.URL = "https://acme.com/jobs/1"
+
+		_, _, err := client.GenerateChatGPTCoverLetter(c, profileId, &jobPostingWithURL, nil, mockStore)
+		assert.NoError(t, err)
+		assert.Contains(t, server.Requests()[0].Messages[1].Content, "Job Role:Operations Manager\nURL:https://acme.com/jobs/1\nDetails:\n")
+	})
+
+	t.Run("StreamChatGPTCoverLetter", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		server.Enqueue(openaitest.Response{Chunks: []string{`{"salutation": "Dear Hiring Manager,", "para`, `graphs": ["I am `, `a great fit.", "I \u00e9`, `lan\n"], "closing": "Sincerely,"}`}})
+
+		var deltas []string
+		coverLetter, statusCode, err := client.StreamChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore, func(delta string) error {
+			deltas = append(deltas, delta)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		// The text of the JSON completion is streamed
+		assert.Equal(t, []string{"Dear Hiring Manager,", "\n\nI am ", "a great fit.\n\nI é", "lan\n\n\nSincerely,"}, deltas)
+		assert.True(t, strings.HasSuffix(coverLetter.Content, "Dear Hiring Manager,\n\nI am a great fit.\n\nI élan\n\nSincerely,\nJohn Doe"))
+		assert.True(t, server.Requests()[0].Stream)
+	})
+
+	t.Run("GenerateChatGPTCoverLetterVariants", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		server.Enqueue(openaitest.CoverLetterCompletion("First draft."), openaitest.CoverLetterCompletion("Second draft."), openaitest.CoverLetterCompletion("Third draft."), openaitest.CoverLetterCompletion("Fourth draft."))
+
+		coverLetters, statusCode, err := client.GenerateChatGPTCoverLetterVariants(c, profileId, jobPosting, nil, 4, mockStore)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Len(t, coverLetters, 4)
+		var contents []string
+		for _, coverLetter := range coverLetters {
+			assert.Equal(t, "cover_letter/v2", coverLetter.PromptVersion)
+			assert.Greater(t, coverLetter.Usage.TotalTokens, 0)
+			contents = append(contents, coverLetter.Structured.Paragraphs[0])
+		}
+		assert.ElementsMatch(t, []string{"First draft.", "Second draft.", "Third draft.", "Fourth draft."}, contents)
+		assert.Len(t, server.Requests(), 4)
+	})
+
+	t.Run("variant failure", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		server.Enqueue(openaitest.ServerError(), openaitest.ServerError())
+
+		_, statusCode, err := client.GenerateChatGPTCoverLetterVariants(c, profileId, jobPosting, nil, 2, mockStore)
+		assert.EqualError(t, err, "openai API error (status 500, server_error): The server had an error while processing your request")
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+	})
+
+	t.Run("RankChatGPTCoverLetters", func(t *testing.T) {
+		server, client, _, c := setup(t)
+		server.Enqueue(openaitest.Completion("```json\n[{\"variant\": 2, \"score\": 90, \"reason\": \"Specific\"}, {\"variant\": 1, \"score\": 120, \"reason\": \"Generic\"}]\n```"))
+		coverLetters := []types.GeneratedCoverLetter{{Content: "First draft."}, {Content: "Second draft."}}
+
+		rankings, statusCode, err := client.RankChatGPTCoverLetters(c, jobPosting, nil, coverLetters)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, []types.CoverLetterRanking{{Variant: 2, Score: 90, Reason: "Specific"}, {Variant: 1, Score: 100, Reason: "Generic"}}, rankings.Rankings)
+		assert.Equal(t, "cover_letter_ranking/v1", rankings.PromptVersion)
+
+		request := server.Requests()[0]
+		assert.Equal(t, float32(0), request.Temperature)
+		assert.Contains(t, request.Messages[1].Content, "Job Role:Operations Manager")
+		assert.Contains(t, request.Messages[1].Content, "Cover letter 1:\nFirst draft.\n\nCover letter 2:\nSecond draft.")
+	})
+
+	t.Run("invalid ranking", func(t *testing.T) {
+		server, client, _, c := setup(t)
+		server.Enqueue(openaitest.Completion(`[{"variant": 1, "score": 90, "reason": "Specific"}]`), openaitest.Completion("The first one is better"))
+		coverLetters := []types.GeneratedCoverLetter{{Content: "First draft."}, {Content: "Second draft."}}
+
+		_, statusCode, err := client.RankChatGPTCoverLetters(c, jobPosting, nil, coverLetters)
+		assert.EqualError(t, err, "invalid cover letter ranking from the LLM provider: 1 of 2 variants ranked")
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+		var usageError *llm.UsageError
+		if assert.ErrorAs(t, err, &usageError) && assert.Len(t, usageError.Calls, 1) {
+			assert.Equal(t, "cover_letter_ranking/v1", usageError.Calls[0].PromptVersion)
+		}
+
+		_, _, err = client.RankChatGPTCoverLetters(c, jobPosting, nil, coverLetters)
+		assert.EqualError(t, err, "invalid cover letter ranking from the LLM provider: no JSON array")
+	})
+
+	t.Run("ParseChatGPTJobPosting", func(t *testing.T) {
+		server, client, _, c := setup(t)
+		server.Enqueue(
+			openaitest.Completion(`{"company_name": "Acme", "job_role": "Operations Manager", "location": null, "seniority": "lead", "salary": {"min": 0, "max": 0, "currency": null, "period": null}, "required_skills": ["Management"], "nice_to_have_skills": [], "responsibilities": ["Lead the operations team"]}`),
+			openaitest.Completion("Acme is hiring an Operations Manager"),
+		)
+
+		generated, statusCode, err := client.ParseChatGPTJobPosting(c, "Operations Manager at Acme\nLead the operations team")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, types.ParsedJobPosting{
+			CompanyName:      "Acme",
+			JobRole:          "Operations Manager",
+			Seniority:        "lead",
+			RequiredSkills:   []string{"Management"},
+			NiceToHaveSkills: []string{},
+			Responsibilities: []string{"Lead the operations team"},
+		}, generated.JobPosting)
+		assert.Equal(t, "job_posting/v1", generated.PromptVersion)
+
+		request := server.Requests()[0]
+		assert.Equal(t, float32(0), request.Temperature)
+		assert.Equal(t, "json_object", request.ResponseFormat.Type)
+		assert.Contains(t, request.Messages[0].Content, `"seniority": one of intern, junior, mid, senior, lead, principal, director, executive`)
+		assert.Equal(t, "Job posting:\nOperations Manager at Acme\nLead the operations team", request.Messages[1].Content)
+
+		_, statusCode, err = client.ParseChatGPTJobPosting(c, "Operations Manager at Acme")
+		assert.EqualError(t, err, "invalid job posting from the LLM provider: no JSON object")
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+		var usageError *llm.UsageError
+		if assert.ErrorAs(t, err, &usageError) && assert.Len(t, usageError.Calls, 1) {
+			assert.Equal(t, "job_posting/v1", usageError.Calls[0].PromptVersion)
+		}
+	})
+
+	t.Run("AnalyzeChatGPTSkillsGap", func(t *testing.T) {
+		server, client, _, c := setup(t)
+		server.Enqueue(
+			openaitest.Completion("```json\n{\"score\": 140, \"explanation\": \"A seasoned manager.\", \"matched_skills\": [\"Management\"], \"missing_skills\": [], \"transferable_skills\": [{\"skill\": \"Logistics\", \"profile_skills\": [\"Planning\"], \"explanation\": \"Planning operations.\"}, {\"skill\": \"\"}]}\n```"),
+			openaitest.Completion(`{"score": "high"}`),
+		)
+		keywordMatch := &types.SkillsGapAnalysis{MatchedSkills: []string{"Management"}, MissingSkills: []string{"Logistics"}}
+
+		generated, statusCode, err := client.AnalyzeChatGPTSkillsGap(c, careerProfile, jobPosting, keywordMatch)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, types.SkillsGapAnalysis{
+			Score:              100,
+			Explanations:       []string{"A seasoned manager."},
+			MatchedSkills:      []string{"Management"},
+			MissingSkills:      []string{},
+			TransferableSkills: []types.TransferableSkill{{Skill: "Logistics", ProfileSkills: []string{"Planning"}, Explanation: "Planning operations."}},
+		}, generated.Analysis)
+		assert.Equal(t, "skills_gap/v1", generated.PromptVersion)
+
+		request := server.Requests()[0]
+		assert.Equal(t, "json_object", request.ResponseFormat.Type)
+		assert.Contains(t, request.Messages[1].Content, "Job Role:Operations Manager")
+		assert.Contains(t, request.Messages[1].Content, "Skills:Leadership,Planning,")
+		assert.Contains(t, request.Messages[1].Content, "Skills of the job found in the career profile:Management\nSkills of the job not found in the career profile:Logistics")
+
+		_, statusCode, err = client.AnalyzeChatGPTSkillsGap(c, careerProfile, jobPosting, keywordMatch)
+		assert.ErrorContains(t, err, "invalid skills gap analysis from the LLM provider")
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+		var usageError *llm.UsageError
+		if assert.ErrorAs(t, err, &usageError) && assert.Len(t, usageError.Calls, 1) {
+			assert.Equal(t, "skills_gap/v1", usageError.Calls[0].PromptVersion)
+		}
+	})
+
+	t.Run("TailorChatGPTResume", func(t *testing.T) {
+		server, client, _, c := setup(t)
+		server.Enqueue(
+			openaitest.Completion(`{"summary": "Operations manager leading teams.", "skills": ["planning", "Logistics"], "experience": [{"position": 2, "bullets": ["- Planned the budget", " "]}, {"position": 9, "bullets": ["Invented"]}]}`),
+			openaitest.Completion(`{"skills": []}`),
+		)
+		withWorkHistory := *careerProfile
+		withWorkHistory.WorkHistory = &[]types.WorkExperience{
+			{Company: "Initech", Title: "Manager", StartDate: "Jan 2020", Highlights: []string{"Led a team of 5"}},
+			{Company: "Globex", Title: "Coordinator", Location: "Springfield", StartDate: "2017", EndDate: "2019", Highlights: []string{"Managed budgets"}},
+		}
+
+		generated, statusCode, err := client.TailorChatGPTResume(c, &withWorkHistory, jobPosting)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		// The skills of the profile are reordered without the ones it does not have, and the positions the completion
+		// left out keep their highlights
+		assert.Equal(t, types.Resume{
+			Summary: "Operations manager leading teams.",
+			Skills:  []string{"Planning", "Leadership"},
+			Experience: []types.ResumeExperience{
+				{Company: "Initech", Title: "Manager", StartDate: "Jan 2020", Bullets: []string{"Led a team of 5"}},
+				{Company: "Globex", Title: "Coordinator", Location: "Springfield", StartDate: "2017", EndDate: "2019", Bullets: []string{"Planned the budget"}},
+			},
+		}, generated.Resume)
+		assert.Equal(t, "resume/v1", generated.PromptVersion)
+
+		request := server.Requests()[0]
+		assert.Equal(t, "json_object", request.ResponseFormat.Type)
+		assert.Contains(t, request.Messages[1].Content, "Job Role:Operations Manager")
+		assert.Contains(t, request.Messages[1].Content, "Work history:\n1. Manager at Initech (Jan 2020 - Present)\n- Led a team of 5\n2. Coordinator at Globex, Springfield (2017 - 2019)\n- Managed budgets")
+
+		_, statusCode, err = client.TailorChatGPTResume(c, &withWorkHistory, jobPosting)
+		assert.EqualError(t, err, "invalid resume from the LLM provider: no summary")
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+		// The completion is paid for even when it cannot be parsed, or was cut off
+		var usageError *llm.UsageError
+		if assert.ErrorAs(t, err, &usageError) && assert.Len(t, usageError.Calls, 1) {
+			assert.Equal(t, "resume/v1", usageError.Calls[0].PromptVersion)
+		}
+		server.Enqueue(openaitest.Response{Content: `{"summary": "Operations`, FinishReason: "length"})
+		_, statusCode, err = client.TailorChatGPTResume(c, &withWorkHistory, jobPosting)
+		assert.EqualError(t, err, "the resume was cut off at the max tokens (2048)")
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+		assert.ErrorAs(t, err, &usageError)
+	})
+
+	t.Run("PrepareChatGPTInterview", func(t *testing.T) {
+		server, client, _, c := setup(t)
+		server.Enqueue(
+			openaitest.Completion(`{"questions": [{"question": "How do you plan a quarter?", "category": "Behavioral", "suggested_answer": {"situation": "Budget cuts", "task": "Plan the quarter", "action": "Prioritized the projects", "result": "Met the goals"}}, {"question": " ", "category": "technical"}, {"question": "Why Acme?", "category": "culture"}], "questions_to_ask": ["What are the goals of the team?", ""]}`),
+			openaitest.Completion(`{"questions": [], "questions_to_ask": ["Why?"]}`),
+		)
+		withWorkHistory := *careerProfile
+		withWorkHistory.WorkHistory = &[]types.WorkExperience{{Company: "Initech", Title: "Manager", StartDate: "2020", Highlights: []string{"Led a team of 5"}}}
+		notes := "Panel with the COO"
+		interviews := []types.JobApplicationEvent{{Description: "Onsite interview", Date: "2023-10-10", AdditionalNotes: &notes}}
+
+		generated, statusCode, err := client.PrepareChatGPTInterview(c, &withWorkHistory, jobPosting, interviews)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, types.InterviewPrep{
+			Questions: []types.InterviewQuestion{
+				{Question: "How do you plan a quarter?", Category: "behavioral", SuggestedAnswer: types.StarAnswer{Situation: "Budget cuts", Task: "Plan the quarter", Action: "Prioritized the projects", Result: "Met the goals"}},
+				{Question: "Why Acme?", Category: "general"},
+			},
+			QuestionsToAsk: []string{"What are the goals of the team?"},
+		}, generated.InterviewPrep)
+		assert.Equal(t, "interview_prep/v1", generated.PromptVersion)
+
+		request := server.Requests()[0]
+		assert.Equal(t, "json_object", request.ResponseFormat.Type)
+		assert.Contains(t, request.Messages[1].Content, "Interviews:\n- 2023-10-10: Onsite interview (Panel with the COO)")
+		assert.Contains(t, request.Messages[1].Content, "Work history:\n1. Manager at Initech (2020 - Present)\n- Led a team of 5")
+
+		_, statusCode, err = client.PrepareChatGPTInterview(c, careerProfile, jobPosting, nil)
+		assert.EqualError(t, err, "invalid interview prep from the LLM provider: no questions")
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+		assert.NotContains(t, server.Requests()[1].Messages[1].Content, "Interviews:")
+		var usageError *llm.UsageError
+		if assert.ErrorAs(t, err, &usageError) && assert.Len(t, usageError.Calls, 1) {
+			assert.Equal(t, "interview_prep/v1", usageError.Calls[0].PromptVersion)
+		}
+		server.Enqueue(openaitest.Response{Content: `{"questions": [{"question": "Why`, FinishReason: "length"})
+		_, statusCode, err = client.PrepareChatGPTInterview(c, careerProfile, jobPosting, nil)
+		assert.EqualError(t, err, "the interview prep was cut off at the max tokens (2048)")
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+		assert.ErrorAs(t, err, &usageError)
+	})
+
+	t.Run("ReviseChatGPTCoverLetter", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		server.Enqueue(openaitest.CoverLetterCompletion("I am a great fit."), openaitest.CoverLetterCompletion("I led teams."))
+
+		generated, _, err := client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore)
+		assert.NoError(t, err)
+		assert.Len(t, generated.Messages, 3)
+		assert.Equal(t, types.ChatGTPRequestMessage{Role: "assistant", Content: `{"closing":"Sincerely,","paragraphs":["I am a great fit."],"salutation":"Dear Hiring Manager,"}`}, generated.Messages[2])
+
+		coverLetter := &types.CoverLetter{ProfileID: profileId, JobPosting: *jobPosting, Content: generated.Content, Messages: generated.Messages}
+		revised, statusCode, err := client.ReviseChatGPTCoverLetter(c, coverLetter, "emphasize leadership", mockStore)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.True(t, strings.HasSuffix(revised.Content, "Dear Hiring Manager,\n\nI led teams.\n\nSincerely,\nJohn Doe"))
+		assert.Equal(t, []string{"I led teams."}, revised.Structured.Paragraphs)
+		assert.Equal(t, "cover_letter_revision/v2", revised.PromptVersion)
+		assert.Len(t, revised.Messages, 5)
+
+		// The revision continues the conversation with the instruction
+		request := server.Requests()[1]
+		assert.Equal(t, generated.Messages, request.Messages[:3])
+		assert.Equal(t, "user", request.Messages[3].Role)
+		assert.Equal(t, "Revise the cover letter following this instruction: emphasize leadership\nReply only with the full revised cover letter as a JSON object with the fields \"salutation\", \"paragraphs\" and \"closing\", without placeholders in brackets.", request.Messages[3].Content)
+		assert.Equal(t, &types.ChatGPTResponseFormat{Type: "json_object"}, request.ResponseFormat)
+
+		// The user edits are sent with the instruction
+		coverLetter.Content = "My edited letter"
+		coverLetter.Edited = true
+		_, _, err = client.ReviseChatGPTCoverLetter(c, coverLetter, "make it shorter", mockStore)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(server.Requests()[2].Messages[3].Content, "I edited the cover letter to:\nMy edited letter\n\nRevise the cover letter following this instruction: make it shorter"))
+
+		_, statusCode, err = client.ReviseChatGPTCoverLetter(c, &types.CoverLetter{ProfileID: profileId, Content: "old letter"}, "make it shorter", mockStore)
+		assert.EqualError(t, err, "cover letter has no conversation history to revise")
+		assert.Equal(t, http.StatusConflict, statusCode)
+	})
+
+	t.Run("invalid JSON completion", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		server.Enqueue(openaitest.Completion("Dear Hiring Manager,"), openaitest.Completion(`{"salutation": "Dear Hiring Manager,", "paragraphs": [" "]}`))
+
+		_, statusCode, err := client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore)
+		assert.EqualError(t, err, "invalid cover letter from the LLM provider: no JSON object")
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+
+		_, _, err = client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore)
+		assert.EqualError(t, err, "invalid cover letter from the LLM provider: no paragraphs")
+	})
+
+	t.Run("extractJSONObject", func(t *testing.T) {
+		object, err := extractJSONObject("Here it is:\n```json\n{\"a\": {\"b\": 1}}\n```")
+		assert.NoError(t, err)
+		assert.Equal(t, `{"a": {"b": 1}}`, object)
+
+		_, err = extractJSONObject("} no object {")
+		assert.EqualError(t, err, "no JSON object")
+
+		array, err := extractJSONArray("Rankings: [{\"variant\": 1}].")
+		assert.NoError(t, err)
+		assert.Equal(t, `[{"variant": 1}]`, array)
+	})
+
+	t.Run("placeholders", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		server.Enqueue(openaitest.CoverLetterCompletion("I met [Referrer's Name] at [Event].", "[Referrer's Name] recommended me."))
+
+		coverLetter, _, err := client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"[Referrer's Name]", "[Event]"}, coverLetter.Placeholders)
+	})
+
+	t.Run("text prompt version", func(t *testing.T) {
+		server, _, mockStore, c := setup(t)
+		server.Enqueue(openaitest.Completion("Dear [Employer's Name],\n\nI want to join [Company Name] as [Company Address] says.\n\nSincerely,\n[Your Name]"))
+		registry, err := prompt.NewRegistry("", map[string]string{prompt.CoverLetter: "v1"})
+		assert.NoError(t, err)
+		client := NewOpenAIClientWithPrompts(server.Provider(), registry)
+
+		coverLetter, _, err := client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore)
+		assert.NoError(t, err)
+		assert.Nil(t, server.Requests()[0].ResponseFormat)
+		assert.Equal(t, "cover_letter/v1", coverLetter.PromptVersion)
+		assert.Nil(t, coverLetter.Structured)
+		// The known placeholders are replaced, and the unknown ones are reported instead of removed
+		assert.True(t, strings.HasPrefix(coverLetter.Content, "John Doe\njohn@email.com\n555-0100\n\n"))
+		assert.True(t, strings.HasSuffix(coverLetter.Content, "\n\nHiring Manager\nAcme\n\nDear Hiring Manager,\n\nI want to join Acme as [Company Address] says.\n\nSincerely,\nJohn Doe"))
+		assert.Equal(t, []string{"[Company Address]"}, coverLetter.Placeholders)
+	})
+
+	t.Run("context window", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		t.Setenv("LLM_CONTEXT_WINDOW", "1200")
+		longJobPosting := *jobPosting
+		longJobPosting.Details = strings.Repeat("Lead the operations team. ", 400)
+		server.Enqueue(openaitest.ServerError())
+
+		// The job details are trimmed to fit the prompt with the max tokens of the completion when the summary fails
+		coverLetter, statusCode, err := client.GenerateChatGPTCoverLetter(c, profileId, &longJobPosting, nil, mockStore)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, []string{"job_details"}, coverLetter.Truncated)
+		assert.Empty(t, coverLetter.Summarized)
+		assert.Len(t, server.Requests(), 2)
+		request := server.Requests()[1]
+		assert.Contains(t, request.Messages[1].Content, "Lead the operations team. (truncated)\nSkills:Management")
+		assert.LessOrEqual(t, client.provider.CountTokens("", request.Messages), 1200-512)
+
+		// The prompt that does not fit without the job details and summary is rejected
+		t.Setenv("LLM_CONTEXT_WINDOW", "600")
+		_, statusCode, err = client.GenerateChatGPTCoverLetter(c, profileId, &longJobPosting, nil, mockStore)
+		assert.ErrorContains(t, err, "only 88 tokens of the context window of gpt-3.5-turbo are left for it")
+		assert.Equal(t, http.StatusUnprocessableEntity, statusCode)
+		assert.Len(t, server.Requests(), 2)
+	})
+
+	t.Run("summarized job details", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		t.Setenv("LLM_CONTEXT_WINDOW", "1200")
+		longJobPosting := *jobPosting
+		longJobPosting.Details = strings.Repeat("Lead the operations team. ", 120)
+		server.Enqueue(openaitest.Response{Content: "Acme is looking for a lead of its operations team.", Usage: &types.TokenUsage{PromptTokens: 600, CompletionTokens: 12, TotalTokens: 612}})
+
+		// The job details are summarized to fit the prompt, and the summary is recorded with the calls of the cover letter
+		coverLetter, statusCode, err := client.GenerateChatGPTCoverLetter(c, profileId, &longJobPosting, nil, mockStore)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, []string{"job_details"}, coverLetter.Summarized)
+		assert.Empty(t, coverLetter.Truncated)
+		requests := server.Requests()
+		if assert.Len(t, requests, 2) {
+			assert.Contains(t, requests[0].Messages[0].Content, "You summarize job postings")
+			assert.Contains(t, requests[0].Messages[1].Content, "Lead the operations team.")
+			assert.Contains(t, requests[1].Messages[1].Content, "Acme is looking for a lead of its operations team.")
+			assert.NotContains(t, requests[1].Messages[1].Content, "Lead the operations team.")
+		}
+		if assert.Len(t, coverLetter.Calls, 2) {
+			assert.Equal(t, "job_details_summary/v1", coverLetter.Calls[0].PromptVersion)
+			assert.Equal(t, types.TokenUsage{PromptTokens: 600, CompletionTokens: 12, TotalTokens: 612}, coverLetter.Calls[0].Usage)
+			assert.Equal(t, "cover_letter/v2", coverLetter.Calls[1].PromptVersion)
+			assert.Equal(t, coverLetter.Calls[0].Usage.Add(coverLetter.Calls[1].Usage), coverLetter.Usage)
+		}
+
+		// The job details too long to be summarized in the context window are trimmed before being summarized
+		longJobPosting.Details = strings.Repeat("Lead the operations team. ", 400)
+		coverLetter, statusCode, err = client.GenerateChatGPTCoverLetter(c, profileId, &longJobPosting, nil, mockStore)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, []string{"job_details"}, coverLetter.Summarized)
+		assert.Equal(t, []string{"job_details"}, coverLetter.Truncated)
+		assert.Contains(t, server.Requests()[2].Messages[1].Content, "Lead the operations team. (truncated)")
+		assert.LessOrEqual(t, client.provider.CountTokens("", server.Requests()[2].Messages)+server.Requests()[2].MaxTokens, 1200)
+
+		// The job details are trimmed when the summary fails, and the failed summary is not recorded
+		longJobPosting.Details = strings.Repeat("Lead the operations team. ", 120)
+		server.Enqueue(openaitest.ServerError())
+		coverLetter, statusCode, err = client.GenerateChatGPTCoverLetter(c, profileId, &longJobPosting, nil, mockStore)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Empty(t, coverLetter.Summarized)
+		assert.Equal(t, []string{"job_details"}, coverLetter.Truncated)
+		assert.Len(t, coverLetter.Calls, 1)
+	})
+
+	t.Run("continuation", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		server.Enqueue(
+			openaitest.Response{Content: `{"salutation": "Dear Hiring Manager,", "paragraphs": ["I am`, FinishReason: "length", Usage: &types.TokenUsage{PromptTokens: 100, CompletionTokens: 512, TotalTokens: 612}},
+			openaitest.Response{Content: ` a great fit."], "closing": "Sincerely,"}`, Usage: &types.TokenUsage{PromptTokens: 620, CompletionTokens: 10, TotalTokens: 630}},
+		)
+
+		// The completion cut off at the max tokens is continued in the same conversation
+		coverLetter, statusCode, err := client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, []string{"I am a great fit."}, coverLetter.Structured.Paragraphs)
+		assert.Equal(t, types.TokenUsage{PromptTokens: 720, CompletionTokens: 522, TotalTokens: 1242}, coverLetter.Usage)
+		if assert.Len(t, coverLetter.Calls, 2) {
+			assert.Equal(t, types.TokenUsage{PromptTokens: 100, CompletionTokens: 512, TotalTokens: 612}, coverLetter.Calls[0].Usage)
+			assert.Equal(t, types.TokenUsage{PromptTokens: 620, CompletionTokens: 10, TotalTokens: 630}, coverLetter.Calls[1].Usage)
+			assert.Equal(t, coverLetter.PromptVersion, coverLetter.Calls[1].PromptVersion)
+		}
+		request := server.Requests()[1]
+		assert.Nil(t, request.ResponseFormat)
+		assert.Len(t, request.Messages, 4)
+		assert.Equal(t, types.ChatGTPRequestMessage{Role: "assistant", Content: `{"salutation": "Dear Hiring Manager,", "paragraphs": ["I am`}, request.Messages[2])
+		assert.Equal(t, "user", request.Messages[3].Role)
+		assert.Contains(t, request.Messages[3].Content, "Continue it exactly where it stopped")
+
+		// The completion is not continued more than MaxContinuations times
+		server.Enqueue(openaitest.Response{Content: "Dear", FinishReason: "length"}, openaitest.Response{Content: " Hiring", FinishReason: "length"})
+		_, statusCode, err = client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore)
+		assert.EqualError(t, err, "the completion was cut off at the max tokens (512), even after continuing it")
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+		assert.Len(t, server.Requests(), 4)
+		// The calls of the failed completion are still reported to record their usage
+		var usageError *llm.UsageError
+		if assert.ErrorAs(t, err, &usageError) {
+			assert.Len(t, usageError.Calls, 2)
+			assert.Equal(t, "cover_letter/v2", usageError.Calls[1].PromptVersion)
+		}
+	})
+
+	t.Run("empty completion", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		server.Enqueue(openaitest.Completion(""))
+
+		_, statusCode, err := client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore)
+		assert.EqualError(t, err, "received an empty cover letter from OpenAI")
+		assert.Equal(t, http.StatusInternalServerError, statusCode)
+	})
+
+	t.Run("provider errors", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		server.Enqueue(openaitest.RateLimited(30*time.Second), openaitest.ServerError())
+
+		_, statusCode, err := client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore)
+		assert.EqualError(t, err, "openai API error (status 429, rate_limit_exceeded): Rate limit reached for requests")
+		assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+		assert.Equal(t, 30*time.Second, llm.RetryAfter(err))
+
+		_, statusCode, err = client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore)
+		assert.EqualError(t, err, "openai API error (status 500, server_error): The server had an error while processing your request")
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+	})
+
+	t.Run("retried provider errors", func(t *testing.T) {
+		server, _, mockStore, c := setup(t)
+		server.Enqueue(openaitest.ServerError(), openaitest.RateLimited(0))
+		client := NewOpenAIClientWithProvider(llm.WithRetry(server.Provider(), llm.RetryConfig{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}))
+
+		coverLetter, statusCode, err := client.GenerateChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Contains(t, coverLetter.Content, "Dear Hiring Manager,")
+		assert.Len(t, server.Requests(), 3)
+	})
+
+	t.Run("stream interrupted", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		server.Enqueue(openaitest.Response{
+			Chunks:      []string{`{"salutation": "Dear `, "Hiring "},
+			StreamError: &types.ChatGPTError{Message: "The server had an error while processing your request", Type: "server_error"},
+		})
+
+		var content string
+		_, statusCode, err := client.StreamChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore, func(delta string) error {
+			content += delta
+			return nil
+		})
+		assert.EqualError(t, err, "openai API error (status 500, server_error): The server had an error while processing your request")
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+		assert.Equal(t, "Dear Hiring ", content)
+		// The usage of the interrupted stream is estimated from the streamed content
+		var usageError *llm.UsageError
+		if assert.ErrorAs(t, err, &usageError) && assert.Len(t, usageError.Calls, 1) {
+			assert.Equal(t, llm.EstimateTokens(`{"salutation": "Dear Hiring `), usageError.Calls[0].Usage.CompletionTokens)
+			assert.Greater(t, usageError.Calls[0].Usage.PromptTokens, 0)
+		}
+	})
+
+	t.Run("stream truncated", func(t *testing.T) {
+		server, client, mockStore, c := setup(t)
+		server.Enqueue(openaitest.Response{Chunks: []string{`{"salutation": "Dear `, "Hiring "}, Truncated: true})
+
+		var content string
+		_, statusCode, err := client.StreamChatGPTCoverLetter(c, profileId, jobPosting, nil, mockStore, func(delta string) error {
+			content += delta
+			return nil
+		})
+		assert.ErrorIs(t, err, llm.ErrStreamTruncated)
+		assert.Equal(t, http.StatusBadGateway, statusCode)
+		assert.Equal(t, "Dear Hiring ", content)
+		// The usage of the truncated stream is estimated from the streamed content
+		var usageError *llm.UsageError
+		if assert.ErrorAs(t, err, &usageError) && assert.Len(t, usageError.Calls, 1) {
+			assert.Equal(t, llm.EstimateTokens(`{"salutation": "Dear Hiring `), usageError.Calls[0].Usage.CompletionTokens)
+		}
+	})
+}
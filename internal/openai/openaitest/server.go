@@ -0,0 +1,259 @@
+// Package openaitest provides a fake chat completions server to test the cover letter generation
+// without calling OpenAI, with scripted responses, streaming, errors and rate limits.
+package openaitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonada182/cover-letter-ai-api/internal/llm"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// CompletionsPath is the path of the chat completions endpoint of the fake server
+const CompletionsPath = "/v1/chat/completions"
+
+// DefaultContent is the completion returned when no response is scripted
+const DefaultContent = "Dear [Employer's Name],\n\nI am excited to apply for this role.\n\nSincerely,\n[Your Name]"
+
+// DefaultJSONContent is the completion returned when no response is scripted and the JSON mode is requested
+const DefaultJSONContent = `{"salutation": "Dear Hiring Manager,", "paragraphs": ["I am excited to apply for this role."], "closing": "Sincerely,"}`
+
+// Response is a scripted response of the fake server
+type Response struct {
+	// StatusCode is the status of the response, 200 by default
+	StatusCode int
+	// Content is the completion content, sent in chunks when streaming
+	Content string
+	// Chunks are the content deltas sent when streaming, the words of Content by default
+	Chunks []string
+	// FinishReason is the finish reason of the completion, "stop" by default
+	FinishReason string
+	// Model is the model of the completion, the requested model by default
+	Model string
+	// Usage is the token usage of the completion, the number of words of the messages and content by default
+	Usage *types.TokenUsage
+	// Error is the error body sent with a non-200 StatusCode
+	Error *types.ChatGPTError
+	// StreamError is sent as a chunk after the Chunks, interrupting the stream
+	StreamError *types.ChatGPTError
+	// Truncated closes the stream after the Chunks, without the finish reason and [DONE]
+	Truncated bool
+	// RetryAfter sets the Retry-After header of the response
+	RetryAfter time.Duration
+	// Delay is how long the server waits before responding
+	Delay time.Duration
+}
+
+// Completion returns a successful response with the given content
+func Completion(content string) Response {
+	return Response{Content: content}
+}
+
+// CoverLetterCompletion returns a successful JSON response with a cover letter made of the given paragraphs
+func CoverLetterCompletion(paragraphs ...string) Response {
+	content, _ := json.Marshal(map[string]interface{}{
+		"salutation": "Dear Hiring Manager,",
+		"paragraphs": paragraphs,
+		"closing":    "Sincerely,",
+	})
+	return Completion(string(content))
+}
+
+// RateLimited returns a 429 rate limit error response asking to retry after the given delay
+func RateLimited(retryAfter time.Duration) Response {
+	return Response{
+		StatusCode: http.StatusTooManyRequests,
+		Error:      &types.ChatGPTError{Message: "Rate limit reached for requests", Type: "requests", Code: "rate_limit_exceeded"},
+		RetryAfter: retryAfter,
+	}
+}
+
+// ServerError returns a 500 server error response
+func ServerError() Response {
+	return Response{
+		StatusCode: http.StatusInternalServerError,
+		Error:      &types.ChatGPTError{Message: "The server had an error while processing your request", Type: "server_error"},
+	}
+}
+
+// Server is a fake chat completions API, returning the scripted responses in order
+type Server struct {
+	*httptest.Server
+	mutex     sync.Mutex
+	responses []Response
+	requests  []types.ChatGPTRequest
+}
+
+// NewServer starts a fake chat completions server, which is closed when the test finishes
+func NewServer(t testing.TB) *Server {
+	server := &Server{}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handleCompletions))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Enqueue scripts the next responses of the server, which returns DefaultContent, or DefaultJSONContent
+// in JSON mode, when there are none left
+func (s *Server) Enqueue(responses ...Response) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.responses = append(s.responses, responses...)
+}
+
+// Requests returns the chat completion requests received by the server
+func (s *Server) Requests() []types.ChatGPTRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]types.ChatGPTRequest(nil), s.requests...)
+}
+
+// CompletionsURL returns the chat completions URL of the server
+func (s *Server) CompletionsURL() string {
+	return s.URL + CompletionsPath
+}
+
+// Provider returns an OpenAI provider that sends its requests to the server
+func (s *Server) Provider() *llm.OpenAIProvider {
+	return llm.NewOpenAIProvider(s.CompletionsURL(), "test_key", llm.GPT35)
+}
+
+// handleCompletions mimics the chat completions endpoint of the OpenAI API
+func (s *Server) handleCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != CompletionsPath {
+		writeError(w, http.StatusNotFound, &types.ChatGPTError{Message: "Unknown request URL", Type: "invalid_request_error"})
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, http.StatusUnauthorized, &types.ChatGPTError{Message: "You didn't provide an API key", Type: "invalid_request_error"})
+		return
+	}
+	var request types.ChatGPTRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Messages) == 0 {
+		writeError(w, http.StatusBadRequest, &types.ChatGPTError{Message: "Invalid request body", Type: "invalid_request_error"})
+		return
+	}
+
+	response := s.nextResponse(request)
+	if response.Delay > 0 {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(response.Delay):
+		}
+	}
+	if response.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(response.RetryAfter.Seconds())))
+	}
+	if response.StatusCode != http.StatusOK {
+		writeError(w, response.StatusCode, response.Error)
+		return
+	}
+	if request.Stream {
+		writeStream(w, response, request.StreamOptions != nil && request.StreamOptions.IncludeUsage)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"object": "chat.completion",
+		"model":  response.Model,
+		"choices": []types.ChatGPTResponseChoice{{
+			Message:      types.ChatGPTMessage{Role: "assistant", Content: response.Content},
+			FinishReason: response.FinishReason,
+		}},
+		"usage": response.Usage,
+	})
+}
+
+// nextResponse records the request and returns the next scripted response with its defaults set
+func (s *Server) nextResponse(request types.ChatGPTRequest) Response {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests = append(s.requests, request)
+
+	response := Completion(DefaultContent)
+	if request.ResponseFormat != nil && request.ResponseFormat.Type == "json_object" {
+		response = Completion(DefaultJSONContent)
+	}
+	if len(s.responses) > 0 {
+		response = s.responses[0]
+		s.responses = s.responses[1:]
+	}
+	if response.StatusCode == 0 {
+		response.StatusCode = http.StatusOK
+	}
+	if response.FinishReason == "" {
+		response.FinishReason = "stop"
+	}
+	if response.Model == "" {
+		response.Model = request.Model
+	}
+	if response.Chunks == nil {
+		response.Chunks = strings.SplitAfter(response.Content, " ")
+	}
+	if response.Usage == nil {
+		usage := types.TokenUsage{CompletionTokens: len(strings.Fields(strings.Join(response.Chunks, "")))}
+		for _, message := range request.Messages {
+			usage.PromptTokens += len(strings.Fields(message.Content))
+		}
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		response.Usage = &usage
+	}
+	return response
+}
+
+// writeStream sends the response as chat completion chunks, ending with the usage when requested
+// and [DONE], unless a stream error interrupts it
+func writeStream(w http.ResponseWriter, response Response, includeUsage bool) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	writeChunk := func(chunk interface{}) {
+		data, _ := json.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	streamChunk := func(delta types.ChatGPTMessage, finishReason *string) types.ChatGPTStreamResponseData {
+		return types.ChatGPTStreamResponseData{
+			Model:   response.Model,
+			Choices: []types.ChatGPTStreamChoice{{Delta: delta, FinishReason: finishReason}},
+		}
+	}
+
+	writeChunk(streamChunk(types.ChatGPTMessage{Role: "assistant"}, nil))
+	for _, chunk := range response.Chunks {
+		if chunk != "" {
+			writeChunk(streamChunk(types.ChatGPTMessage{Content: chunk}, nil))
+		}
+	}
+	if response.StreamError != nil {
+		writeChunk(types.ChatGPTStreamResponseData{Error: response.StreamError})
+		return
+	}
+	if response.Truncated {
+		return
+	}
+	writeChunk(streamChunk(types.ChatGPTMessage{}, &response.FinishReason))
+	if includeUsage {
+		writeChunk(types.ChatGPTStreamResponseData{Model: response.Model, Choices: []types.ChatGPTStreamChoice{}, Usage: response.Usage})
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+// writeError sends an error response with the OpenAI error body format
+func writeError(w http.ResponseWriter, statusCode int, chatGPTError *types.ChatGPTError) {
+	if chatGPTError == nil {
+		chatGPTError = &types.ChatGPTError{Message: http.StatusText(statusCode)}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": chatGPTError})
+}
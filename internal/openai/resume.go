@@ -0,0 +1,172 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonada182/cover-letter-ai-api/internal/llm"
+	"github.com/jonada182/cover-letter-ai-api/internal/prompt"
+	"github.com/jonada182/cover-letter-ai-api/internal/skillsgap"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// resumeMaxTokens is the max tokens of the tailored resume completion
+const resumeMaxTokens = 2048
+
+// resumePromptData is the data of the resume prompt template
+type resumePromptData struct {
+	JobPosting    types.JobPosting
+	CareerProfile string
+	Positions     []workHistoryPosition
+}
+
+// llmResume is the JSON object of the tailored resume completion
+type llmResume struct {
+	Summary    string   `json:"summary"`
+	Skills     []string `json:"skills"`
+	Experience []struct {
+		Position int      `json:"position"`
+		Bullets  []string `json:"bullets"`
+	} `json:"experience"`
+}
+
+// TailorChatGPTResume writes a resume for the job posting with the LLM provider, from the career profile and its work
+// history, truncating the job details to fit in the context window of the model. A completion that cannot be parsed,
+// or was cut off at the max tokens, returns a llm.UsageError to record its usage.
+func (oa *OpenAIClient) TailorChatGPTResume(c *gin.Context, careerProfile *types.CareerProfile, jobPosting *types.JobPosting) (*types.GeneratedResume, int, error) {
+	template, err := oa.prompts.Get(prompt.Resume)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	careerProfileInfo, err := oa.renderCareerProfilePrompt(newCareerProfilePromptData(careerProfile))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	data := resumePromptData{
+		JobPosting:    *jobPosting,
+		CareerProfile: careerProfileInfo,
+		Positions:     newWorkHistoryPositions(careerProfile),
+	}
+	var messages []types.ChatGTPRequestMessage
+	render := func() error {
+		systemPrompt, err := template.RenderSection("system", data)
+		if err != nil {
+			return err
+		}
+		userPrompt, err := template.RenderSection("user", data)
+		if err != nil {
+			return err
+		}
+		messages = []types.ChatGTPRequestMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		}
+		return nil
+	}
+	if err := render(); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	budget := llm.ContextWindow(oa.provider.DefaultModel()) - resumeMaxTokens
+	if promptTokens := oa.provider.CountTokens("", messages); promptTokens > budget && data.JobPosting.Details != "" {
+		data.JobPosting.Details = oa.truncateToFit("", data.JobPosting.Details, promptTokens-budget)
+		if err := render(); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+
+	request := llm.Request{
+		Messages:    messages,
+		Temperature: 0,
+		MaxTokens:   resumeMaxTokens,
+		JSON:        template.HasSection("response_format"),
+	}
+	response, err := oa.provider.ChatCompletion(requestContext(c), request)
+	if err != nil {
+		return nil, llm.HTTPStatus(err), err
+	}
+
+	if response.FinishReason == "length" {
+		return nil, http.StatusBadGateway, oa.failedCompletion(fmt.Errorf("the resume was cut off at the max tokens (%d)", resumeMaxTokens), request, response, template.ID())
+	}
+	resume, err := parseResume(response.Content, careerProfile)
+	if err != nil {
+		return nil, http.StatusBadGateway, oa.failedCompletion(err, request, response, template.ID())
+	}
+	model := response.Model
+	if model == "" {
+		model = oa.provider.DefaultModel()
+	}
+	return &types.GeneratedResume{
+		Resume:        *resume,
+		Model:         model,
+		PromptVersion: template.ID(),
+		Usage:         response.Usage,
+	}, http.StatusOK, nil
+}
+
+// parseResume parses the JSON object of the tailored resume completion. The positions come from the work history, in
+// its order, with the bullets of the completion, or their highlights when the completion has none. The skills are the
+// skills of the career profile, in the order of the completion followed by the ones it left out, so the completion
+// cannot add skills.
+func parseResume(content string, careerProfile *types.CareerProfile) (*types.Resume, error) {
+	object, err := extractJSONObject(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resume from the LLM provider: %w", err)
+	}
+	var parsed llmResume
+	if err := json.Unmarshal([]byte(object), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid resume from the LLM provider: %w", err)
+	}
+	if strings.TrimSpace(parsed.Summary) == "" {
+		return nil, errors.New("invalid resume from the LLM provider: no summary")
+	}
+
+	resume := &types.Resume{
+		Summary:    strings.TrimSpace(parsed.Summary),
+		Skills:     []string{},
+		Experience: []types.ResumeExperience{},
+	}
+	if careerProfile.Skills != nil {
+		profileSkills := map[string]string{}
+		for _, skill := range *careerProfile.Skills {
+			profileSkills[skillsgap.Normalize(skill)] = skill
+		}
+		for _, skill := range append(parsed.Skills, *careerProfile.Skills...) {
+			if profileSkill, ok := profileSkills[skillsgap.Normalize(skill)]; ok {
+				resume.Skills = append(resume.Skills, profileSkill)
+				delete(profileSkills, skillsgap.Normalize(skill))
+			}
+		}
+	}
+
+	bullets := map[int][]string{}
+	for _, experience := range parsed.Experience {
+		for _, bullet := range experience.Bullets {
+			if bullet = strings.TrimSpace(strings.TrimLeft(bullet, "-•* ")); bullet != "" {
+				bullets[experience.Position] = append(bullets[experience.Position], bullet)
+			}
+		}
+	}
+	if careerProfile.WorkHistory != nil {
+		for i, workExperience := range *careerProfile.WorkHistory {
+			experience := types.ResumeExperience{
+				Company:   workExperience.Company,
+				Title:     workExperience.Title,
+				Location:  workExperience.Location,
+				StartDate: workExperience.StartDate,
+				EndDate:   workExperience.EndDate,
+				Bullets:   bullets[i+1],
+			}
+			if len(experience.Bullets) == 0 {
+				experience.Bullets = append([]string{}, workExperience.Highlights...)
+			}
+			resume.Experience = append(resume.Experience, experience)
+		}
+	}
+	return resume, nil
+}
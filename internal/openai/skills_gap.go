@@ -0,0 +1,134 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jonada182/cover-letter-ai-api/internal/llm"
+	"github.com/jonada182/cover-letter-ai-api/internal/prompt"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// skillsGapMaxTokens is the max tokens of the skills gap analysis completion
+const skillsGapMaxTokens = 1024
+
+// skillsGapPromptData is the data of the skills gap analysis prompt template
+type skillsGapPromptData struct {
+	JobPosting    types.JobPosting
+	CareerProfile string
+	MatchedSkills []string
+	MissingSkills []string
+}
+
+// llmSkillsGap is the JSON object of the skills gap analysis completion
+type llmSkillsGap struct {
+	Score              int                       `json:"score"`
+	Explanation        string                    `json:"explanation"`
+	MatchedSkills      []string                  `json:"matched_skills"`
+	MissingSkills      []string                  `json:"missing_skills"`
+	TransferableSkills []types.TransferableSkill `json:"transferable_skills"`
+}
+
+// AnalyzeChatGPTSkillsGap compares the career profile with the job posting with the LLM provider, given the skills
+// already matched by keyword, truncating the job details to fit in the context window of the model. A completion that
+// cannot be parsed returns a llm.UsageError to record its usage.
+func (oa *OpenAIClient) AnalyzeChatGPTSkillsGap(c *gin.Context, careerProfile *types.CareerProfile, jobPosting *types.JobPosting, keywordMatch *types.SkillsGapAnalysis) (*types.GeneratedSkillsGap, int, error) {
+	template, err := oa.prompts.Get(prompt.SkillsGap)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	careerProfileInfo, err := oa.renderCareerProfilePrompt(newCareerProfilePromptData(careerProfile))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	data := skillsGapPromptData{
+		JobPosting:    *jobPosting,
+		CareerProfile: careerProfileInfo,
+		MatchedSkills: keywordMatch.MatchedSkills,
+		MissingSkills: keywordMatch.MissingSkills,
+	}
+	var messages []types.ChatGTPRequestMessage
+	render := func() error {
+		systemPrompt, err := template.RenderSection("system", data)
+		if err != nil {
+			return err
+		}
+		userPrompt, err := template.RenderSection("user", data)
+		if err != nil {
+			return err
+		}
+		messages = []types.ChatGTPRequestMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		}
+		return nil
+	}
+	if err := render(); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	budget := llm.ContextWindow(oa.provider.DefaultModel()) - skillsGapMaxTokens
+	if promptTokens := oa.provider.CountTokens("", messages); promptTokens > budget && data.JobPosting.Details != "" {
+		data.JobPosting.Details = oa.truncateToFit("", data.JobPosting.Details, promptTokens-budget)
+		if err := render(); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+
+	request := llm.Request{
+		Messages:    messages,
+		Temperature: 0,
+		MaxTokens:   skillsGapMaxTokens,
+		JSON:        template.HasSection("response_format"),
+	}
+	response, err := oa.provider.ChatCompletion(requestContext(c), request)
+	if err != nil {
+		return nil, llm.HTTPStatus(err), err
+	}
+
+	analysis, err := parseSkillsGap(response.Content)
+	if err != nil {
+		return nil, http.StatusBadGateway, oa.failedCompletion(err, request, response, template.ID())
+	}
+	model := response.Model
+	if model == "" {
+		model = oa.provider.DefaultModel()
+	}
+	return &types.GeneratedSkillsGap{
+		Analysis:      *analysis,
+		Model:         model,
+		PromptVersion: template.ID(),
+		Usage:         response.Usage,
+	}, http.StatusOK, nil
+}
+
+// parseSkillsGap parses the JSON object of the skills gap analysis completion, dropping the transferable skills without a name
+func parseSkillsGap(content string) (*types.SkillsGapAnalysis, error) {
+	object, err := extractJSONObject(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid skills gap analysis from the LLM provider: %w", err)
+	}
+	var parsed llmSkillsGap
+	if err := json.Unmarshal([]byte(object), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid skills gap analysis from the LLM provider: %w", err)
+	}
+
+	analysis := &types.SkillsGapAnalysis{
+		Score:              min(max(parsed.Score, 0), 100),
+		MatchedSkills:      parsed.MatchedSkills,
+		MissingSkills:      parsed.MissingSkills,
+		TransferableSkills: []types.TransferableSkill{},
+	}
+	if parsed.Explanation != "" {
+		analysis.Explanations = []string{parsed.Explanation}
+	}
+	for _, transferable := range parsed.TransferableSkills {
+		if strings.TrimSpace(transferable.Skill) != "" {
+			analysis.TransferableSkills = append(analysis.TransferableSkills, transferable)
+		}
+	}
+	return analysis, nil
+}
@@ -0,0 +1,208 @@
+// Package prompt renders the LLM prompts from versioned text/template files.
+//
+// Templates are stored as templates/<name>/<version>.tmpl and embedded in the binary. A deployment can
+// override them, or add new versions, with the same layout in the PROMPT_TEMPLATES_DIR directory, and pick
+// the version of each template with PROMPT_VERSIONS (e.g. cover_letter=v2), using the latest one by default.
+package prompt
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates
+var embeddedTemplates embed.FS
+
+const (
+	CoverLetter         = "cover_letter"
+	CoverLetterRanking  = "cover_letter_ranking"
+	CoverLetterRevision = "cover_letter_revision"
+	// CoverLetterContinuation continues a completion that stopped at the max tokens
+	CoverLetterContinuation = "cover_letter_continuation"
+	CareerProfile           = "career_profile"
+	JobPosting              = "job_posting"
+	SkillsGap               = "skills_gap"
+	Resume                  = "resume"
+	InterviewPrep           = "interview_prep"
+	// JobDetailsSummary summarizes the job details that do not fit in the cover letter prompt
+	JobDetailsSummary = "job_details_summary"
+)
+
+// funcs are the functions available in the templates
+var funcs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// Template is a version of a prompt template
+type Template struct {
+	Name     string
+	Version  string
+	template *template.Template
+}
+
+// ID identifies the template version, to be recorded with the generated content (e.g. cover_letter/v1)
+func (t *Template) ID() string {
+	return t.Name + "/" + t.Version
+}
+
+// Render executes the template with the given data, trimming the surrounding whitespace
+func (t *Template) Render(data interface{}) (string, error) {
+	return t.execute(t.template, data)
+}
+
+// RenderSection executes a section of the template declared with {{define "section"}}
+func (t *Template) RenderSection(section string, data interface{}) (string, error) {
+	sectionTemplate := t.template.Lookup(section)
+	if sectionTemplate == nil {
+		return "", fmt.Errorf("prompt template %s has no %s section", t.ID(), section)
+	}
+	return t.execute(sectionTemplate, data)
+}
+
+// HasSection reports whether the template declares the section, e.g. the optional "response_format" section
+func (t *Template) HasSection(section string) bool {
+	return t.template.Lookup(section) != nil
+}
+
+func (t *Template) execute(tmpl *template.Template, data interface{}) (string, error) {
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return "", fmt.Errorf("error rendering prompt template %s: %w", t.ID(), err)
+	}
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+// Registry holds the available versions of each prompt template
+type Registry struct {
+	templates map[string]map[string]*Template
+	active    map[string]string
+}
+
+// NewRegistry loads the embedded templates, overridden by the templates in overridesDir when it is not empty,
+// using the given active version of each template, or the latest version when it is not set
+func NewRegistry(overridesDir string, activeVersions map[string]string) (*Registry, error) {
+	registry := &Registry{
+		templates: map[string]map[string]*Template{},
+		active:    map[string]string{},
+	}
+	templatesFS, err := fs.Sub(embeddedTemplates, "templates")
+	if err != nil {
+		return nil, err
+	}
+	if err := registry.load(templatesFS); err != nil {
+		return nil, err
+	}
+	if overridesDir != "" {
+		if err := registry.load(os.DirFS(overridesDir)); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, versions := range registry.templates {
+		registry.active[name] = latestVersion(versions)
+	}
+	for name, version := range activeVersions {
+		if _, ok := registry.templates[name][version]; !ok {
+			return nil, fmt.Errorf("prompt template %s/%s does not exist", name, version)
+		}
+		registry.active[name] = version
+	}
+	return registry, nil
+}
+
+// NewRegistryFromEnv returns a registry with the overrides from PROMPT_TEMPLATES_DIR and the versions from PROMPT_VERSIONS
+func NewRegistryFromEnv() (*Registry, error) {
+	activeVersions := map[string]string{}
+	for _, entry := range strings.Split(os.Getenv("PROMPT_VERSIONS"), ",") {
+		name, version, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		activeVersions[strings.TrimSpace(name)] = strings.TrimSpace(version)
+	}
+	return NewRegistry(os.Getenv("PROMPT_TEMPLATES_DIR"), activeVersions)
+}
+
+// Default returns a registry with the latest version of the embedded templates
+func Default() *Registry {
+	registry, err := NewRegistry("", nil)
+	if err != nil {
+		panic(err)
+	}
+	return registry
+}
+
+// Get returns the active version of a template
+func (r *Registry) Get(name string) (*Template, error) {
+	version, ok := r.active[name]
+	if !ok {
+		return nil, fmt.Errorf("prompt template %s does not exist", name)
+	}
+	return r.templates[name][version], nil
+}
+
+// Versions returns the available versions of a template
+func (r *Registry) Versions(name string) []string {
+	versions := make([]string, 0, len(r.templates[name]))
+	for version := range r.templates[name] {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+	return versions
+}
+
+// load parses the <name>/<version>.tmpl files of a file system, replacing the versions already loaded
+func (r *Registry) load(templatesFS fs.FS) error {
+	files, err := fs.Glob(templatesFS, "*/*.tmpl")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		content, err := fs.ReadFile(templatesFS, file)
+		if err != nil {
+			return err
+		}
+		name := path.Dir(file)
+		version := strings.TrimSuffix(path.Base(file), ".tmpl")
+		parsed, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("error parsing prompt template %s: %w", file, err)
+		}
+		if r.templates[name] == nil {
+			r.templates[name] = map[string]*Template{}
+		}
+		r.templates[name][version] = &Template{Name: name, Version: version, template: parsed}
+	}
+	return nil
+}
+
+// latestVersion returns the highest version of a template
+func latestVersion(versions map[string]*Template) string {
+	latest := ""
+	for version := range versions {
+		if latest == "" || compareVersions(version, latest) > 0 {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// compareVersions compares versions by their number (v2 < v10), falling back to comparing them as strings
+func compareVersions(a string, b string) int {
+	aNumber, aErr := strconv.Atoi(strings.TrimPrefix(a, "v"))
+	bNumber, bErr := strconv.Atoi(strings.TrimPrefix(b, "v"))
+	if aErr == nil && bErr == nil {
+		return aNumber - bNumber
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,83 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Run("embedded templates", func(t *testing.T) {
+		registry := Default()
+		for name, id := range map[string]string{CoverLetter: "cover_letter/v2", CoverLetterRevision: "cover_letter_revision/v2", CareerProfile: "career_profile/v1"} {
+			template, err := registry.Get(name)
+			assert.NoError(t, err)
+			assert.Equal(t, id, template.ID())
+		}
+
+		// The JSON completions are declared with a response_format section
+		template, err := registry.Get(CoverLetter)
+		assert.NoError(t, err)
+		assert.True(t, template.HasSection("response_format"))
+		assert.False(t, template.HasSection("unknown"))
+
+		template, err = registry.Get(CareerProfile)
+		assert.NoError(t, err)
+		assert.False(t, template.HasSection("response_format"))
+		info, err := template.Render(map[string]interface{}{
+			"Headline":        "Manager",
+			"ExperienceYears": 5,
+			"Skills":          []string{"Leadership", "Planning"},
+			"Summary":         "",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Here is my career profile information:\nHeadline:Manager,\nExperience:5 years,\nSkills:Leadership,Planning,", info)
+
+		_, err = template.RenderSection("system", nil)
+		assert.EqualError(t, err, "prompt template career_profile/v1 has no system section")
+		_, err = registry.Get("unknown")
+		assert.EqualError(t, err, "prompt template unknown does not exist")
+	})
+
+	t.Run("overrides directory", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, CoverLetter), 0o755))
+		content := `{{define "system"}}You write short cover letters.{{end}}{{define "user"}}Job: {{.JobRole}}{{end}}`
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, CoverLetter, "v10.tmpl"), []byte(content), 0o644))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, CoverLetter, "v2.tmpl"), []byte(content), 0o644))
+
+		// The latest version is active by default
+		registry, err := NewRegistry(dir, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"v1", "v2", "v10"}, registry.Versions(CoverLetter))
+		template, err := registry.Get(CoverLetter)
+		assert.NoError(t, err)
+		assert.Equal(t, "cover_letter/v10", template.ID())
+		userPrompt, err := template.RenderSection("user", map[string]string{"JobRole": "Manager"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Job: Manager", userPrompt)
+
+		// A previous version can be selected, e.g. to compare prompt versions
+		t.Setenv("PROMPT_TEMPLATES_DIR", dir)
+		t.Setenv("PROMPT_VERSIONS", "cover_letter=v1, career_profile=v1")
+		registry, err = NewRegistryFromEnv()
+		assert.NoError(t, err)
+		template, err = registry.Get(CoverLetter)
+		assert.NoError(t, err)
+		assert.Equal(t, "cover_letter/v1", template.ID())
+
+		_, err = NewRegistry(dir, map[string]string{CoverLetter: "v3"})
+		assert.EqualError(t, err, "prompt template cover_letter/v3 does not exist")
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, CoverLetter), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, CoverLetter, "v2.tmpl"), []byte("{{.JobRole"), 0o644))
+
+		_, err := NewRegistry(dir, nil)
+		assert.ErrorContains(t, err, "error parsing prompt template cover_letter/v2.tmpl")
+	})
+}
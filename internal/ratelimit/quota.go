@@ -0,0 +1,203 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	QuotaPeriodDaily   = "daily"
+	QuotaPeriodMonthly = "monthly"
+)
+
+// QuotaResult describes the outcome of consuming a generation from a profile quota
+type QuotaResult struct {
+	Allowed bool
+	// Period is the quota period that was exceeded, or the most restrictive one when allowed
+	Period    string
+	Limit     int
+	Remaining int
+	ResetAt   time.Time
+	// PeriodKeys identify the periods the generation was counted in, to refund it in the same periods
+	PeriodKeys []string
+}
+
+// Quota tracks the number of generations per profile over daily and monthly periods
+type Quota interface {
+	Consume(profileId uuid.UUID) (QuotaResult, error)
+	// Refund removes a consumed generation from the periods it was counted in, even when they are over
+	Refund(profileId uuid.UUID, periodKeys []string) error
+}
+
+// quotaPeriod is a period with its own generation limit
+type quotaPeriod struct {
+	name  string
+	limit int
+	// key identifies the period containing a point in time
+	key func(t time.Time) string
+	// end returns the end of the period containing a point in time
+	end func(t time.Time) time.Time
+}
+
+// quotaPeriods returns the daily and monthly periods, skipping the ones without a limit
+func quotaPeriods(dailyLimit int, monthlyLimit int) []quotaPeriod {
+	var periods []quotaPeriod
+	if dailyLimit > 0 {
+		periods = append(periods, quotaPeriod{
+			name:  QuotaPeriodDaily,
+			limit: dailyLimit,
+			key:   func(t time.Time) string { return t.UTC().Format("2006-01-02") },
+			end: func(t time.Time) time.Time {
+				year, month, day := t.UTC().Date()
+				return time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+			},
+		})
+	}
+	if monthlyLimit > 0 {
+		periods = append(periods, quotaPeriod{
+			name:  QuotaPeriodMonthly,
+			limit: monthlyLimit,
+			key:   func(t time.Time) string { return t.UTC().Format("2006-01") },
+			end: func(t time.Time) time.Time {
+				year, month, _ := t.UTC().Date()
+				return time.Date(year, month+1, 1, 0, 0, 0, 0, time.UTC)
+			},
+		})
+	}
+	return periods
+}
+
+// CounterStore persists generation counts per profile and period
+type CounterStore interface {
+	IncrementGenerationCount(profileId uuid.UUID, period string, delta int) (int, error)
+}
+
+// counter increments the generation count of a profile for a period key, returning the new count. The end of the
+// period is only known when a generation is consumed, and is zero when it is refunded.
+type counter func(profileId uuid.UUID, periodKey string, end time.Time, delta int) (int, error)
+
+// periodQuota implements Quota on top of a counter
+type periodQuota struct {
+	periods []quotaPeriod
+	count   counter
+	now     func() time.Time
+}
+
+// Consume counts a generation for the profile, and reports if it is within the quota of every period
+func (q *periodQuota) Consume(profileId uuid.UUID) (QuotaResult, error) {
+	now := q.now()
+	result := QuotaResult{Allowed: true, Remaining: -1}
+	for i, period := range q.periods {
+		count, err := q.count(profileId, period.key(now), period.end(now), 1)
+		if err != nil {
+			return QuotaResult{}, err
+		}
+		remaining := period.limit - count
+		if remaining < 0 {
+			// Undo the generation counted in this and the previous periods
+			for _, countedPeriod := range q.periods[:i+1] {
+				if _, err := q.count(profileId, countedPeriod.key(now), time.Time{}, -1); err != nil {
+					return QuotaResult{}, err
+				}
+			}
+			return QuotaResult{
+				Allowed: false,
+				Period:  period.name,
+				Limit:   period.limit,
+				ResetAt: period.end(now),
+			}, nil
+		}
+		result.PeriodKeys = append(result.PeriodKeys, period.key(now))
+		if result.Remaining < 0 || remaining < result.Remaining {
+			result.Period = period.name
+			result.Limit = period.limit
+			result.Remaining = remaining
+			result.ResetAt = period.end(now)
+		}
+	}
+	return result, nil
+}
+
+// Refund removes a generation that was consumed but did not succeed, from the periods it was counted in, so a
+// generation that fails after the end of a period is not refunded from the next one
+func (q *periodQuota) Refund(profileId uuid.UUID, periodKeys []string) error {
+	for _, periodKey := range periodKeys {
+		if _, err := q.count(profileId, periodKey, time.Time{}, -1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// memoryPeriod holds the generation counts of the profiles in a period, until the end of the period
+type memoryPeriod struct {
+	end    time.Time
+	counts map[uuid.UUID]int
+}
+
+// memoryCounter counts the generations in memory by period key, removing the periods that are over
+type memoryCounter struct {
+	mutex   sync.Mutex
+	periods map[string]*memoryPeriod
+	now     func() time.Time
+}
+
+// count implements counter
+func (c *memoryCounter) count(profileId uuid.UUID, periodKey string, end time.Time, delta int) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now()
+	for key, period := range c.periods {
+		if !now.Before(period.end) {
+			delete(c.periods, key)
+		}
+	}
+	period, ok := c.periods[periodKey]
+	if !ok {
+		// The refunds of a period that is over have nothing to remove
+		if delta < 0 {
+			return 0, nil
+		}
+		period = &memoryPeriod{end: end, counts: map[uuid.UUID]int{}}
+		c.periods[periodKey] = period
+	}
+	count := period.counts[profileId] + delta
+	if count <= 0 {
+		delete(period.counts, profileId)
+		return 0, nil
+	}
+	period.counts[profileId] = count
+	return count, nil
+}
+
+// NewMemoryQuota returns an in-memory Quota, for a single API instance. A limit of 0 disables the period.
+// The counts of the periods that are over are removed.
+func NewMemoryQuota(dailyLimit int, monthlyLimit int) Quota {
+	quota := &periodQuota{
+		periods: quotaPeriods(dailyLimit, monthlyLimit),
+		now:     time.Now,
+	}
+	counter := &memoryCounter{
+		periods: map[string]*memoryPeriod{},
+		now:     func() time.Time { return quota.now() },
+	}
+	quota.count = counter.count
+	return quota
+}
+
+// NewStoreQuota returns a Quota backed by the store. A limit of 0 disables the period. The period keys are prefixed
+// by the scope, so quotas sharing the store are counted separately, and the cover letter quota has no scope.
+func NewStoreQuota(store CounterStore, scope string, dailyLimit int, monthlyLimit int) Quota {
+	return &periodQuota{
+		periods: quotaPeriods(dailyLimit, monthlyLimit),
+		count: func(profileId uuid.UUID, periodKey string, _ time.Time, delta int) (int, error) {
+			if scope != "" {
+				periodKey = scope + ":" + periodKey
+			}
+			return store.IncrementGenerationCount(profileId, periodKey, delta)
+		},
+		now: time.Now,
+	}
+}
@@ -0,0 +1,189 @@
+package ratelimit
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Result describes the outcome of taking a token from a rate limiter bucket
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+// Limiter is a token bucket rate limiter, where each key has its own bucket
+type Limiter interface {
+	Allow(key string) (Result, error)
+}
+
+// Config holds the rate limits and generation quotas, read from env variables
+type Config struct {
+	ProfileRatePerMinute float64
+	ProfileBurst         int
+	IPRatePerMinute      float64
+	IPBurst              int
+	DailyQuota           int
+	MonthlyQuota         int
+	// GenerationDailyQuota and GenerationMonthlyQuota limit the other LLM generations, e.g. tailored resumes
+	GenerationDailyQuota   int
+	GenerationMonthlyQuota int
+	// Backend is either "memory" or "store"
+	Backend string
+}
+
+// ConfigFromEnv returns the rate limiting configuration from env variables, using defaults when not set
+func ConfigFromEnv() Config {
+	return Config{
+		ProfileRatePerMinute:   envFloat("RATE_LIMIT_PROFILE_PER_MINUTE", 2),
+		ProfileBurst:           envInt("RATE_LIMIT_PROFILE_BURST", 5),
+		IPRatePerMinute:        envFloat("RATE_LIMIT_IP_PER_MINUTE", 10),
+		IPBurst:                envInt("RATE_LIMIT_IP_BURST", 20),
+		DailyQuota:             envInt("COVER_LETTER_DAILY_QUOTA", 20),
+		MonthlyQuota:           envInt("COVER_LETTER_MONTHLY_QUOTA", 200),
+		GenerationDailyQuota:   envInt("GENERATION_DAILY_QUOTA", 50),
+		GenerationMonthlyQuota: envInt("GENERATION_MONTHLY_QUOTA", 500),
+		Backend:                envString("RATE_LIMIT_BACKEND", "memory"),
+	}
+}
+
+// maxMemoryBuckets is the number of buckets kept in memory before pruning the full ones
+const maxMemoryBuckets = 10000
+
+// bucket is the state of a token bucket for a key
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// MemoryLimiter is an in-memory token bucket Limiter, for a single API instance
+type MemoryLimiter struct {
+	ratePerSecond float64
+	burst         int
+	buckets       map[string]*bucket
+	mutex         sync.Mutex
+	now           func() time.Time
+}
+
+// NewMemoryLimiter returns an in-memory Limiter that refills ratePerMinute tokens per minute, up to burst tokens
+func NewMemoryLimiter(ratePerMinute float64, burst int) *MemoryLimiter {
+	return &MemoryLimiter{
+		ratePerSecond: ratePerMinute / 60,
+		burst:         burst,
+		buckets:       map[string]*bucket{},
+		now:           time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of the given key
+func (l *MemoryLimiter) Allow(key string) (Result, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	if len(l.buckets) >= maxMemoryBuckets {
+		l.prune(now)
+	}
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: float64(l.burst), updatedAt: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.updatedAt).Seconds()*l.ratePerSecond)
+	b.updatedAt = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return newResult(allowed, b.tokens, l.ratePerSecond, l.burst), nil
+}
+
+// prune removes the buckets that have been refilled, as they are equivalent to new buckets
+func (l *MemoryLimiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updatedAt).Seconds()*l.ratePerSecond >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// TokenStore persists token buckets, so that limits are shared between API instances
+type TokenStore interface {
+	TakeRateLimitToken(key string, ratePerSecond float64, burst int) (bool, float64, error)
+}
+
+// StoreLimiter is a token bucket Limiter backed by the store
+type StoreLimiter struct {
+	store         TokenStore
+	ratePerSecond float64
+	burst         int
+}
+
+// NewStoreLimiter returns a Limiter backed by the store that refills ratePerMinute tokens per minute, up to burst tokens
+func NewStoreLimiter(store TokenStore, ratePerMinute float64, burst int) *StoreLimiter {
+	return &StoreLimiter{
+		store:         store,
+		ratePerSecond: ratePerMinute / 60,
+		burst:         burst,
+	}
+}
+
+// Allow takes a token from the bucket of the given key
+func (l *StoreLimiter) Allow(key string) (Result, error) {
+	allowed, tokens, err := l.store.TakeRateLimitToken(key, l.ratePerSecond, l.burst)
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(allowed, tokens, l.ratePerSecond, l.burst), nil
+}
+
+// newResult returns the Result for a bucket with the given remaining tokens
+func newResult(allowed bool, tokens float64, ratePerSecond float64, burst int) Result {
+	result := Result{
+		Allowed:    allowed,
+		Limit:      burst,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: tokensDuration(float64(burst)-tokens, ratePerSecond),
+	}
+	if !allowed {
+		result.RetryAfter = tokensDuration(1-tokens, ratePerSecond)
+	}
+	return result
+}
+
+// tokensDuration returns how long it takes to refill the given number of tokens
+func tokensDuration(tokens float64, ratePerSecond float64) time.Duration {
+	if tokens <= 0 || ratePerSecond <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(tokens / ratePerSecond * float64(time.Second)))
+}
+
+func envString(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func envFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
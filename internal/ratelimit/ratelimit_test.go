@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter(60, 2)
+	limiter.now = func() time.Time { return now }
+
+	t.Run("allows up to the burst", func(t *testing.T) {
+		for remaining := 1; remaining >= 0; remaining-- {
+			result, err := limiter.Allow("profile")
+			assert.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, 2, result.Limit)
+			assert.Equal(t, remaining, result.Remaining)
+		}
+
+		result, err := limiter.Allow("profile")
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, time.Second, result.RetryAfter)
+	})
+
+	t.Run("keys have their own buckets", func(t *testing.T) {
+		result, err := limiter.Allow("ip")
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("refills tokens over time", func(t *testing.T) {
+		now = now.Add(time.Second)
+		result, err := limiter.Allow("profile")
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+	})
+}
+
+func TestMemoryQuota(t *testing.T) {
+	now := time.Date(2023, 10, 31, 12, 0, 0, 0, time.UTC)
+	quota := NewMemoryQuota(2, 3).(*periodQuota)
+	quota.now = func() time.Time { return now }
+	profileId := uuid.New()
+	var consumed QuotaResult
+
+	t.Run("enforces the daily quota", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			result, err := quota.Consume(profileId)
+			assert.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, []string{"2023-10-31", "2023-10"}, result.PeriodKeys)
+			consumed = result
+		}
+
+		result, err := quota.Consume(profileId)
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, QuotaPeriodDaily, result.Period)
+		assert.Equal(t, time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC), result.ResetAt)
+	})
+
+	t.Run("refunds failed generations", func(t *testing.T) {
+		assert.NoError(t, quota.Refund(profileId, consumed.PeriodKeys))
+		result, err := quota.Consume(profileId)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("enforces the monthly quota", func(t *testing.T) {
+		// The previous day is in the same month, which only has one generation left
+		now = now.Add(-24 * time.Hour)
+		result, err := quota.Consume(profileId)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, QuotaPeriodMonthly, result.Period)
+		assert.Equal(t, 0, result.Remaining)
+
+		result, err = quota.Consume(profileId)
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, QuotaPeriodMonthly, result.Period)
+	})
+
+	t.Run("refunds in the periods the generation was counted in", func(t *testing.T) {
+		now = time.Date(2023, 11, 30, 23, 59, 0, 0, time.UTC)
+		result, err := quota.Consume(profileId)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+
+		// The generation failing after the end of the periods is not refunded from the next ones
+		now = time.Date(2023, 12, 1, 0, 1, 0, 0, time.UTC)
+		for i := 0; i < 2; i++ {
+			_, err := quota.Consume(profileId)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, quota.Refund(profileId, result.PeriodKeys))
+		result, err = quota.Consume(profileId)
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, QuotaPeriodDaily, result.Period)
+	})
+}
+
+func TestMemoryQuotaPruning(t *testing.T) {
+	now := time.Date(2023, 10, 31, 12, 0, 0, 0, time.UTC)
+	counter := &memoryCounter{periods: map[string]*memoryPeriod{}, now: func() time.Time { return now }}
+	quota := &periodQuota{periods: quotaPeriods(2, 3), count: counter.count, now: func() time.Time { return now }}
+	periodKeys := func() []string {
+		var keys []string
+		for key := range counter.periods {
+			keys = append(keys, key)
+		}
+		return keys
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := quota.Consume(uuid.New())
+		assert.NoError(t, err)
+	}
+	assert.ElementsMatch(t, []string{"2023-10-31", "2023-10"}, periodKeys())
+	assert.Len(t, counter.periods["2023-10"].counts, 3)
+
+	// The periods that are over are removed, and are not recreated by refunds
+	now = time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC)
+	profileId := uuid.New()
+	_, err := quota.Consume(profileId)
+	assert.NoError(t, err)
+	assert.NoError(t, quota.Refund(profileId, []string{"2023-10-31", "2023-10"}))
+	assert.ElementsMatch(t, []string{"2023-11-01", "2023-11"}, periodKeys())
+
+	// The profiles without generations left in a period are removed from it
+	assert.NoError(t, quota.Refund(profileId, []string{"2023-11-01"}))
+	assert.Empty(t, counter.periods["2023-11-01"].counts)
+	assert.Equal(t, map[uuid.UUID]int{profileId: 1}, counter.periods["2023-11"].counts)
+}
@@ -0,0 +1,190 @@
+// Package skillsgap compares the skills of a career profile with the skills of a job posting, matching normalized
+// keywords, and combines the keyword match with the analysis of the LLM provider
+package skillsgap
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jonada182/cover-letter-ai-api/internal/jobposting"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// MaxSkillWords is the max number of words of the skills listed in the job posting details, which tells them
+// apart from the requirement sentences (e.g. "5+ years of experience building APIs")
+const MaxSkillWords = 4
+
+// aliases map the common spellings and abbreviations of skills to the same normalized name
+var aliases = map[string]string{
+	"golang":                  "go",
+	"js":                      "javascript",
+	"ts":                      "typescript",
+	"k8s":                     "kubernetes",
+	"postgres":                "postgresql",
+	"psql":                    "postgresql",
+	"nodejs":                  "node",
+	"node.js":                 "node",
+	"reactjs":                 "react",
+	"react.js":                "react",
+	"vuejs":                   "vue",
+	"vue.js":                  "vue",
+	"amazon web services":     "aws",
+	"google cloud platform":   "gcp",
+	"google cloud":            "gcp",
+	"ci cd":                   "ci/cd",
+	"machine learning":        "ml",
+	"artificial intelligence": "ai",
+	"mongo":                   "mongodb",
+}
+
+// separators split the lists of skills
+var separators = regexp.MustCompile(`[,;\n•|]|\s+/\s+`)
+
+// nonSkillCharacters are removed from the skills, keeping the characters of names like c++, c#, .net or ci/cd
+var nonSkillCharacters = regexp.MustCompile(`[^\p{L}\p{N}+#./ -]+`)
+
+// Normalize returns the comparable form of a skill: lower case, without punctuation and with the aliases replaced
+func Normalize(skill string) string {
+	skill = strings.ToLower(skill)
+	skill = nonSkillCharacters.ReplaceAllString(skill, " ")
+	skill = strings.Join(strings.Fields(strings.ReplaceAll(skill, "-", " ")), " ")
+	skill = strings.Trim(skill, " .")
+	if alias, ok := aliases[skill]; ok {
+		return alias
+	}
+	return skill
+}
+
+// JobSkills returns the skills of a job posting, from its skills list or, when it is empty, from the short items of
+// the requirements and nice to have sections of its details
+func JobSkills(jobPosting *types.JobPosting) []string {
+	skills := splitSkills(jobPosting.Skills)
+	if len(skills) > 0 {
+		return skills
+	}
+	extracted := jobposting.Extract(jobPosting.Details)
+	for _, item := range append(extracted.RequiredSkills, extracted.NiceToHaveSkills...) {
+		if len(strings.Fields(item)) <= MaxSkillWords {
+			skills = appendUnique(skills, item)
+		}
+	}
+	return skills
+}
+
+// splitSkills splits a list of skills, dropping the empty and duplicated skills
+func splitSkills(list string) []string {
+	var skills []string
+	for _, skill := range separators.Split(list, -1) {
+		if skill = strings.TrimSpace(skill); Normalize(skill) != "" {
+			skills = appendUnique(skills, skill)
+		}
+	}
+	return skills
+}
+
+func appendUnique(skills []string, skill string) []string {
+	for _, existing := range skills {
+		if Normalize(existing) == Normalize(skill) {
+			return skills
+		}
+	}
+	return append(skills, skill)
+}
+
+// Match compares the skills of the job posting with the skills of the career profile, and with the words of its
+// headline and summary, returning the analysis of the keyword match scored by the share of matched skills
+func Match(careerProfile *types.CareerProfile, jobPosting *types.JobPosting) types.SkillsGapAnalysis {
+	profileSkills := map[string]bool{}
+	if careerProfile.Skills != nil {
+		for _, skill := range *careerProfile.Skills {
+			profileSkills[Normalize(skill)] = true
+		}
+	}
+	profileText := " " + Normalize(careerProfile.Headline) + " "
+	if careerProfile.Summary != nil {
+		profileText += Normalize(*careerProfile.Summary) + " "
+	}
+
+	analysis := types.SkillsGapAnalysis{
+		MatchedSkills:      []string{},
+		MissingSkills:      []string{},
+		TransferableSkills: []types.TransferableSkill{},
+	}
+	jobSkills := JobSkills(jobPosting)
+	for _, skill := range jobSkills {
+		normalized := Normalize(skill)
+		if profileSkills[normalized] || mentions(profileText, normalized) {
+			analysis.MatchedSkills = append(analysis.MatchedSkills, skill)
+		} else {
+			analysis.MissingSkills = append(analysis.MissingSkills, skill)
+		}
+	}
+
+	if len(jobSkills) == 0 {
+		analysis.Explanations = []string{"The job posting does not list any skills to compare with the career profile."}
+		return analysis
+	}
+	analysis.KeywordScore = 100 * len(analysis.MatchedSkills) / len(jobSkills)
+	analysis.Score = analysis.KeywordScore
+	analysis.Explanations = []string{fmt.Sprintf("%d of the %d skills of the job posting are in the career profile.", len(analysis.MatchedSkills), len(jobSkills))}
+	return analysis
+}
+
+// mentions reports whether the normalized profile text mentions a normalized skill, or one of its aliases
+func mentions(profileText string, skill string) bool {
+	if strings.Contains(profileText, " "+skill+" ") {
+		return true
+	}
+	for alias, name := range aliases {
+		if name == skill && strings.Contains(profileText, " "+alias+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// Combine adds the analysis of the LLM provider to the keyword match: the skills matched by keyword stay matched,
+// the missing skills the LLM provider found transferable are moved to the transferable skills, and the score is
+// the average of the keyword and LLM scores
+func Combine(keywordMatch types.SkillsGapAnalysis, llmAnalysis types.SkillsGapAnalysis) types.SkillsGapAnalysis {
+	combined := types.SkillsGapAnalysis{
+		KeywordScore:       keywordMatch.KeywordScore,
+		MatchedSkills:      append([]string{}, keywordMatch.MatchedSkills...),
+		MissingSkills:      []string{},
+		TransferableSkills: []types.TransferableSkill{},
+		Explanations:       append([]string{}, keywordMatch.Explanations...),
+	}
+	llmScore := min(max(llmAnalysis.Score, 0), 100)
+	combined.LLMScore = &llmScore
+	combined.Score = (keywordMatch.KeywordScore + llmScore + 1) / 2
+	if len(keywordMatch.MatchedSkills)+len(keywordMatch.MissingSkills) == 0 {
+		// Without skills listed in the job posting, only the LLM provider can score the match
+		combined.Score = llmScore
+	}
+	combined.Explanations = append(combined.Explanations, llmAnalysis.Explanations...)
+
+	matched := map[string]bool{}
+	for _, skill := range combined.MatchedSkills {
+		matched[Normalize(skill)] = true
+	}
+	for _, skill := range llmAnalysis.MatchedSkills {
+		if !matched[Normalize(skill)] {
+			matched[Normalize(skill)] = true
+			combined.MatchedSkills = append(combined.MatchedSkills, skill)
+		}
+	}
+	for _, transferable := range llmAnalysis.TransferableSkills {
+		if !matched[Normalize(transferable.Skill)] {
+			matched[Normalize(transferable.Skill)] = true
+			combined.TransferableSkills = append(combined.TransferableSkills, transferable)
+		}
+	}
+	for _, skill := range append(append([]string{}, keywordMatch.MissingSkills...), llmAnalysis.MissingSkills...) {
+		if !matched[Normalize(skill)] {
+			matched[Normalize(skill)] = true
+			combined.MissingSkills = append(combined.MissingSkills, skill)
+		}
+	}
+	return combined
+}
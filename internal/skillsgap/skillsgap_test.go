@@ -0,0 +1,91 @@
+package skillsgap
+
+import (
+	"testing"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	for skill, expected := range map[string]string{
+		" Golang ":        "go",
+		"Node.js":         "node",
+		"C++":             "c++",
+		"C#":              "c#",
+		"CI-CD":           "ci/cd",
+		"PostgreSQL.":     "postgresql",
+		"Problem-solving": "problem solving",
+		"(Kubernetes)":    "kubernetes",
+	} {
+		assert.Equal(t, expected, Normalize(skill), skill)
+	}
+}
+
+func TestJobSkills(t *testing.T) {
+	assert.Equal(t, []string{"Go", "PostgreSQL", "Kubernetes"}, JobSkills(&types.JobPosting{Skills: "Go, PostgreSQL; golang\nKubernetes,"}))
+	assert.Equal(t, []string{"SQL", "Python", "dbt"}, JobSkills(&types.JobPosting{
+		Details: "Requirements:\n- SQL\n- Python\n- 3+ years of experience in analytics teams\n\nNice to have:\n- dbt",
+	}))
+}
+
+func TestMatch(t *testing.T) {
+	summary := "I built Node.js and golang services on AWS."
+	careerProfile := &types.CareerProfile{
+		Headline: "Backend Engineer",
+		Skills:   &[]string{"Postgres", "Docker"},
+		Summary:  &summary,
+	}
+
+	t.Run("skills of the profile and its summary", func(t *testing.T) {
+		analysis := Match(careerProfile, &types.JobPosting{Skills: "Go, PostgreSQL, Kubernetes, Node, Terraform"})
+
+		assert.Equal(t, []string{"Go", "PostgreSQL", "Node"}, analysis.MatchedSkills)
+		assert.Equal(t, []string{"Kubernetes", "Terraform"}, analysis.MissingSkills)
+		assert.Equal(t, 60, analysis.KeywordScore)
+		assert.Equal(t, 60, analysis.Score)
+		assert.Nil(t, analysis.LLMScore)
+		assert.Equal(t, []string{"3 of the 5 skills of the job posting are in the career profile."}, analysis.Explanations)
+	})
+
+	t.Run("job posting without skills", func(t *testing.T) {
+		analysis := Match(careerProfile, &types.JobPosting{Details: "Join our team."})
+
+		assert.Equal(t, 0, analysis.Score)
+		assert.Equal(t, []string{}, analysis.MatchedSkills)
+		assert.Len(t, analysis.Explanations, 1)
+	})
+}
+
+func TestCombine(t *testing.T) {
+	keywordMatch := types.SkillsGapAnalysis{
+		Score:         50,
+		KeywordScore:  50,
+		MatchedSkills: []string{"Go", "PostgreSQL"},
+		MissingSkills: []string{"Kubernetes", "Terraform"},
+		Explanations:  []string{"2 of the 4 skills of the job posting are in the career profile."},
+	}
+	llmAnalysis := types.SkillsGapAnalysis{
+		Score:         81,
+		MatchedSkills: []string{"golang", "Leadership"},
+		MissingSkills: []string{"Terraform", "Kubernetes"},
+		TransferableSkills: []types.TransferableSkill{
+			{Skill: "Kubernetes", ProfileSkills: []string{"Docker"}, Explanation: "Containers experience."},
+			{Skill: "Go", ProfileSkills: []string{"Go"}},
+		},
+		Explanations: []string{"A strong backend profile."},
+	}
+
+	combined := Combine(keywordMatch, llmAnalysis)
+	assert.Equal(t, 66, combined.Score)
+	assert.Equal(t, 50, combined.KeywordScore)
+	assert.Equal(t, 81, *combined.LLMScore)
+	assert.Equal(t, []string{"Go", "PostgreSQL", "Leadership"}, combined.MatchedSkills)
+	assert.Equal(t, []types.TransferableSkill{{Skill: "Kubernetes", ProfileSkills: []string{"Docker"}, Explanation: "Containers experience."}}, combined.TransferableSkills)
+	assert.Equal(t, []string{"Terraform"}, combined.MissingSkills)
+	assert.Equal(t, []string{"2 of the 4 skills of the job posting are in the career profile.", "A strong backend profile."}, combined.Explanations)
+	assert.Equal(t, []string{"Kubernetes", "Terraform"}, keywordMatch.MissingSkills)
+
+	combined = Combine(types.SkillsGapAnalysis{}, types.SkillsGapAnalysis{Score: 120})
+	assert.Equal(t, 100, combined.Score)
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"log"
+	"time"
+
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetCachedCoverLetter retrieves a cached cover letter by its key from MongoDB, unless it expired
+func (store *StoreClient) GetCachedCoverLetter(key string) (*types.CachedCoverLetter, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	var cachedCoverLetter types.CachedCoverLetter
+	// Get the cover_letter_cache collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("cover_letter_cache")
+	// The expired cover letters are filtered out, since the TTL index only removes them periodically
+	err = collection.FindOne(ctx, bson.M{"key": key, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&cachedCoverLetter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cachedCoverLetter, nil
+}
+
+// StoreCachedCoverLetter upserts a cached cover letter by its key in MongoDB, with a TTL index removing
+// the cover letters once they expire
+func (store *StoreClient) StoreCachedCoverLetter(cachedCoverLetter *types.CachedCoverLetter) error {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	// Get the cover_letter_cache collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("cover_letter_cache")
+	// Creating an index that already exists does nothing
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		log.Printf("Failed to create cover letter cache index:%s", err.Error())
+		return err
+	}
+
+	_, err = collection.ReplaceOne(ctx, bson.M{"key": cachedCoverLetter.Key}, cachedCoverLetter, options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Printf("Failed to cache cover letter:%s", err.Error())
+		return err
+	}
+
+	return nil
+}
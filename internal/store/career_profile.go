@@ -33,6 +33,7 @@ func (store *StoreClient) StoreCareerProfile(careerProfile *types.CareerProfile)
 		Summary:         careerProfile.Summary,
 		Skills:          careerProfile.Skills,
 		ContactInfo:     careerProfile.ContactInfo,
+		WorkHistory:     careerProfile.WorkHistory,
 	}
 	// Set up update options to ensure the values are overwritten in the database
 	update := bson.M{"$set": careerProfileRow}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StoreCoverLetter inserts a generated CoverLetter in MongoDB
+func (store *StoreClient) StoreCoverLetter(coverLetter *types.CoverLetter) (*types.CoverLetter, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	// Get the cover_letters collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("cover_letters")
+	currentDateTime := time.Now().Format(DateTimeFormat)
+	coverLetterRow := *coverLetter
+	coverLetterRow.ID = uuid.New()
+	coverLetterRow.CreatedAt = currentDateTime
+	coverLetterRow.UpdatedAt = currentDateTime
+	if coverLetterRow.Version == 0 {
+		coverLetterRow.Version = 1
+	}
+
+	_, err = collection.InsertOne(ctx, coverLetterRow)
+	if err != nil {
+		log.Printf("Failed to insert cover letter:%s", err.Error())
+		return nil, err
+	}
+
+	return &coverLetterRow, nil
+}
+
+// GetCoverLetters retrieves the cover letters of a profile from MongoDB, starting with the most recent
+func (store *StoreClient) GetCoverLetters(profileId uuid.UUID) (*[]types.CoverLetter, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	var coverLetters []types.CoverLetter
+	// Get the cover_letters collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("cover_letters")
+	// Find cover letters using the career profile id
+	log.Printf("Find cover letters for %s", profileId.String())
+	options := options.Find()
+	options.SetSort(bson.M{"created_at": -1})
+	cur, err := collection.Find(ctx, bson.M{"profile_id": profileId}, options)
+	if err != nil {
+		log.Printf("Failed to retrieve cover letters:%s", err.Error())
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	if err := cur.All(ctx, &coverLetters); err != nil {
+		log.Printf("Failed to retrieve cover letters:%s", err.Error())
+		return nil, err
+	}
+
+	if len(coverLetters) == 0 {
+		return nil, errors.New("no cover letters found")
+	}
+
+	return &coverLetters, nil
+}
+
+// GetCoverLetterByID retrieves a cover letter of a profile by ID from MongoDB
+func (store *StoreClient) GetCoverLetterByID(profileId uuid.UUID, coverLetterId uuid.UUID) (*types.CoverLetter, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	var coverLetter types.CoverLetter
+	// Get the cover_letters collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("cover_letters")
+	// Find the cover letter only if it belongs to the profile
+	log.Printf("Find cover letter for %s", coverLetterId.String())
+	err = collection.FindOne(ctx, bson.M{"id": coverLetterId, "profile_id": profileId}).Decode(&coverLetter)
+	if err != nil {
+		log.Printf("Failed to find cover letter:%s", err.Error())
+		return nil, err
+	}
+
+	return &coverLetter, nil
+}
+
+// UpdateCoverLetterContent saves the content of a cover letter edited by its profile in MongoDB
+func (store *StoreClient) UpdateCoverLetterContent(profileId uuid.UUID, coverLetterId uuid.UUID, content string) (*types.CoverLetter, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	var coverLetter types.CoverLetter
+	// Get the cover_letters collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("cover_letters")
+	update := bson.M{"$set": bson.M{
+		"content":    content,
+		"edited":     true,
+		"updated_at": time.Now().Format(DateTimeFormat),
+	}}
+	updateOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = collection.FindOneAndUpdate(ctx, bson.M{"id": coverLetterId, "profile_id": profileId}, update, updateOptions).Decode(&coverLetter)
+	if err != nil {
+		log.Printf("Failed to update cover letter:%s", err.Error())
+		return nil, err
+	}
+
+	return &coverLetter, nil
+}
+
+// DeleteCoverLetter deletes a cover letter of a profile from MongoDB
+func (store *StoreClient) DeleteCoverLetter(profileId uuid.UUID, coverLetterId uuid.UUID) error {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	// Get the cover_letters collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("cover_letters")
+	// Delete the cover letter only if it belongs to the profile
+	log.Printf("Deleting cover letter %s", coverLetterId.String())
+	result, err := collection.DeleteOne(ctx, bson.M{"id": coverLetterId, "profile_id": profileId})
+	if err != nil {
+		log.Printf("Failed to delete cover letter:%s", err.Error())
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return errors.New("no cover letter found")
+	}
+
+	log.Printf("Deleted cover letter %s", coverLetterId.String())
+	return nil
+}
+
+// GetJobApplicationCoverLetters retrieves the cover letters generated for a job application of a profile from MongoDB,
+// starting with the most recent
+func (store *StoreClient) GetJobApplicationCoverLetters(profileId uuid.UUID, jobApplicationId uuid.UUID) (*[]types.CoverLetter, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	var coverLetters []types.CoverLetter
+	// Get the cover_letters collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("cover_letters")
+	// Find cover letters using the job application id, only if they belong to the profile
+	log.Printf("Find cover letters for job application %s", jobApplicationId.String())
+	options := options.Find()
+	options.SetSort(bson.M{"created_at": -1})
+	cur, err := collection.Find(ctx, bson.M{"profile_id": profileId, "job_application_id": jobApplicationId}, options)
+	if err != nil {
+		log.Printf("Failed to retrieve cover letters:%s", err.Error())
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	if err := cur.All(ctx, &coverLetters); err != nil {
+		log.Printf("Failed to retrieve cover letters:%s", err.Error())
+		return nil, err
+	}
+
+	if len(coverLetters) == 0 {
+		return nil, errors.New("no cover letters found")
+	}
+
+	return &coverLetters, nil
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StoreInterviewPrep inserts a generated InterviewPrep of a job application in MongoDB
+func (store *StoreClient) StoreInterviewPrep(interviewPrep *types.InterviewPrep) (*types.InterviewPrep, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	// Get the interview_preps collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("interview_preps")
+	currentDateTime := time.Now().Format(DateTimeFormat)
+	interviewPrepRow := *interviewPrep
+	interviewPrepRow.ID = uuid.New()
+	interviewPrepRow.CreatedAt = currentDateTime
+	interviewPrepRow.UpdatedAt = currentDateTime
+
+	_, err = collection.InsertOne(ctx, interviewPrepRow)
+	if err != nil {
+		log.Printf("Failed to insert interview prep:%s", err.Error())
+		return nil, err
+	}
+
+	return &interviewPrepRow, nil
+}
+
+// GetInterviewPrep retrieves the most recent interview prep of a job application of a profile from MongoDB
+func (store *StoreClient) GetInterviewPrep(profileId uuid.UUID, jobApplicationId uuid.UUID) (*types.InterviewPrep, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	var interviewPrep types.InterviewPrep
+	// Get the interview_preps collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("interview_preps")
+	// Find the interview prep only if it belongs to the profile
+	log.Printf("Find interview prep for %s", jobApplicationId.String())
+	options := options.FindOne().SetSort(bson.M{"created_at": -1})
+	err = collection.FindOne(ctx, bson.M{"profile_id": profileId, "job_application_id": jobApplicationId}, options).Decode(&interviewPrep)
+	if err != nil {
+		log.Printf("Failed to find interview prep:%s", err.Error())
+		return nil, err
+	}
+
+	return &interviewPrep, nil
+}
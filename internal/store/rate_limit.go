@@ -0,0 +1,82 @@
+package store
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TakeRateLimitToken refills the token bucket of a key and takes a token from it when available,
+// returning whether the token was taken and the remaining tokens
+func (store *StoreClient) TakeRateLimitToken(key string, ratePerSecond float64, burst int) (bool, float64, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return false, 0, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	// Get the rate_limits collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("rate_limits")
+	now := time.Now()
+	// Refill and take a token in a single update pipeline, so concurrent requests cannot take the same token
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tokens": bson.M{"$min": bson.A{
+				burst,
+				bson.M{"$add": bson.A{
+					bson.M{"$ifNull": bson.A{"$tokens", burst}},
+					bson.M{"$multiply": bson.A{
+						bson.M{"$divide": bson.A{bson.M{"$subtract": bson.A{now, bson.M{"$ifNull": bson.A{"$updated_at", now}}}}, 1000}},
+						ratePerSecond,
+					}},
+				}},
+			}},
+			"updated_at": now,
+		}}},
+		{{Key: "$set", Value: bson.M{"allowed": bson.M{"$gte": bson.A{"$tokens", 1}}}}},
+		{{Key: "$set", Value: bson.M{"tokens": bson.M{"$cond": bson.A{"$allowed", bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}}}}},
+	}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var rateLimitBucket struct {
+		Tokens  float64 `bson:"tokens"`
+		Allowed bool    `bson:"allowed"`
+	}
+	err = collection.FindOneAndUpdate(ctx, bson.M{"key": key}, update, updateOptions).Decode(&rateLimitBucket)
+	if err != nil {
+		log.Printf("Failed to take rate limit token:%s", err.Error())
+		return false, 0, err
+	}
+
+	return rateLimitBucket.Allowed, rateLimitBucket.Tokens, nil
+}
+
+// IncrementGenerationCount adds delta to the generations of a profile for a quota period,
+// returning the new count
+func (store *StoreClient) IncrementGenerationCount(profileId uuid.UUID, period string, delta int) (int, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return 0, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	// Get the generation_quotas collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("generation_quotas")
+	update := bson.M{"$inc": bson.M{"count": delta}}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var generationQuota struct {
+		Count int `bson:"count"`
+	}
+	err = collection.FindOneAndUpdate(ctx, bson.M{"profile_id": profileId, "period": period}, update, updateOptions).Decode(&generationQuota)
+	if err != nil {
+		log.Printf("Failed to increment generation count:%s", err.Error())
+		return 0, err
+	}
+
+	return generationQuota.Count, nil
+}
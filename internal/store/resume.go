@@ -0,0 +1,92 @@
+package store
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StoreResume inserts a tailored Resume in MongoDB
+func (store *StoreClient) StoreResume(resume *types.Resume) (*types.Resume, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	// Get the resumes collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("resumes")
+	currentDateTime := time.Now().Format(DateTimeFormat)
+	resumeRow := *resume
+	resumeRow.ID = uuid.New()
+	resumeRow.CreatedAt = currentDateTime
+	resumeRow.UpdatedAt = currentDateTime
+
+	_, err = collection.InsertOne(ctx, resumeRow)
+	if err != nil {
+		log.Printf("Failed to insert resume:%s", err.Error())
+		return nil, err
+	}
+
+	return &resumeRow, nil
+}
+
+// GetResumes retrieves the resumes of a profile from MongoDB, starting with the most recent
+func (store *StoreClient) GetResumes(profileId uuid.UUID) (*[]types.Resume, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	var resumes []types.Resume
+	// Get the resumes collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("resumes")
+	// Find resumes using the career profile id
+	log.Printf("Find resumes for %s", profileId.String())
+	options := options.Find()
+	options.SetSort(bson.M{"created_at": -1})
+	cur, err := collection.Find(ctx, bson.M{"profile_id": profileId}, options)
+	if err != nil {
+		log.Printf("Failed to retrieve resumes:%s", err.Error())
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	if err := cur.All(ctx, &resumes); err != nil {
+		log.Printf("Failed to retrieve resumes:%s", err.Error())
+		return nil, err
+	}
+
+	if len(resumes) == 0 {
+		return nil, errors.New("no resumes found")
+	}
+
+	return &resumes, nil
+}
+
+// GetResumeByID retrieves a resume of a profile by ID from MongoDB
+func (store *StoreClient) GetResumeByID(profileId uuid.UUID, resumeId uuid.UUID) (*types.Resume, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	var resume types.Resume
+	// Get the resumes collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("resumes")
+	// Find the resume only if it belongs to the profile
+	log.Printf("Find resume for %s", resumeId.String())
+	err = collection.FindOne(ctx, bson.M{"id": resumeId, "profile_id": profileId}).Decode(&resume)
+	if err != nil {
+		log.Printf("Failed to find resume:%s", err.Error())
+		return nil, err
+	}
+
+	return &resume, nil
+}
@@ -44,6 +44,23 @@ type Store interface {
 	DeleteJobApplication(jobApplicationId uuid.UUID) error
 	StoreAccessToken(profileId uuid.UUID, accessToken string, ipAddress string) (string, error)
 	ValidateAccessToken(profileId uuid.UUID, accessToken string, ipAddress string) (bool, error)
+	TakeRateLimitToken(key string, ratePerSecond float64, burst int) (bool, float64, error)
+	IncrementGenerationCount(profileId uuid.UUID, period string, delta int) (int, error)
+	StoreCoverLetter(coverLetter *types.CoverLetter) (*types.CoverLetter, error)
+	GetCoverLetters(profileId uuid.UUID) (*[]types.CoverLetter, error)
+	GetJobApplicationCoverLetters(profileId uuid.UUID, jobApplicationId uuid.UUID) (*[]types.CoverLetter, error)
+	GetCoverLetterByID(profileId uuid.UUID, coverLetterId uuid.UUID) (*types.CoverLetter, error)
+	UpdateCoverLetterContent(profileId uuid.UUID, coverLetterId uuid.UUID, content string) (*types.CoverLetter, error)
+	DeleteCoverLetter(profileId uuid.UUID, coverLetterId uuid.UUID) error
+	GetCachedCoverLetter(key string) (*types.CachedCoverLetter, error)
+	StoreCachedCoverLetter(cachedCoverLetter *types.CachedCoverLetter) error
+	StoreUsageRecord(usageRecord *types.UsageRecord) error
+	GetDailyUsage(profileId *uuid.UUID, from string, to string) (*[]types.DailyUsage, error)
+	StoreResume(resume *types.Resume) (*types.Resume, error)
+	GetResumes(profileId uuid.UUID) (*[]types.Resume, error)
+	GetResumeByID(profileId uuid.UUID, resumeId uuid.UUID) (*types.Resume, error)
+	StoreInterviewPrep(interviewPrep *types.InterviewPrep) (*types.InterviewPrep, error)
+	GetInterviewPrep(profileId uuid.UUID, jobApplicationId uuid.UUID) (*types.InterviewPrep, error)
 }
 
 // NewStore returns a store client, which has methods to interact with MongoDB
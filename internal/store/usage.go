@@ -0,0 +1,90 @@
+package store
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// StoreUsageRecord inserts the token usage of a LLM call in MongoDB
+func (store *StoreClient) StoreUsageRecord(usageRecord *types.UsageRecord) error {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	// Get the llm_usage collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("llm_usage")
+	usageRecordRow := *usageRecord
+	usageRecordRow.ID = uuid.New()
+	usageRecordRow.CreatedAt = time.Now().Format(DateTimeFormat)
+
+	_, err = collection.InsertOne(ctx, usageRecordRow)
+	if err != nil {
+		log.Printf("Failed to insert usage record:%s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetDailyUsage aggregates the usage records between two dates, both included, per profile and per day,
+// for the given profile or for every profile when it is nil
+func (store *StoreClient) GetDailyUsage(profileId *uuid.UUID, from string, to string) (*[]types.DailyUsage, error) {
+	mongoClient, ctx, err := store.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Disconnect(ctx, mongoClient)
+
+	// Get the llm_usage collection from the database client
+	collection := mongoClient.Database(store.dbName).Collection("llm_usage")
+	// Dates are formatted as 2006-01-02, so they can be compared as strings
+	match := bson.M{"date": bson.M{"$gte": from, "$lte": to}}
+	if profileId != nil {
+		match["profile_id"] = *profileId
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":               bson.M{"profile_id": "$profile_id", "date": "$date"},
+			"calls":             bson.M{"$sum": 1},
+			"prompt_tokens":     bson.M{"$sum": "$usage.prompt_tokens"},
+			"completion_tokens": bson.M{"$sum": "$usage.completion_tokens"},
+			"total_tokens":      bson.M{"$sum": "$usage.total_tokens"},
+			"cost":              bson.M{"$sum": "$cost"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":        0,
+			"profile_id": "$_id.profile_id",
+			"date":       "$_id.date",
+			"calls":      1,
+			"usage": bson.M{
+				"prompt_tokens":     "$prompt_tokens",
+				"completion_tokens": "$completion_tokens",
+				"total_tokens":      "$total_tokens",
+			},
+			"cost": 1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "date", Value: 1}, {Key: "profile_id", Value: 1}}}},
+	}
+	cur, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Failed to aggregate usage:%s", err.Error())
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	dailyUsage := []types.DailyUsage{}
+	if err := cur.All(ctx, &dailyUsage); err != nil {
+		log.Printf("Failed to aggregate usage:%s", err.Error())
+		return nil, err
+	}
+
+	return &dailyUsage, nil
+}
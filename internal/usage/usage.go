@@ -0,0 +1,158 @@
+// Package usage estimates the cost of the LLM calls from a per model price table, and summarizes the usage
+// recorded per profile and per day
+package usage
+
+import (
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/types"
+)
+
+// Operations of the recorded LLM calls
+const (
+	OperationCoverLetter         = "cover_letter"
+	OperationCoverLetterStream   = "cover_letter_stream"
+	OperationCoverLetterVariant  = "cover_letter_variant"
+	OperationCoverLetterRanking  = "cover_letter_ranking"
+	OperationCoverLetterRevision = "cover_letter_revision"
+	OperationJobPostingParse     = "job_posting_parse"
+	OperationSkillsGap           = "skills_gap"
+	OperationResumeTailor        = "resume_tailor"
+	OperationInterviewPrep       = "interview_prep"
+)
+
+// DateFormat is the format of the days the usage is aggregated by
+const DateFormat = "2006-01-02"
+
+// Price is the price of a model in USD per million tokens
+type Price struct {
+	Prompt     float64
+	Completion float64
+}
+
+// Prices are the prices of the models by model name prefix, e.g. gpt-4o matches gpt-4o-2024-05-13
+type Prices map[string]Price
+
+// DefaultPrices are the list prices of the OpenAI and Anthropic models
+var DefaultPrices = Prices{
+	"gpt-3.5-turbo":     {Prompt: 0.5, Completion: 1.5},
+	"gpt-4":             {Prompt: 30, Completion: 60},
+	"gpt-4-32k":         {Prompt: 60, Completion: 120},
+	"gpt-4-turbo":       {Prompt: 10, Completion: 30},
+	"gpt-4o":            {Prompt: 5, Completion: 15},
+	"gpt-4o-mini":       {Prompt: 0.15, Completion: 0.6},
+	"claude-3-haiku":    {Prompt: 0.25, Completion: 1.25},
+	"claude-3-sonnet":   {Prompt: 3, Completion: 15},
+	"claude-3-5-sonnet": {Prompt: 3, Completion: 15},
+	"claude-3-opus":     {Prompt: 15, Completion: 75},
+}
+
+// PricesFromEnv returns the DefaultPrices with the prices of the comma separated LLM_PRICES env variable,
+// e.g. "gpt-4o=5:15,llama3=0:0" for the prompt and completion prices in USD per million tokens
+func PricesFromEnv() Prices {
+	prices := Prices{}
+	for model, price := range DefaultPrices {
+		prices[model] = price
+	}
+	for _, entry := range strings.Split(os.Getenv("LLM_PRICES"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		model, price, ok := parsePrice(entry)
+		if !ok {
+			log.Printf("ignoring invalid LLM price: %s", entry)
+			continue
+		}
+		prices[model] = price
+	}
+	return prices
+}
+
+// parsePrice parses a model price in the model=prompt:completion format
+func parsePrice(entry string) (string, Price, bool) {
+	model, value, found := strings.Cut(entry, "=")
+	if !found || strings.TrimSpace(model) == "" {
+		return "", Price{}, false
+	}
+	promptValue, completionValue, found := strings.Cut(value, ":")
+	if !found {
+		return "", Price{}, false
+	}
+	prompt, err := strconv.ParseFloat(strings.TrimSpace(promptValue), 64)
+	if err != nil || prompt < 0 {
+		return "", Price{}, false
+	}
+	completion, err := strconv.ParseFloat(strings.TrimSpace(completionValue), 64)
+	if err != nil || completion < 0 {
+		return "", Price{}, false
+	}
+	return strings.TrimSpace(model), Price{Prompt: prompt, Completion: completion}, true
+}
+
+// Price returns the price of the longest model name prefix of the model, and whether the model has a price
+func (p Prices) Price(model string) (Price, bool) {
+	var price Price
+	matched := ""
+	for prefix, prefixPrice := range p {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(matched) {
+			price = prefixPrice
+			matched = prefix
+		}
+	}
+	return price, matched != ""
+}
+
+// Cost returns the estimated cost in USD of the token usage of a model, which is 0 for the models without a price
+func (p Prices) Cost(model string, usage types.TokenUsage) float64 {
+	price, _ := p.Price(model)
+	return roundCost((float64(usage.PromptTokens)*price.Prompt + float64(usage.CompletionTokens)*price.Completion) / 1e6)
+}
+
+// roundCost rounds a cost to a billionth of a dollar, avoiding float artifacts in the sums
+func roundCost(cost float64) float64 {
+	return math.Round(cost*1e9) / 1e9
+}
+
+// NewReport summarizes the daily usage of a period, with the total and, when perProfile is set, the total of each profile
+func NewReport(from string, to string, days []types.DailyUsage, perProfile bool) types.UsageReport {
+	report := types.UsageReport{From: from, To: to, Days: days}
+	if report.Days == nil {
+		report.Days = []types.DailyUsage{}
+	}
+	profiles := map[uuid.UUID]types.UsageSummary{}
+	for _, day := range days {
+		report.Total = addSummary(report.Total, day.Calls, day.Usage, day.Cost)
+		if perProfile {
+			profiles[day.ProfileID] = addSummary(profiles[day.ProfileID], day.Calls, day.Usage, day.Cost)
+		}
+	}
+	if perProfile {
+		report.Profiles = []types.ProfileUsage{}
+		for profileId, summary := range profiles {
+			report.Profiles = append(report.Profiles, types.ProfileUsage{ProfileID: profileId, Calls: summary.Calls, Usage: summary.Usage, Cost: summary.Cost})
+		}
+		// The profiles with the highest cost first
+		sort.Slice(report.Profiles, func(i, j int) bool {
+			if report.Profiles[i].Cost != report.Profiles[j].Cost {
+				return report.Profiles[i].Cost > report.Profiles[j].Cost
+			}
+			return report.Profiles[i].ProfileID.String() < report.Profiles[j].ProfileID.String()
+		})
+	}
+	return report
+}
+
+// addSummary adds the calls, token usage and cost of a day to a usage summary
+func addSummary(summary types.UsageSummary, calls int, usage types.TokenUsage, cost float64) types.UsageSummary {
+	return types.UsageSummary{
+		Calls: summary.Calls + calls,
+		Usage: summary.Usage.Add(usage),
+		Cost:  roundCost(summary.Cost + cost),
+	}
+}
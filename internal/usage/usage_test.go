@@ -0,0 +1,67 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jonada182/cover-letter-ai-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrices(t *testing.T) {
+	t.Run("cost of the longest matching model prefix", func(t *testing.T) {
+		usage := types.TokenUsage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500}
+		assert.Equal(t, 0.00125, DefaultPrices.Cost("gpt-3.5-turbo-0125", usage))
+		assert.Equal(t, 0.0125, DefaultPrices.Cost("gpt-4o-2024-05-13", usage))
+		assert.Equal(t, 0.00045, DefaultPrices.Cost("gpt-4o-mini", usage))
+		assert.Equal(t, 0.06, DefaultPrices.Cost("gpt-4", usage))
+		assert.Equal(t, 0.0, DefaultPrices.Cost("llama3", usage))
+	})
+
+	t.Run("prices from env", func(t *testing.T) {
+		t.Setenv("LLM_PRICES", "llama3=0.1:0.2, gpt-4o=2.5:10,invalid,mistral=a:1")
+		prices := PricesFromEnv()
+
+		assert.Equal(t, Price{Prompt: 0.1, Completion: 0.2}, prices["llama3"])
+		assert.Equal(t, Price{Prompt: 2.5, Completion: 10}, prices["gpt-4o"])
+		assert.Equal(t, DefaultPrices["gpt-3.5-turbo"], prices["gpt-3.5-turbo"])
+		_, ok := prices.Price("mistral")
+		assert.False(t, ok)
+		// The defaults are not changed
+		assert.Equal(t, Price{Prompt: 5, Completion: 15}, DefaultPrices["gpt-4o"])
+	})
+}
+
+func TestNewReport(t *testing.T) {
+	profileId := uuid.New()
+	otherProfileId := uuid.New()
+	days := []types.DailyUsage{
+		{ProfileID: profileId, Date: "2023-10-01", Calls: 2, Usage: types.TokenUsage{PromptTokens: 200, CompletionTokens: 100, TotalTokens: 300}, Cost: 0.1},
+		{ProfileID: otherProfileId, Date: "2023-10-01", Calls: 1, Usage: types.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, Cost: 0.2},
+		{ProfileID: profileId, Date: "2023-10-02", Calls: 1, Usage: types.TokenUsage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150}, Cost: 0.2},
+	}
+
+	t.Run("total of the period", func(t *testing.T) {
+		report := NewReport("2023-10-01", "2023-10-31", days, false)
+
+		assert.Equal(t, types.UsageSummary{Calls: 4, Usage: types.TokenUsage{PromptTokens: 310, CompletionTokens: 155, TotalTokens: 465}, Cost: 0.5}, report.Total)
+		assert.Equal(t, days, report.Days)
+		assert.Nil(t, report.Profiles)
+	})
+
+	t.Run("totals per profile by cost", func(t *testing.T) {
+		report := NewReport("2023-10-01", "2023-10-31", days, true)
+
+		assert.Equal(t, []types.ProfileUsage{
+			{ProfileID: profileId, Calls: 3, Usage: types.TokenUsage{PromptTokens: 300, CompletionTokens: 150, TotalTokens: 450}, Cost: 0.3},
+			{ProfileID: otherProfileId, Calls: 1, Usage: types.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, Cost: 0.2},
+		}, report.Profiles)
+	})
+
+	t.Run("without usage", func(t *testing.T) {
+		report := NewReport("2023-10-01", "2023-10-31", nil, true)
+
+		assert.Equal(t, []types.DailyUsage{}, report.Days)
+		assert.Equal(t, []types.ProfileUsage{}, report.Profiles)
+	})
+}
@@ -34,6 +34,18 @@ func (m *MockHandlerInterface) EXPECT() *MockHandlerInterfaceMockRecorder {
 	return m.recorder
 }
 
+// HandleAdminUsage mocks base method.
+func (m *MockHandlerInterface) HandleAdminUsage(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleAdminUsage", arg0)
+}
+
+// HandleAdminUsage indicates an expected call of HandleAdminUsage.
+func (mr *MockHandlerInterfaceMockRecorder) HandleAdminUsage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleAdminUsage", reflect.TypeOf((*MockHandlerInterface)(nil).HandleAdminUsage), arg0)
+}
+
 // HandleAuth mocks base method.
 func (m *MockHandlerInterface) HandleAuth(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
@@ -58,6 +70,18 @@ func (mr *MockHandlerInterfaceMockRecorder) HandleCoverLetter(arg0 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleCoverLetter", reflect.TypeOf((*MockHandlerInterface)(nil).HandleCoverLetter), arg0)
 }
 
+// HandleCoverLetterStream mocks base method.
+func (m *MockHandlerInterface) HandleCoverLetterStream(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleCoverLetterStream", arg0)
+}
+
+// HandleCoverLetterStream indicates an expected call of HandleCoverLetterStream.
+func (mr *MockHandlerInterfaceMockRecorder) HandleCoverLetterStream(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleCoverLetterStream", reflect.TypeOf((*MockHandlerInterface)(nil).HandleCoverLetterStream), arg0)
+}
+
 // HandleCreateCareerProfile mocks base method.
 func (m *MockHandlerInterface) HandleCreateCareerProfile(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
@@ -82,6 +106,18 @@ func (mr *MockHandlerInterfaceMockRecorder) HandleCreateJobApplication(arg0 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleCreateJobApplication", reflect.TypeOf((*MockHandlerInterface)(nil).HandleCreateJobApplication), arg0)
 }
 
+// HandleDeleteCoverLetter mocks base method.
+func (m *MockHandlerInterface) HandleDeleteCoverLetter(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleDeleteCoverLetter", arg0)
+}
+
+// HandleDeleteCoverLetter indicates an expected call of HandleDeleteCoverLetter.
+func (mr *MockHandlerInterfaceMockRecorder) HandleDeleteCoverLetter(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleDeleteCoverLetter", reflect.TypeOf((*MockHandlerInterface)(nil).HandleDeleteCoverLetter), arg0)
+}
+
 // HandleDeleteJobApplication mocks base method.
 func (m *MockHandlerInterface) HandleDeleteJobApplication(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
@@ -94,6 +130,30 @@ func (mr *MockHandlerInterfaceMockRecorder) HandleDeleteJobApplication(arg0 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleDeleteJobApplication", reflect.TypeOf((*MockHandlerInterface)(nil).HandleDeleteJobApplication), arg0)
 }
 
+// HandleExportCoverLetter mocks base method.
+func (m *MockHandlerInterface) HandleExportCoverLetter(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleExportCoverLetter", arg0)
+}
+
+// HandleExportCoverLetter indicates an expected call of HandleExportCoverLetter.
+func (mr *MockHandlerInterfaceMockRecorder) HandleExportCoverLetter(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleExportCoverLetter", reflect.TypeOf((*MockHandlerInterface)(nil).HandleExportCoverLetter), arg0)
+}
+
+// HandleExportResume mocks base method.
+func (m *MockHandlerInterface) HandleExportResume(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleExportResume", arg0)
+}
+
+// HandleExportResume indicates an expected call of HandleExportResume.
+func (mr *MockHandlerInterfaceMockRecorder) HandleExportResume(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleExportResume", reflect.TypeOf((*MockHandlerInterface)(nil).HandleExportResume), arg0)
+}
+
 // HandleGetCareerProfile mocks base method.
 func (m *MockHandlerInterface) HandleGetCareerProfile(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
@@ -106,6 +166,42 @@ func (mr *MockHandlerInterfaceMockRecorder) HandleGetCareerProfile(arg0 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleGetCareerProfile", reflect.TypeOf((*MockHandlerInterface)(nil).HandleGetCareerProfile), arg0)
 }
 
+// HandleGetCoverLetterByID mocks base method.
+func (m *MockHandlerInterface) HandleGetCoverLetterByID(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleGetCoverLetterByID", arg0)
+}
+
+// HandleGetCoverLetterByID indicates an expected call of HandleGetCoverLetterByID.
+func (mr *MockHandlerInterfaceMockRecorder) HandleGetCoverLetterByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleGetCoverLetterByID", reflect.TypeOf((*MockHandlerInterface)(nil).HandleGetCoverLetterByID), arg0)
+}
+
+// HandleGetCoverLetters mocks base method.
+func (m *MockHandlerInterface) HandleGetCoverLetters(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleGetCoverLetters", arg0)
+}
+
+// HandleGetCoverLetters indicates an expected call of HandleGetCoverLetters.
+func (mr *MockHandlerInterfaceMockRecorder) HandleGetCoverLetters(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleGetCoverLetters", reflect.TypeOf((*MockHandlerInterface)(nil).HandleGetCoverLetters), arg0)
+}
+
+// HandleGetInterviewPrep mocks base method.
+func (m *MockHandlerInterface) HandleGetInterviewPrep(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleGetInterviewPrep", arg0)
+}
+
+// HandleGetInterviewPrep indicates an expected call of HandleGetInterviewPrep.
+func (mr *MockHandlerInterfaceMockRecorder) HandleGetInterviewPrep(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleGetInterviewPrep", reflect.TypeOf((*MockHandlerInterface)(nil).HandleGetInterviewPrep), arg0)
+}
+
 // HandleGetJobApplicationByID mocks base method.
 func (m *MockHandlerInterface) HandleGetJobApplicationByID(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
@@ -118,6 +214,18 @@ func (mr *MockHandlerInterfaceMockRecorder) HandleGetJobApplicationByID(arg0 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleGetJobApplicationByID", reflect.TypeOf((*MockHandlerInterface)(nil).HandleGetJobApplicationByID), arg0)
 }
 
+// HandleGetJobApplicationCoverLetters mocks base method.
+func (m *MockHandlerInterface) HandleGetJobApplicationCoverLetters(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleGetJobApplicationCoverLetters", arg0)
+}
+
+// HandleGetJobApplicationCoverLetters indicates an expected call of HandleGetJobApplicationCoverLetters.
+func (mr *MockHandlerInterfaceMockRecorder) HandleGetJobApplicationCoverLetters(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleGetJobApplicationCoverLetters", reflect.TypeOf((*MockHandlerInterface)(nil).HandleGetJobApplicationCoverLetters), arg0)
+}
+
 // HandleGetJobApplications mocks base method.
 func (m *MockHandlerInterface) HandleGetJobApplications(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
@@ -130,6 +238,54 @@ func (mr *MockHandlerInterfaceMockRecorder) HandleGetJobApplications(arg0 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleGetJobApplications", reflect.TypeOf((*MockHandlerInterface)(nil).HandleGetJobApplications), arg0)
 }
 
+// HandleGetResumeByID mocks base method.
+func (m *MockHandlerInterface) HandleGetResumeByID(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleGetResumeByID", arg0)
+}
+
+// HandleGetResumeByID indicates an expected call of HandleGetResumeByID.
+func (mr *MockHandlerInterfaceMockRecorder) HandleGetResumeByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleGetResumeByID", reflect.TypeOf((*MockHandlerInterface)(nil).HandleGetResumeByID), arg0)
+}
+
+// HandleGetResumes mocks base method.
+func (m *MockHandlerInterface) HandleGetResumes(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleGetResumes", arg0)
+}
+
+// HandleGetResumes indicates an expected call of HandleGetResumes.
+func (mr *MockHandlerInterfaceMockRecorder) HandleGetResumes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleGetResumes", reflect.TypeOf((*MockHandlerInterface)(nil).HandleGetResumes), arg0)
+}
+
+// HandleGetUsage mocks base method.
+func (m *MockHandlerInterface) HandleGetUsage(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleGetUsage", arg0)
+}
+
+// HandleGetUsage indicates an expected call of HandleGetUsage.
+func (mr *MockHandlerInterfaceMockRecorder) HandleGetUsage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleGetUsage", reflect.TypeOf((*MockHandlerInterface)(nil).HandleGetUsage), arg0)
+}
+
+// HandleImportJobPosting mocks base method.
+func (m *MockHandlerInterface) HandleImportJobPosting(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleImportJobPosting", arg0)
+}
+
+// HandleImportJobPosting indicates an expected call of HandleImportJobPosting.
+func (mr *MockHandlerInterfaceMockRecorder) HandleImportJobPosting(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleImportJobPosting", reflect.TypeOf((*MockHandlerInterface)(nil).HandleImportJobPosting), arg0)
+}
+
 // HandleIndex mocks base method.
 func (m *MockHandlerInterface) HandleIndex(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
@@ -142,6 +298,30 @@ func (mr *MockHandlerInterfaceMockRecorder) HandleIndex(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleIndex", reflect.TypeOf((*MockHandlerInterface)(nil).HandleIndex), arg0)
 }
 
+// HandleInterviewPrep mocks base method.
+func (m *MockHandlerInterface) HandleInterviewPrep(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleInterviewPrep", arg0)
+}
+
+// HandleInterviewPrep indicates an expected call of HandleInterviewPrep.
+func (mr *MockHandlerInterfaceMockRecorder) HandleInterviewPrep(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleInterviewPrep", reflect.TypeOf((*MockHandlerInterface)(nil).HandleInterviewPrep), arg0)
+}
+
+// HandleJobApplicationCoverLetter mocks base method.
+func (m *MockHandlerInterface) HandleJobApplicationCoverLetter(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleJobApplicationCoverLetter", arg0)
+}
+
+// HandleJobApplicationCoverLetter indicates an expected call of HandleJobApplicationCoverLetter.
+func (mr *MockHandlerInterfaceMockRecorder) HandleJobApplicationCoverLetter(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleJobApplicationCoverLetter", reflect.TypeOf((*MockHandlerInterface)(nil).HandleJobApplicationCoverLetter), arg0)
+}
+
 // HandleLinkedInCallback mocks base method.
 func (m *MockHandlerInterface) HandleLinkedInCallback(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
@@ -153,3 +333,87 @@ func (mr *MockHandlerInterfaceMockRecorder) HandleLinkedInCallback(arg0 interfac
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleLinkedInCallback", reflect.TypeOf((*MockHandlerInterface)(nil).HandleLinkedInCallback), arg0)
 }
+
+// HandleOpenAPISpec mocks base method.
+func (m *MockHandlerInterface) HandleOpenAPISpec(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleOpenAPISpec", arg0)
+}
+
+// HandleOpenAPISpec indicates an expected call of HandleOpenAPISpec.
+func (mr *MockHandlerInterfaceMockRecorder) HandleOpenAPISpec(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleOpenAPISpec", reflect.TypeOf((*MockHandlerInterface)(nil).HandleOpenAPISpec), arg0)
+}
+
+// HandleParseJobPosting mocks base method.
+func (m *MockHandlerInterface) HandleParseJobPosting(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleParseJobPosting", arg0)
+}
+
+// HandleParseJobPosting indicates an expected call of HandleParseJobPosting.
+func (mr *MockHandlerInterfaceMockRecorder) HandleParseJobPosting(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleParseJobPosting", reflect.TypeOf((*MockHandlerInterface)(nil).HandleParseJobPosting), arg0)
+}
+
+// HandleReviseCoverLetter mocks base method.
+func (m *MockHandlerInterface) HandleReviseCoverLetter(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleReviseCoverLetter", arg0)
+}
+
+// HandleReviseCoverLetter indicates an expected call of HandleReviseCoverLetter.
+func (mr *MockHandlerInterfaceMockRecorder) HandleReviseCoverLetter(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleReviseCoverLetter", reflect.TypeOf((*MockHandlerInterface)(nil).HandleReviseCoverLetter), arg0)
+}
+
+// HandleSkillsGap mocks base method.
+func (m *MockHandlerInterface) HandleSkillsGap(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleSkillsGap", arg0)
+}
+
+// HandleSkillsGap indicates an expected call of HandleSkillsGap.
+func (mr *MockHandlerInterfaceMockRecorder) HandleSkillsGap(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleSkillsGap", reflect.TypeOf((*MockHandlerInterface)(nil).HandleSkillsGap), arg0)
+}
+
+// HandleSwaggerUI mocks base method.
+func (m *MockHandlerInterface) HandleSwaggerUI(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleSwaggerUI", arg0)
+}
+
+// HandleSwaggerUI indicates an expected call of HandleSwaggerUI.
+func (mr *MockHandlerInterfaceMockRecorder) HandleSwaggerUI(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleSwaggerUI", reflect.TypeOf((*MockHandlerInterface)(nil).HandleSwaggerUI), arg0)
+}
+
+// HandleTailorResume mocks base method.
+func (m *MockHandlerInterface) HandleTailorResume(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleTailorResume", arg0)
+}
+
+// HandleTailorResume indicates an expected call of HandleTailorResume.
+func (mr *MockHandlerInterfaceMockRecorder) HandleTailorResume(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleTailorResume", reflect.TypeOf((*MockHandlerInterface)(nil).HandleTailorResume), arg0)
+}
+
+// HandleUpdateCoverLetter mocks base method.
+func (m *MockHandlerInterface) HandleUpdateCoverLetter(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleUpdateCoverLetter", arg0)
+}
+
+// HandleUpdateCoverLetter indicates an expected call of HandleUpdateCoverLetter.
+func (mr *MockHandlerInterfaceMockRecorder) HandleUpdateCoverLetter(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleUpdateCoverLetter", reflect.TypeOf((*MockHandlerInterface)(nil).HandleUpdateCoverLetter), arg0)
+}
@@ -36,20 +36,67 @@ func (m *MockOpenAI) EXPECT() *MockOpenAIMockRecorder {
 	return m.recorder
 }
 
-// GenerateChatGPTCoverLetter mocks base method.
-func (m *MockOpenAI) GenerateChatGPTCoverLetter(arg0 *gin.Context, arg1 uuid.UUID, arg2 *types.JobPosting, arg3 types.StoreClient) (string, int, error) {
+// AnalyzeChatGPTSkillsGap mocks base method.
+func (m *MockOpenAI) AnalyzeChatGPTSkillsGap(arg0 *gin.Context, arg1 *types.CareerProfile, arg2 *types.JobPosting, arg3 *types.SkillsGapAnalysis) (*types.GeneratedSkillsGap, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateChatGPTCoverLetter", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "AnalyzeChatGPTSkillsGap", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*types.GeneratedSkillsGap)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AnalyzeChatGPTSkillsGap indicates an expected call of AnalyzeChatGPTSkillsGap.
+func (mr *MockOpenAIMockRecorder) AnalyzeChatGPTSkillsGap(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnalyzeChatGPTSkillsGap", reflect.TypeOf((*MockOpenAI)(nil).AnalyzeChatGPTSkillsGap), arg0, arg1, arg2, arg3)
+}
+
+// CoverLetterCacheKey mocks base method.
+func (m *MockOpenAI) CoverLetterCacheKey(arg0 uuid.UUID, arg1 *types.JobPosting, arg2 *types.CoverLetterOptions, arg3 types.StoreClient) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CoverLetterCacheKey", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CoverLetterCacheKey indicates an expected call of CoverLetterCacheKey.
+func (mr *MockOpenAIMockRecorder) CoverLetterCacheKey(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CoverLetterCacheKey", reflect.TypeOf((*MockOpenAI)(nil).CoverLetterCacheKey), arg0, arg1, arg2, arg3)
+}
+
+// GenerateChatGPTCoverLetter mocks base method.
+func (m *MockOpenAI) GenerateChatGPTCoverLetter(arg0 *gin.Context, arg1 uuid.UUID, arg2 *types.JobPosting, arg3 *types.CoverLetterOptions, arg4 types.StoreClient) (*types.GeneratedCoverLetter, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateChatGPTCoverLetter", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*types.GeneratedCoverLetter)
 	ret1, _ := ret[1].(int)
 	ret2, _ := ret[2].(error)
 	return ret0, ret1, ret2
 }
 
 // GenerateChatGPTCoverLetter indicates an expected call of GenerateChatGPTCoverLetter.
-func (mr *MockOpenAIMockRecorder) GenerateChatGPTCoverLetter(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockOpenAIMockRecorder) GenerateChatGPTCoverLetter(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateChatGPTCoverLetter", reflect.TypeOf((*MockOpenAI)(nil).GenerateChatGPTCoverLetter), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateChatGPTCoverLetter", reflect.TypeOf((*MockOpenAI)(nil).GenerateChatGPTCoverLetter), arg0, arg1, arg2, arg3, arg4)
+}
+
+// GenerateChatGPTCoverLetterVariants mocks base method.
+func (m *MockOpenAI) GenerateChatGPTCoverLetterVariants(arg0 *gin.Context, arg1 uuid.UUID, arg2 *types.JobPosting, arg3 *types.CoverLetterOptions, arg4 int, arg5 types.StoreClient) ([]types.GeneratedCoverLetter, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateChatGPTCoverLetterVariants", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].([]types.GeneratedCoverLetter)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GenerateChatGPTCoverLetterVariants indicates an expected call of GenerateChatGPTCoverLetterVariants.
+func (mr *MockOpenAIMockRecorder) GenerateChatGPTCoverLetterVariants(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateChatGPTCoverLetterVariants", reflect.TypeOf((*MockOpenAI)(nil).GenerateChatGPTCoverLetterVariants), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
 // GetCareerProfileInfoPrompt mocks base method.
@@ -68,6 +115,22 @@ func (mr *MockOpenAIMockRecorder) GetCareerProfileInfoPrompt(arg0, arg1 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCareerProfileInfoPrompt", reflect.TypeOf((*MockOpenAI)(nil).GetCareerProfileInfoPrompt), arg0, arg1)
 }
 
+// ParseChatGPTJobPosting mocks base method.
+func (m *MockOpenAI) ParseChatGPTJobPosting(arg0 *gin.Context, arg1 string) (*types.GeneratedJobPosting, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseChatGPTJobPosting", arg0, arg1)
+	ret0, _ := ret[0].(*types.GeneratedJobPosting)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ParseChatGPTJobPosting indicates an expected call of ParseChatGPTJobPosting.
+func (mr *MockOpenAIMockRecorder) ParseChatGPTJobPosting(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseChatGPTJobPosting", reflect.TypeOf((*MockOpenAI)(nil).ParseChatGPTJobPosting), arg0, arg1)
+}
+
 // ParseCoverLetter mocks base method.
 func (m *MockOpenAI) ParseCoverLetter(arg0 *string, arg1 *types.CareerProfile, arg2 *types.JobPosting) (string, error) {
 	m.ctrl.T.Helper()
@@ -82,3 +145,83 @@ func (mr *MockOpenAIMockRecorder) ParseCoverLetter(arg0, arg1, arg2 interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseCoverLetter", reflect.TypeOf((*MockOpenAI)(nil).ParseCoverLetter), arg0, arg1, arg2)
 }
+
+// PrepareChatGPTInterview mocks base method.
+func (m *MockOpenAI) PrepareChatGPTInterview(arg0 *gin.Context, arg1 *types.CareerProfile, arg2 *types.JobPosting, arg3 []types.JobApplicationEvent) (*types.GeneratedInterviewPrep, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrepareChatGPTInterview", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*types.GeneratedInterviewPrep)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// PrepareChatGPTInterview indicates an expected call of PrepareChatGPTInterview.
+func (mr *MockOpenAIMockRecorder) PrepareChatGPTInterview(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareChatGPTInterview", reflect.TypeOf((*MockOpenAI)(nil).PrepareChatGPTInterview), arg0, arg1, arg2, arg3)
+}
+
+// RankChatGPTCoverLetters mocks base method.
+func (m *MockOpenAI) RankChatGPTCoverLetters(arg0 *gin.Context, arg1 *types.JobPosting, arg2 *types.CoverLetterOptions, arg3 []types.GeneratedCoverLetter) (*types.CoverLetterRankings, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RankChatGPTCoverLetters", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*types.CoverLetterRankings)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RankChatGPTCoverLetters indicates an expected call of RankChatGPTCoverLetters.
+func (mr *MockOpenAIMockRecorder) RankChatGPTCoverLetters(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RankChatGPTCoverLetters", reflect.TypeOf((*MockOpenAI)(nil).RankChatGPTCoverLetters), arg0, arg1, arg2, arg3)
+}
+
+// ReviseChatGPTCoverLetter mocks base method.
+func (m *MockOpenAI) ReviseChatGPTCoverLetter(arg0 *gin.Context, arg1 *types.CoverLetter, arg2 string, arg3 types.StoreClient) (*types.GeneratedCoverLetter, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviseChatGPTCoverLetter", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*types.GeneratedCoverLetter)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ReviseChatGPTCoverLetter indicates an expected call of ReviseChatGPTCoverLetter.
+func (mr *MockOpenAIMockRecorder) ReviseChatGPTCoverLetter(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviseChatGPTCoverLetter", reflect.TypeOf((*MockOpenAI)(nil).ReviseChatGPTCoverLetter), arg0, arg1, arg2, arg3)
+}
+
+// StreamChatGPTCoverLetter mocks base method.
+func (m *MockOpenAI) StreamChatGPTCoverLetter(arg0 *gin.Context, arg1 uuid.UUID, arg2 *types.JobPosting, arg3 *types.CoverLetterOptions, arg4 types.StoreClient, arg5 func(string) error) (*types.GeneratedCoverLetter, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamChatGPTCoverLetter", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(*types.GeneratedCoverLetter)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StreamChatGPTCoverLetter indicates an expected call of StreamChatGPTCoverLetter.
+func (mr *MockOpenAIMockRecorder) StreamChatGPTCoverLetter(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamChatGPTCoverLetter", reflect.TypeOf((*MockOpenAI)(nil).StreamChatGPTCoverLetter), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// TailorChatGPTResume mocks base method.
+func (m *MockOpenAI) TailorChatGPTResume(arg0 *gin.Context, arg1 *types.CareerProfile, arg2 *types.JobPosting) (*types.GeneratedResume, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TailorChatGPTResume", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*types.GeneratedResume)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// TailorChatGPTResume indicates an expected call of TailorChatGPTResume.
+func (mr *MockOpenAIMockRecorder) TailorChatGPTResume(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TailorChatGPTResume", reflect.TypeOf((*MockOpenAI)(nil).TailorChatGPTResume), arg0, arg1, arg2)
+}
@@ -53,6 +53,20 @@ func (mr *MockStoreMockRecorder) Connect() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockStore)(nil).Connect))
 }
 
+// DeleteCoverLetter mocks base method.
+func (m *MockStore) DeleteCoverLetter(arg0, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCoverLetter", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCoverLetter indicates an expected call of DeleteCoverLetter.
+func (mr *MockStoreMockRecorder) DeleteCoverLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCoverLetter", reflect.TypeOf((*MockStore)(nil).DeleteCoverLetter), arg0, arg1)
+}
+
 // DeleteJobApplication mocks base method.
 func (m *MockStore) DeleteJobApplication(arg0 uuid.UUID) error {
 	m.ctrl.T.Helper()
@@ -79,6 +93,21 @@ func (mr *MockStoreMockRecorder) Disconnect(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disconnect", reflect.TypeOf((*MockStore)(nil).Disconnect), arg0, arg1)
 }
 
+// GetCachedCoverLetter mocks base method.
+func (m *MockStore) GetCachedCoverLetter(arg0 string) (*types.CachedCoverLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCachedCoverLetter", arg0)
+	ret0, _ := ret[0].(*types.CachedCoverLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCachedCoverLetter indicates an expected call of GetCachedCoverLetter.
+func (mr *MockStoreMockRecorder) GetCachedCoverLetter(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCachedCoverLetter", reflect.TypeOf((*MockStore)(nil).GetCachedCoverLetter), arg0)
+}
+
 // GetCareerProfileByEmail mocks base method.
 func (m *MockStore) GetCareerProfileByEmail(arg0 string) (*types.CareerProfile, error) {
 	m.ctrl.T.Helper()
@@ -109,6 +138,66 @@ func (mr *MockStoreMockRecorder) GetCareerProfileByID(arg0 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCareerProfileByID", reflect.TypeOf((*MockStore)(nil).GetCareerProfileByID), arg0)
 }
 
+// GetCoverLetterByID mocks base method.
+func (m *MockStore) GetCoverLetterByID(arg0, arg1 uuid.UUID) (*types.CoverLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCoverLetterByID", arg0, arg1)
+	ret0, _ := ret[0].(*types.CoverLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCoverLetterByID indicates an expected call of GetCoverLetterByID.
+func (mr *MockStoreMockRecorder) GetCoverLetterByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCoverLetterByID", reflect.TypeOf((*MockStore)(nil).GetCoverLetterByID), arg0, arg1)
+}
+
+// GetCoverLetters mocks base method.
+func (m *MockStore) GetCoverLetters(arg0 uuid.UUID) (*[]types.CoverLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCoverLetters", arg0)
+	ret0, _ := ret[0].(*[]types.CoverLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCoverLetters indicates an expected call of GetCoverLetters.
+func (mr *MockStoreMockRecorder) GetCoverLetters(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCoverLetters", reflect.TypeOf((*MockStore)(nil).GetCoverLetters), arg0)
+}
+
+// GetDailyUsage mocks base method.
+func (m *MockStore) GetDailyUsage(arg0 *uuid.UUID, arg1, arg2 string) (*[]types.DailyUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDailyUsage", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]types.DailyUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDailyUsage indicates an expected call of GetDailyUsage.
+func (mr *MockStoreMockRecorder) GetDailyUsage(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDailyUsage", reflect.TypeOf((*MockStore)(nil).GetDailyUsage), arg0, arg1, arg2)
+}
+
+// GetInterviewPrep mocks base method.
+func (m *MockStore) GetInterviewPrep(arg0, arg1 uuid.UUID) (*types.InterviewPrep, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInterviewPrep", arg0, arg1)
+	ret0, _ := ret[0].(*types.InterviewPrep)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInterviewPrep indicates an expected call of GetInterviewPrep.
+func (mr *MockStoreMockRecorder) GetInterviewPrep(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInterviewPrep", reflect.TypeOf((*MockStore)(nil).GetInterviewPrep), arg0, arg1)
+}
+
 // GetJobApplicationByID mocks base method.
 func (m *MockStore) GetJobApplicationByID(arg0 uuid.UUID) (*types.JobApplication, error) {
 	m.ctrl.T.Helper()
@@ -124,6 +213,21 @@ func (mr *MockStoreMockRecorder) GetJobApplicationByID(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJobApplicationByID", reflect.TypeOf((*MockStore)(nil).GetJobApplicationByID), arg0)
 }
 
+// GetJobApplicationCoverLetters mocks base method.
+func (m *MockStore) GetJobApplicationCoverLetters(arg0, arg1 uuid.UUID) (*[]types.CoverLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJobApplicationCoverLetters", arg0, arg1)
+	ret0, _ := ret[0].(*[]types.CoverLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJobApplicationCoverLetters indicates an expected call of GetJobApplicationCoverLetters.
+func (mr *MockStoreMockRecorder) GetJobApplicationCoverLetters(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJobApplicationCoverLetters", reflect.TypeOf((*MockStore)(nil).GetJobApplicationCoverLetters), arg0, arg1)
+}
+
 // GetJobApplications mocks base method.
 func (m *MockStore) GetJobApplications(arg0 uuid.UUID) (*[]types.JobApplication, error) {
 	m.ctrl.T.Helper()
@@ -139,6 +243,51 @@ func (mr *MockStoreMockRecorder) GetJobApplications(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJobApplications", reflect.TypeOf((*MockStore)(nil).GetJobApplications), arg0)
 }
 
+// GetResumeByID mocks base method.
+func (m *MockStore) GetResumeByID(arg0, arg1 uuid.UUID) (*types.Resume, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResumeByID", arg0, arg1)
+	ret0, _ := ret[0].(*types.Resume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetResumeByID indicates an expected call of GetResumeByID.
+func (mr *MockStoreMockRecorder) GetResumeByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResumeByID", reflect.TypeOf((*MockStore)(nil).GetResumeByID), arg0, arg1)
+}
+
+// GetResumes mocks base method.
+func (m *MockStore) GetResumes(arg0 uuid.UUID) (*[]types.Resume, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResumes", arg0)
+	ret0, _ := ret[0].(*[]types.Resume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetResumes indicates an expected call of GetResumes.
+func (mr *MockStoreMockRecorder) GetResumes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResumes", reflect.TypeOf((*MockStore)(nil).GetResumes), arg0)
+}
+
+// IncrementGenerationCount mocks base method.
+func (m *MockStore) IncrementGenerationCount(arg0 uuid.UUID, arg1 string, arg2 int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementGenerationCount", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementGenerationCount indicates an expected call of IncrementGenerationCount.
+func (mr *MockStoreMockRecorder) IncrementGenerationCount(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementGenerationCount", reflect.TypeOf((*MockStore)(nil).IncrementGenerationCount), arg0, arg1, arg2)
+}
+
 // StoreAccessToken mocks base method.
 func (m *MockStore) StoreAccessToken(arg0 uuid.UUID, arg1, arg2 string) (string, error) {
 	m.ctrl.T.Helper()
@@ -154,6 +303,20 @@ func (mr *MockStoreMockRecorder) StoreAccessToken(arg0, arg1, arg2 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreAccessToken", reflect.TypeOf((*MockStore)(nil).StoreAccessToken), arg0, arg1, arg2)
 }
 
+// StoreCachedCoverLetter mocks base method.
+func (m *MockStore) StoreCachedCoverLetter(arg0 *types.CachedCoverLetter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreCachedCoverLetter", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreCachedCoverLetter indicates an expected call of StoreCachedCoverLetter.
+func (mr *MockStoreMockRecorder) StoreCachedCoverLetter(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreCachedCoverLetter", reflect.TypeOf((*MockStore)(nil).StoreCachedCoverLetter), arg0)
+}
+
 // StoreCareerProfile mocks base method.
 func (m *MockStore) StoreCareerProfile(arg0 *types.CareerProfile) (*types.CareerProfile, string, error) {
 	m.ctrl.T.Helper()
@@ -170,6 +333,36 @@ func (mr *MockStoreMockRecorder) StoreCareerProfile(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreCareerProfile", reflect.TypeOf((*MockStore)(nil).StoreCareerProfile), arg0)
 }
 
+// StoreCoverLetter mocks base method.
+func (m *MockStore) StoreCoverLetter(arg0 *types.CoverLetter) (*types.CoverLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreCoverLetter", arg0)
+	ret0, _ := ret[0].(*types.CoverLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreCoverLetter indicates an expected call of StoreCoverLetter.
+func (mr *MockStoreMockRecorder) StoreCoverLetter(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreCoverLetter", reflect.TypeOf((*MockStore)(nil).StoreCoverLetter), arg0)
+}
+
+// StoreInterviewPrep mocks base method.
+func (m *MockStore) StoreInterviewPrep(arg0 *types.InterviewPrep) (*types.InterviewPrep, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreInterviewPrep", arg0)
+	ret0, _ := ret[0].(*types.InterviewPrep)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreInterviewPrep indicates an expected call of StoreInterviewPrep.
+func (mr *MockStoreMockRecorder) StoreInterviewPrep(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreInterviewPrep", reflect.TypeOf((*MockStore)(nil).StoreInterviewPrep), arg0)
+}
+
 // StoreJobApplication mocks base method.
 func (m *MockStore) StoreJobApplication(arg0 *types.JobApplication) (*types.JobApplication, string, error) {
 	m.ctrl.T.Helper()
@@ -186,6 +379,66 @@ func (mr *MockStoreMockRecorder) StoreJobApplication(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreJobApplication", reflect.TypeOf((*MockStore)(nil).StoreJobApplication), arg0)
 }
 
+// StoreResume mocks base method.
+func (m *MockStore) StoreResume(arg0 *types.Resume) (*types.Resume, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreResume", arg0)
+	ret0, _ := ret[0].(*types.Resume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreResume indicates an expected call of StoreResume.
+func (mr *MockStoreMockRecorder) StoreResume(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreResume", reflect.TypeOf((*MockStore)(nil).StoreResume), arg0)
+}
+
+// StoreUsageRecord mocks base method.
+func (m *MockStore) StoreUsageRecord(arg0 *types.UsageRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreUsageRecord", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreUsageRecord indicates an expected call of StoreUsageRecord.
+func (mr *MockStoreMockRecorder) StoreUsageRecord(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreUsageRecord", reflect.TypeOf((*MockStore)(nil).StoreUsageRecord), arg0)
+}
+
+// TakeRateLimitToken mocks base method.
+func (m *MockStore) TakeRateLimitToken(arg0 string, arg1 float64, arg2 int) (bool, float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeRateLimitToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(float64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// TakeRateLimitToken indicates an expected call of TakeRateLimitToken.
+func (mr *MockStoreMockRecorder) TakeRateLimitToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeRateLimitToken", reflect.TypeOf((*MockStore)(nil).TakeRateLimitToken), arg0, arg1, arg2)
+}
+
+// UpdateCoverLetterContent mocks base method.
+func (m *MockStore) UpdateCoverLetterContent(arg0, arg1 uuid.UUID, arg2 string) (*types.CoverLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCoverLetterContent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*types.CoverLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateCoverLetterContent indicates an expected call of UpdateCoverLetterContent.
+func (mr *MockStoreMockRecorder) UpdateCoverLetterContent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCoverLetterContent", reflect.TypeOf((*MockStore)(nil).UpdateCoverLetterContent), arg0, arg1, arg2)
+}
+
 // ValidateAccessToken mocks base method.
 func (m *MockStore) ValidateAccessToken(arg0 uuid.UUID, arg1, arg2 string) (bool, error) {
 	m.ctrl.T.Helper()
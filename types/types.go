@@ -2,22 +2,328 @@ package types
 
 import (
 	"context"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+type Response struct {
+	Data  interface{}            `json:"data"`
+	Error *ResponseError         `json:"error,omitempty"`
+	Meta  map[string]interface{} `json:"meta,omitempty"`
+}
+
+type ResponseError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+type ValidationErrorDetail struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
 type CoverLetterRequest struct {
-	ProfileID  uuid.UUID  `json:"profile_id"`
-	JobPosting JobPosting `json:"job_posting"`
+	ProfileID  uuid.UUID          `json:"profile_id" binding:"required"`
+	JobPosting JobPosting         `json:"job_posting"`
+	Options    CoverLetterOptions `json:"options"`
+	// Variants is the number of cover letters to generate, returning an array of variants when it is more than 1
+	Variants int `json:"n" binding:"omitempty,min=1,max=5"`
+	// Rank scores each variant against the job posting, sorting the variants by score
+	Rank bool `json:"rank"`
+	// ForceRefresh generates a new cover letter instead of returning the cached cover letter of an identical request
+	ForceRefresh bool `json:"force_refresh"`
+}
+
+// CoverLetterOptions customize the generated cover letter, using the defaults when omitted
+type CoverLetterOptions struct {
+	Tone       string `bson:"tone,omitempty" json:"tone,omitempty" binding:"omitempty,oneof=formal enthusiastic concise"`
+	WordCount  int    `bson:"word_count,omitempty" json:"word_count,omitempty" binding:"omitempty,min=100,max=1000"`
+	Paragraphs int    `bson:"paragraphs,omitempty" json:"paragraphs,omitempty" binding:"omitempty,min=1,max=6"`
+	// Language is a BCP 47 language tag (e.g. en, es, pt-BR)
+	Language   string `bson:"language,omitempty" json:"language,omitempty" binding:"omitempty,bcp47_language_tag"`
+	Creativity string `bson:"creativity,omitempty" json:"creativity,omitempty" binding:"omitempty,oneof=low medium high"`
+	Model      string `bson:"model,omitempty" json:"model,omitempty" binding:"omitempty,max=100"`
+}
+
+// CoverLetterDateLayout is the format of the date written in the generated cover letters, e.g. October 1, 2023
+const CoverLetterDateLayout = "January 2, 2006"
+
+// GeneratedCoverLetter is a cover letter generated by the LLM provider, with the model and prompt template version used
+type GeneratedCoverLetter struct {
+	Content string `bson:"content" json:"content"`
+	// Structured is the body of the cover letter when it was generated as JSON
+	Structured    *StructuredCoverLetter `bson:"structured,omitempty" json:"structured,omitempty"`
+	Model         string                 `bson:"model" json:"model"`
+	PromptVersion string                 `bson:"prompt_version" json:"prompt_version"`
+	Usage         TokenUsage             `bson:"usage" json:"usage"`
+	// Calls are the provider calls made to generate the cover letter: the summary of the job details, if any, and the
+	// completion followed by its continuations, whose total is Usage
+	Calls []ProviderCall `bson:"-" json:"-"`
+	// Placeholders are the bracket placeholders left in the content, e.g. [Hiring Manager's Name], to be filled by the user
+	Placeholders []string `bson:"placeholders,omitempty" json:"placeholders,omitempty"`
+	// Truncated are the fields of the job posting and career profile trimmed to fit the prompt in the context window, e.g. job_details
+	Truncated []string `bson:"truncated,omitempty" json:"truncated,omitempty"`
+	// Summarized are the fields summarized to fit the prompt in the context window, e.g. job_details
+	Summarized []string `bson:"summarized,omitempty" json:"summarized,omitempty"`
+	// Messages are the prompt messages followed by the generated completion, to continue the conversation
+	Messages []ChatGTPRequestMessage `bson:"messages" json:"-"`
+	// Date is the date written in the content, if any, which is replaced by the current date when the cover letter
+	// is returned from the cache
+	Date string `bson:"date,omitempty" json:"-"`
+	// CoverLetterID is the id of the cover letter saved in the history when it was generated, returned with the
+	// cached cover letter instead of saving it again
+	CoverLetterID *uuid.UUID `bson:"cover_letter_id,omitempty" json:"-"`
+}
+
+// CachedCoverLetter is a generated cover letter saved in the cache with the key of its generation request
+type CachedCoverLetter struct {
+	Key         string               `bson:"key"`
+	CoverLetter GeneratedCoverLetter `bson:"cover_letter"`
+	ExpiresAt   time.Time            `bson:"expires_at"`
+}
+
+// StructuredCoverLetter is the body of a cover letter generated as JSON, without the header and signature
+type StructuredCoverLetter struct {
+	Salutation string   `bson:"salutation" json:"salutation"`
+	Paragraphs []string `bson:"paragraphs" json:"paragraphs"`
+	Closing    string   `bson:"closing" json:"closing"`
+}
+
+// CoverLetterRanking is the score of a cover letter variant against the job posting
+type CoverLetterRanking struct {
+	// Variant is the 1-based position of the ranked cover letter
+	Variant int    `json:"variant"`
+	Score   int    `json:"score"`
+	Reason  string `json:"reason"`
+}
+
+type CoverLetterRankings struct {
+	Rankings      []CoverLetterRanking `json:"rankings"`
+	Model         string               `json:"model"`
+	PromptVersion string               `json:"prompt_version"`
+	Usage         TokenUsage           `json:"usage"`
+}
+
+// CoverLetterVariant is one of the cover letters generated in a request, with its ranking when requested
+type CoverLetterVariant struct {
+	CoverLetterID *uuid.UUID `json:"cover_letter_id,omitempty"`
+	Content       string     `json:"content"`
+	Usage         TokenUsage `json:"usage"`
+	Placeholders  []string   `json:"placeholders,omitempty"`
+	Rank          int        `json:"rank,omitempty"`
+	Score         *int       `json:"score,omitempty"`
+	Reason        string     `json:"reason,omitempty"`
+}
+
+// CoverLetter is a generated cover letter saved in the profile history
+type CoverLetter struct {
+	ID        uuid.UUID `bson:"id" json:"id"`
+	ProfileID uuid.UUID `bson:"profile_id" json:"profile_id"`
+	// JobApplicationID is set when the cover letter was generated for a job application
+	JobApplicationID *uuid.UUID         `bson:"job_application_id,omitempty" json:"job_application_id,omitempty"`
+	JobPosting       JobPosting         `bson:"job_posting" json:"job_posting"`
+	Options          CoverLetterOptions `bson:"options" json:"options"`
+	Content          string             `bson:"content" json:"content"`
+	// Structured is the generated body of the content, when it was generated as JSON
+	Structured    *StructuredCoverLetter `bson:"structured,omitempty" json:"structured,omitempty"`
+	Model         string                 `bson:"model" json:"model"`
+	PromptVersion string                 `bson:"prompt_version" json:"prompt_version"`
+	Usage         TokenUsage             `bson:"usage" json:"usage"`
+	// Messages are the conversation with the LLM provider that generated the content, continued by the revisions
+	Messages []ChatGTPRequestMessage `bson:"messages" json:"-"`
+	// Version starts at 1 and increases with each revision, which is saved as a new cover letter with the ParentID
+	// of the revised version, the user instruction, and the diff of the content against the revised version
+	Version     int        `bson:"version" json:"version"`
+	ParentID    *uuid.UUID `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+	Instruction string     `bson:"instruction,omitempty" json:"instruction,omitempty"`
+	Diff        string     `bson:"diff,omitempty" json:"diff,omitempty"`
+	// Edited is set when the user changed the generated content
+	Edited    bool   `bson:"edited" json:"edited"`
+	CreatedAt string `bson:"created_at" json:"created_at"`
+	UpdatedAt string `bson:"updated_at" json:"updated_at"`
+}
+
+type JobApplicationCoverLetterRequest struct {
+	Options CoverLetterOptions `json:"options"`
+}
+
+type CoverLetterRevisionRequest struct {
+	Instruction string `json:"instruction" binding:"required,max=1000"`
+}
+
+type CoverLetterUpdateRequest struct {
+	Content string `json:"content" binding:"required,max=20000"`
 }
 
 type JobPosting struct {
-	CompanyName string `json:"company_name" bind:"required"`
-	JobRole     string `json:"job_role" bind:"required"`
-	Details     string `json:"job_details"`
-	Skills      string `json:"skills"`
+	CompanyName string `bson:"company_name" json:"company_name" binding:"required,max=200"`
+	JobRole     string `bson:"job_role" json:"job_role" binding:"required,max=200"`
+	URL         string `bson:"url,omitempty" json:"url,omitempty" binding:"omitempty,url,max=2000"`
+	Details     string `bson:"job_details" json:"job_details" binding:"max=20000"`
+	Skills      string `bson:"skills" json:"skills" binding:"max=2000"`
+}
+
+type JobPostingParseRequest struct {
+	// Text is the job posting copied by the user, as plain text or HTML
+	Text string `json:"text" binding:"required,max=100000"`
+}
+
+type JobPostingImportRequest struct {
+	// URL is the page of the job posting, e.g. the URL of a job application
+	URL string `json:"url" binding:"required,url,max=2000"`
+}
+
+// ParsedJobPosting is the structured information of a job posting, with the JobPosting to prefill cover letters and job applications
+type ParsedJobPosting struct {
+	CompanyName string `json:"company_name"`
+	JobRole     string `json:"job_role"`
+	Location    string `json:"location"`
+	// Seniority is one of intern, junior, mid, senior, lead, principal, director or executive
+	Seniority        string       `json:"seniority"`
+	Salary           *SalaryRange `json:"salary"`
+	RequiredSkills   []string     `json:"required_skills"`
+	NiceToHaveSkills []string     `json:"nice_to_have_skills"`
+	Responsibilities []string     `json:"responsibilities"`
+	JobPosting       JobPosting   `json:"job_posting"`
+}
+
+type SalaryRange struct {
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Currency string  `json:"currency"`
+	// Period is one of hour, day, week, month or year
+	Period string `json:"period"`
+}
+
+// GeneratedJobPosting is a job posting parsed by the LLM provider, with the model and prompt template version used
+type GeneratedJobPosting struct {
+	JobPosting    ParsedJobPosting
+	Model         string
+	PromptVersion string
+	Usage         TokenUsage
+}
+
+type SkillsGapRequest struct {
+	JobPosting JobPosting `json:"job_posting"`
+}
+
+// SkillsGapAnalysis compares the skills of the career profile with the skills of a job posting
+type SkillsGapAnalysis struct {
+	// Score is how well the career profile matches the job posting, from 0 to 100
+	Score int `json:"score"`
+	// KeywordScore is the percentage of the skills of the job posting found in the career profile
+	KeywordScore int `json:"keyword_score"`
+	// LLMScore is the score of the LLM provider, and is nil when only the keywords were compared
+	LLMScore           *int                `json:"llm_score"`
+	Explanations       []string            `json:"explanations"`
+	MatchedSkills      []string            `json:"matched_skills"`
+	MissingSkills      []string            `json:"missing_skills"`
+	TransferableSkills []TransferableSkill `json:"transferable_skills"`
+}
+
+// TransferableSkill is a skill of the job posting missing from the career profile, which related skills can make up for
+type TransferableSkill struct {
+	Skill         string   `json:"skill"`
+	ProfileSkills []string `json:"profile_skills"`
+	Explanation   string   `json:"explanation"`
+}
+
+// GeneratedSkillsGap is a skills gap analysis of the LLM provider, with the model and prompt template version used
+type GeneratedSkillsGap struct {
+	Analysis      SkillsGapAnalysis
+	Model         string
+	PromptVersion string
+	Usage         TokenUsage
+}
+
+type ResumeTailorRequest struct {
+	JobPosting JobPosting `json:"job_posting"`
+}
+
+// Resume is a resume tailored to a job posting from the career profile and its work history
+type Resume struct {
+	ID         uuid.UUID  `bson:"id" json:"id"`
+	ProfileID  uuid.UUID  `bson:"profile_id" json:"profile_id"`
+	JobPosting JobPosting `bson:"job_posting" json:"job_posting"`
+	// Summary is written for the job posting
+	Summary string `bson:"summary" json:"summary"`
+	// Skills are the skills of the career profile, the most relevant to the job posting first
+	Skills []string `bson:"skills" json:"skills"`
+	// Experience are the positions of the work history, in the same order
+	Experience    []ResumeExperience `bson:"experience" json:"experience"`
+	Model         string             `bson:"model" json:"model"`
+	PromptVersion string             `bson:"prompt_version" json:"prompt_version"`
+	Usage         TokenUsage         `bson:"usage" json:"usage"`
+	CreatedAt     string             `bson:"created_at" json:"created_at"`
+	UpdatedAt     string             `bson:"updated_at" json:"updated_at"`
+}
+
+// ResumeExperience is a position of the work history, with its highlights rewritten as bullet points for the job
+// posting, the most relevant first
+type ResumeExperience struct {
+	Company   string   `bson:"company" json:"company"`
+	Title     string   `bson:"title" json:"title"`
+	Location  string   `bson:"location" json:"location"`
+	StartDate string   `bson:"start_date" json:"start_date"`
+	EndDate   string   `bson:"end_date" json:"end_date"`
+	Bullets   []string `bson:"bullets" json:"bullets"`
+}
+
+// Dates returns the period of the position, e.g. "Jan 2020 - Present"
+func (e ResumeExperience) Dates() string {
+	return dateRange(e.StartDate, e.EndDate)
+}
+
+// GeneratedResume is a resume tailored by the LLM provider, with the model and prompt template version used
+type GeneratedResume struct {
+	Resume        Resume
+	Model         string
+	PromptVersion string
+	Usage         TokenUsage
+}
+
+// InterviewPrep is the preparation of the interviews of a job application, generated from the career profile
+type InterviewPrep struct {
+	ID               uuid.UUID `bson:"id" json:"id"`
+	ProfileID        uuid.UUID `bson:"profile_id" json:"profile_id"`
+	JobApplicationID uuid.UUID `bson:"job_application_id" json:"job_application_id"`
+	// Questions are the likely interview questions, with an answer suggested from the career profile
+	Questions []InterviewQuestion `bson:"questions" json:"questions"`
+	// QuestionsToAsk are the questions for the candidate to ask the employer
+	QuestionsToAsk []string   `bson:"questions_to_ask" json:"questions_to_ask"`
+	Model          string     `bson:"model" json:"model"`
+	PromptVersion  string     `bson:"prompt_version" json:"prompt_version"`
+	Usage          TokenUsage `bson:"usage" json:"usage"`
+	CreatedAt      string     `bson:"created_at" json:"created_at"`
+	UpdatedAt      string     `bson:"updated_at" json:"updated_at"`
+}
+
+type InterviewQuestion struct {
+	Question string `bson:"question" json:"question"`
+	// Category is one of behavioral, technical, role, company or general
+	Category        string     `bson:"category" json:"category"`
+	SuggestedAnswer StarAnswer `bson:"suggested_answer" json:"suggested_answer"`
+}
+
+// StarAnswer is an answer in the STAR format: the situation, the task, the action taken and its result
+type StarAnswer struct {
+	Situation string `bson:"situation" json:"situation"`
+	Task      string `bson:"task" json:"task"`
+	Action    string `bson:"action" json:"action"`
+	Result    string `bson:"result" json:"result"`
+}
+
+// GeneratedInterviewPrep is an interview preparation of the LLM provider, with the model and prompt template version used
+type GeneratedInterviewPrep struct {
+	InterviewPrep InterviewPrep
+	Model         string
+	PromptVersion string
+	Usage         TokenUsage
 }
 
 type ChatGTPRequestMessage struct {
@@ -30,6 +336,92 @@ type ChatGPTRequest struct {
 	Messages    []ChatGTPRequestMessage `json:"messages"`
 	Temperature float32                 `json:"temperature"`
 	MaxTokens   int                     `json:"max_tokens"`
+	Stream      bool                    `json:"stream,omitempty"`
+	// StreamOptions asks for the token usage in the last chunk of a stream
+	StreamOptions *ChatGPTStreamOptions `json:"stream_options,omitempty"`
+	// ResponseFormat enables the JSON mode when its type is json_object
+	ResponseFormat *ChatGPTResponseFormat `json:"response_format,omitempty"`
+}
+
+type ChatGPTResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type ChatGPTStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// TokenUsage is the number of tokens of the prompt and completion of a LLM call
+type TokenUsage struct {
+	PromptTokens     int `bson:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens int `bson:"completion_tokens" json:"completion_tokens"`
+	TotalTokens      int `bson:"total_tokens" json:"total_tokens"`
+}
+
+// Add returns the sum of two token usages
+func (u TokenUsage) Add(other TokenUsage) TokenUsage {
+	return TokenUsage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:      u.TotalTokens + other.TotalTokens,
+	}
+}
+
+// ProviderCall is the token usage of a call to the LLM provider, with the model and prompt version to record it with
+type ProviderCall struct {
+	Model         string
+	PromptVersion string
+	Usage         TokenUsage
+}
+
+// UsageRecord is the token usage and estimated cost of a LLM call made for a profile
+type UsageRecord struct {
+	ID        uuid.UUID `bson:"id" json:"id"`
+	ProfileID uuid.UUID `bson:"profile_id" json:"profile_id"`
+	// Operation is what the call generated, e.g. cover_letter or cover_letter_ranking
+	Operation     string     `bson:"operation" json:"operation"`
+	Model         string     `bson:"model" json:"model"`
+	PromptVersion string     `bson:"prompt_version" json:"prompt_version"`
+	Usage         TokenUsage `bson:"usage" json:"usage"`
+	// Cost is the estimated cost of the call in USD
+	Cost float64 `bson:"cost" json:"cost"`
+	// Date is the day of the call, e.g. 2023-10-01, which the usage is aggregated by
+	Date      string `bson:"date" json:"date"`
+	CreatedAt string `bson:"created_at" json:"created_at"`
+}
+
+// UsageSummary is the number of LLM calls, their token usage and their estimated cost in USD
+type UsageSummary struct {
+	Calls int        `json:"calls"`
+	Usage TokenUsage `json:"usage"`
+	Cost  float64    `json:"cost"`
+}
+
+// DailyUsage is the usage of a profile on a day
+type DailyUsage struct {
+	ProfileID uuid.UUID  `bson:"profile_id" json:"profile_id"`
+	Date      string     `bson:"date" json:"date"`
+	Calls     int        `bson:"calls" json:"calls"`
+	Usage     TokenUsage `bson:"usage" json:"usage"`
+	Cost      float64    `bson:"cost" json:"cost"`
+}
+
+// ProfileUsage is the usage of a profile over the period of a usage report
+type ProfileUsage struct {
+	ProfileID uuid.UUID  `json:"profile_id"`
+	Calls     int        `json:"calls"`
+	Usage     TokenUsage `json:"usage"`
+	Cost      float64    `json:"cost"`
+}
+
+// UsageReport is the usage between two days, both included, with its total and the usage of each day
+type UsageReport struct {
+	From  string       `json:"from"`
+	To    string       `json:"to"`
+	Total UsageSummary `json:"total"`
+	// Profiles are the totals of each profile, only in the usage report of every profile
+	Profiles []ProfileUsage `json:"profiles,omitempty"`
+	Days     []DailyUsage   `json:"days"`
 }
 
 type ChatGPTResponseChoice struct {
@@ -43,44 +435,96 @@ type ChatGPTMessage struct {
 	Content string `json:"content"`
 }
 
+type ChatGPTError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+}
+
 type ChatGPTResponseData struct {
+	Model   string                  `json:"model"`
 	Choices []ChatGPTResponseChoice `json:"choices"`
+	Usage   *TokenUsage             `json:"usage,omitempty"`
+}
+
+type ChatGPTStreamChoice struct {
+	Index        int            `json:"index"`
+	Delta        ChatGPTMessage `json:"delta"`
+	FinishReason *string        `json:"finish_reason"`
+}
+
+type ChatGPTStreamResponseData struct {
+	Model   string                `json:"model"`
+	Choices []ChatGPTStreamChoice `json:"choices"`
+	Usage   *TokenUsage           `json:"usage,omitempty"`
+	Error   *ChatGPTError         `json:"error,omitempty"`
 }
 
 type CareerProfile struct {
 	ID              uuid.UUID    `bson:"id" json:"id"`
-	FirstName       string       `bson:"first_name" json:"first_name"`
-	LastName        string       `bson:"last_name" json:"last_name"`
-	Headline        string       `bson:"headline" json:"headline"`
-	ExperienceYears uint         `bson:"experience_years" json:"experience_years"`
-	Summary         *string      `bson:"summary" json:"summary"`
-	Skills          *[]string    `bson:"skills" json:"skills"`
-	ContactInfo     *ContactInfo `bson:"contact_info" json:"contact_info"`
+	FirstName       string       `bson:"first_name" json:"first_name" binding:"max=100"`
+	LastName        string       `bson:"last_name" json:"last_name" binding:"max=100"`
+	Headline        string       `bson:"headline" json:"headline" binding:"required,max=200"`
+	ExperienceYears uint         `bson:"experience_years" json:"experience_years" binding:"required,max=70"`
+	Summary         *string      `bson:"summary" json:"summary" binding:"omitempty,max=5000"`
+	Skills          *[]string    `bson:"skills" json:"skills" binding:"omitempty,max=100,dive,required,max=100"`
+	ContactInfo     *ContactInfo `bson:"contact_info" json:"contact_info" binding:"omitempty"`
+	// WorkHistory are the positions of the career profile, the most recent first, used to tailor resumes
+	WorkHistory *[]WorkExperience `bson:"work_history" json:"work_history" binding:"omitempty,max=30,dive"`
+}
+
+type WorkExperience struct {
+	Company  string `bson:"company" json:"company" binding:"required,max=200"`
+	Title    string `bson:"title" json:"title" binding:"required,max=200"`
+	Location string `bson:"location" json:"location" binding:"max=200"`
+	// StartDate and EndDate are written as they are shown in the resumes, e.g. "Jan 2020", and EndDate is empty for the current position
+	StartDate  string   `bson:"start_date" json:"start_date" binding:"max=50"`
+	EndDate    string   `bson:"end_date" json:"end_date" binding:"max=50"`
+	Highlights []string `bson:"highlights" json:"highlights" binding:"omitempty,max=20,dive,required,max=500"`
+}
+
+// Dates returns the period of the position, e.g. "Jan 2020 - Present"
+func (w WorkExperience) Dates() string {
+	return dateRange(w.StartDate, w.EndDate)
+}
+
+// dateRange returns the period between two dates, where an empty end date is the present
+func dateRange(startDate string, endDate string) string {
+	switch {
+	case startDate == "":
+		return endDate
+	case endDate == "":
+		return startDate + " - Present"
+	default:
+		return startDate + " - " + endDate
+	}
 }
 
 type ContactInfo struct {
-	Email   string `bson:"email" json:"email"`
-	Address string `bson:"address" json:"address"`
-	Phone   string `bson:"phone" json:"phone"`
-	Website string `bson:"website" json:"website"`
+	Email   string `bson:"email" json:"email" binding:"omitempty,email"`
+	Address string `bson:"address" json:"address" binding:"max=300"`
+	Phone   string `bson:"phone" json:"phone" binding:"max=50"`
+	Website string `bson:"website" json:"website" binding:"omitempty,url"`
 }
 
 type JobApplication struct {
 	ID          uuid.UUID              `bson:"id" json:"id"`
-	ProfileID   uuid.UUID              `bson:"profile_id" json:"profile_id"`
-	CompanyName string                 `bson:"company_name" json:"company_name" bind:"required"`
-	JobRole     string                 `bson:"job_role" json:"job_role" bind:"required"`
-	URL         *string                `bson:"url" json:"url"`
-	Events      *[]JobApplicationEvent `bson:"events" json:"events"`
+	ProfileID   uuid.UUID              `bson:"profile_id" json:"profile_id" binding:"required"`
+	CompanyName string                 `bson:"company_name" json:"company_name" binding:"required,max=200"`
+	JobRole     string                 `bson:"job_role" json:"job_role" binding:"required,max=200"`
+	URL         *string                `bson:"url" json:"url" binding:"omitempty,url"`
+	JobDetails  *string                `bson:"job_details" json:"job_details" binding:"omitempty,max=20000"`
+	Skills      *string                `bson:"skills" json:"skills" binding:"omitempty,max=2000"`
+	Events      *[]JobApplicationEvent `bson:"events" json:"events" binding:"omitempty,dive"`
 	CreatedAt   *string                `bson:"created_at" json:"created_at"`
 	UpdatedAt   *string                `bson:"updated_at" json:"updated_at"`
 }
 
 type JobApplicationEvent struct {
-	Type            uint    `bson:"type" json:"type"`
-	Description     string  `bson:"description" json:"description"`
-	Date            string  `bson:"date" json:"date"`
-	AdditionalNotes *string `bson:"additional_notes" json:"additional_notes"`
+	Type            uint    `bson:"type" json:"type" binding:"event_type"`
+	Description     string  `bson:"description" json:"description" binding:"max=500"`
+	Date            string  `bson:"date" json:"date" binding:"max=50"`
+	AdditionalNotes *string `bson:"additional_notes" json:"additional_notes" binding:"omitempty,max=5000"`
 }
 
 type LinkedInTokenResponse struct {
@@ -127,6 +571,10 @@ type AccessToken struct {
 	ExpiresAt   string    `bson:"expires_at" json:"expires_at"`
 }
 
+type AuthResponse struct {
+	ProfileID uuid.UUID `json:"profile_id"`
+}
+
 func MapToLinkedInUserData(data map[string]interface{}) LinkedInUserData {
 	var mappedData LinkedInUserData
 	if sub, ok := data["sub"].(string); ok {
@@ -152,13 +600,35 @@ func MapToLinkedInUserData(data map[string]interface{}) LinkedInUserData {
 
 type Handler interface {
 	HandleIndex(c *gin.Context)
+	HandleOpenAPISpec(c *gin.Context)
+	HandleSwaggerUI(c *gin.Context)
 	HandleCoverLetter(c *gin.Context)
+	HandleCoverLetterStream(c *gin.Context)
+	HandleGetCoverLetters(c *gin.Context)
+	HandleGetCoverLetterByID(c *gin.Context)
+	HandleUpdateCoverLetter(c *gin.Context)
+	HandleDeleteCoverLetter(c *gin.Context)
+	HandleReviseCoverLetter(c *gin.Context)
+	HandleExportCoverLetter(c *gin.Context)
 	HandleCreateCareerProfile(c *gin.Context)
 	HandleGetCareerProfile(c *gin.Context)
 	HandleCreateJobApplication(c *gin.Context)
 	HandleGetJobApplications(c *gin.Context)
 	HandleGetJobApplicationByID(c *gin.Context)
 	HandleDeleteJobApplication(c *gin.Context)
+	HandleJobApplicationCoverLetter(c *gin.Context)
+	HandleGetJobApplicationCoverLetters(c *gin.Context)
+	HandleInterviewPrep(c *gin.Context)
+	HandleGetInterviewPrep(c *gin.Context)
+	HandleParseJobPosting(c *gin.Context)
+	HandleImportJobPosting(c *gin.Context)
+	HandleSkillsGap(c *gin.Context)
+	HandleTailorResume(c *gin.Context)
+	HandleGetResumes(c *gin.Context)
+	HandleGetResumeByID(c *gin.Context)
+	HandleExportResume(c *gin.Context)
+	HandleGetUsage(c *gin.Context)
+	HandleAdminUsage(c *gin.Context)
 	HandleLinkedInCallback(c *gin.Context)
 	HandleAuth(c *gin.Context)
 }
@@ -175,10 +645,36 @@ type StoreClient interface {
 	DeleteJobApplication(jobApplicationId uuid.UUID) error
 	StoreAccessToken(profileId uuid.UUID, accessToken string, ipAddress string) (string, error)
 	ValidateAccessToken(profileId uuid.UUID, accessToken string, ipAddress string) (bool, error)
+	TakeRateLimitToken(key string, ratePerSecond float64, burst int) (bool, float64, error)
+	IncrementGenerationCount(profileId uuid.UUID, period string, delta int) (int, error)
+	StoreCoverLetter(coverLetter *CoverLetter) (*CoverLetter, error)
+	GetCoverLetters(profileId uuid.UUID) (*[]CoverLetter, error)
+	GetJobApplicationCoverLetters(profileId uuid.UUID, jobApplicationId uuid.UUID) (*[]CoverLetter, error)
+	GetCoverLetterByID(profileId uuid.UUID, coverLetterId uuid.UUID) (*CoverLetter, error)
+	UpdateCoverLetterContent(profileId uuid.UUID, coverLetterId uuid.UUID, content string) (*CoverLetter, error)
+	DeleteCoverLetter(profileId uuid.UUID, coverLetterId uuid.UUID) error
+	GetCachedCoverLetter(key string) (*CachedCoverLetter, error)
+	StoreCachedCoverLetter(cachedCoverLetter *CachedCoverLetter) error
+	StoreUsageRecord(usageRecord *UsageRecord) error
+	GetDailyUsage(profileId *uuid.UUID, from string, to string) (*[]DailyUsage, error)
+	StoreResume(resume *Resume) (*Resume, error)
+	GetResumes(profileId uuid.UUID) (*[]Resume, error)
+	GetResumeByID(profileId uuid.UUID, resumeId uuid.UUID) (*Resume, error)
+	StoreInterviewPrep(interviewPrep *InterviewPrep) (*InterviewPrep, error)
+	GetInterviewPrep(profileId uuid.UUID, jobApplicationId uuid.UUID) (*InterviewPrep, error)
 }
 
 type OpenAIClient interface {
-	GenerateChatGPTCoverLetter(c *gin.Context, profileId uuid.UUID, jobPosting *JobPosting, s StoreClient) (string, int, error)
+	GenerateChatGPTCoverLetter(c *gin.Context, profileId uuid.UUID, jobPosting *JobPosting, options *CoverLetterOptions, s StoreClient) (*GeneratedCoverLetter, int, error)
+	StreamChatGPTCoverLetter(c *gin.Context, profileId uuid.UUID, jobPosting *JobPosting, options *CoverLetterOptions, s StoreClient, onDelta func(delta string) error) (*GeneratedCoverLetter, int, error)
+	GenerateChatGPTCoverLetterVariants(c *gin.Context, profileId uuid.UUID, jobPosting *JobPosting, options *CoverLetterOptions, variants int, s StoreClient) ([]GeneratedCoverLetter, int, error)
+	RankChatGPTCoverLetters(c *gin.Context, jobPosting *JobPosting, options *CoverLetterOptions, coverLetters []GeneratedCoverLetter) (*CoverLetterRankings, int, error)
+	ReviseChatGPTCoverLetter(c *gin.Context, coverLetter *CoverLetter, instruction string, s StoreClient) (*GeneratedCoverLetter, int, error)
+	CoverLetterCacheKey(profileId uuid.UUID, jobPosting *JobPosting, options *CoverLetterOptions, s StoreClient) (string, error)
 	GetCareerProfileInfoPrompt(profileId uuid.UUID, s StoreClient) (string, *CareerProfile, error)
+	ParseChatGPTJobPosting(c *gin.Context, text string) (*GeneratedJobPosting, int, error)
+	AnalyzeChatGPTSkillsGap(c *gin.Context, careerProfile *CareerProfile, jobPosting *JobPosting, keywordMatch *SkillsGapAnalysis) (*GeneratedSkillsGap, int, error)
+	TailorChatGPTResume(c *gin.Context, careerProfile *CareerProfile, jobPosting *JobPosting) (*GeneratedResume, int, error)
+	PrepareChatGPTInterview(c *gin.Context, careerProfile *CareerProfile, jobPosting *JobPosting, interviews []JobApplicationEvent) (*GeneratedInterviewPrep, int, error)
 	ParseCoverLetter(coverLetter *string, careerProfile *CareerProfile, jobPosting *JobPosting) (string, error)
 }